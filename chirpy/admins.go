@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// promoteAdmins makes the users with the given emails admins. It runs at
+// startup with ADMIN_EMAILS, which is how the first admin is made.
+// Emails without an account are logged and skipped, since whoever signs
+// up with one later hasn't proven they own it: create the account, then
+// restart. Admins missing from the list are left as they are.
+func promoteAdmins(ctx context.Context, store database.Store, emails []string) error {
+	for _, email := range emails {
+		user, err := store.GetUserByEmail(ctx, email)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Couldn't make %s an admin: no user has that email", email)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("looking up admin %s: %w", email, err)
+		}
+		if user.IsAdmin {
+			continue
+		}
+		_, err = store.SetUserAdmin(ctx, database.SetUserAdminParams{ID: user.ID, IsAdmin: true})
+		if err != nil {
+			return fmt.Errorf("making %s an admin: %w", email, err)
+		}
+		log.Printf("Made %s an admin", email)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPromoteAdmins(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	_, alice := newTestUser(t, srv, "alice@example.com")
+	_, bob := newTestUser(t, srv, "bob@example.com")
+
+	emails := []string{"alice@example.com", "nobody@example.com"}
+	if err := promoteAdmins(ctx, cfg.db, emails); err != nil {
+		t.Fatalf("promoteAdmins() error = %v", err)
+	}
+	// Running it again, as every restart does, changes nothing.
+	if err := promoteAdmins(ctx, cfg.db, emails); err != nil {
+		t.Fatalf("promoteAdmins() again error = %v", err)
+	}
+
+	for _, tc := range []struct {
+		email string
+		admin bool
+	}{
+		{alice.Email, true},
+		{bob.Email, false},
+	} {
+		user, err := cfg.db.GetUserByEmail(ctx, tc.email)
+		if err != nil {
+			t.Fatalf("GetUserByEmail(%s) error = %v", tc.email, err)
+		}
+		if user.IsAdmin != tc.admin {
+			t.Errorf("%s IsAdmin = %v, want %v", tc.email, user.IsAdmin, tc.admin)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// Audited actions.
+const (
+	auditLoginSucceeded = "login.succeeded"
+	auditLoginFailed    = "login.failed"
+	auditTokenRefreshed = "token.refreshed"
+	auditTokenRevoked   = "token.revoked"
+	auditUserUpdated    = "user.updated"
+	auditUserUpgraded   = "user.upgraded"
+	auditChirpDeleted   = "chirp.deleted"
+	auditAdminReset     = "admin.reset"
+)
+
+// Kinds of audit targets.
+const (
+	auditTargetUser  = "user"
+	auditTargetEmail = "email"
+	auditTargetChirp = "chirp"
+)
+
+// auditRedacted stands in for secrets in diffs, so they show that a
+// password changed but not what to.
+const auditRedacted = "[redacted]"
+
+// auditRecord is an audit event before the request details are added.
+// ActorID is uuid.Nil when nobody signed in did it, as with failed
+// logins and payment provider webhooks.
+type auditRecord struct {
+	Action     string
+	ActorID    uuid.UUID
+	TargetType string
+	TargetID   string
+	Diff       auditDiff
+}
+
+// auditDiff maps the fields an action changed to their old and new
+// values.
+type auditDiff map[string]auditChange
+
+type auditChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// writeAudit stores rec with r's IP and request ID using store. Pass the
+// transaction an action runs in so the record commits or rolls back
+// with it.
+func writeAudit(r *http.Request, store database.Store, rec auditRecord) error {
+	diff := []byte("{}")
+	if len(rec.Diff) > 0 {
+		var err error
+		diff, err = json.Marshal(rec.Diff)
+		if err != nil {
+			return err
+		}
+	}
+	return store.CreateAuditEvent(r.Context(), database.CreateAuditEventParams{
+		Action:     rec.Action,
+		ActorID:    uuid.NullUUID{UUID: rec.ActorID, Valid: rec.ActorID != uuid.Nil},
+		TargetType: rec.TargetType,
+		TargetID:   rec.TargetID,
+		Ip:         clientIP(r),
+		RequestID:  requestID(r),
+		Diff:       diff,
+	})
+}
+
+// audit records an action that has already happened outside of any
+// transaction, even if the client has gone away meanwhile. Failing to is
+// logged rather than failing the request.
+func (cfg *apiConfig) audit(r *http.Request, rec auditRecord) {
+	if err := writeAudit(r.WithContext(context.WithoutCancel(r.Context())), cfg.db, rec); err != nil {
+		log.Printf("Couldn't record %s audit event: %s", rec.Action, err)
+	}
+}
+
+// chirpDeletedRecord is the audit record of actorID deleting chirp.
+func chirpDeletedRecord(actorID uuid.UUID, chirp database.Chirp) auditRecord {
+	return auditRecord{
+		Action:     auditChirpDeleted,
+		ActorID:    actorID,
+		TargetType: auditTargetChirp,
+		TargetID:   chirp.ID.String(),
+		Diff: auditDiff{
+			"body":    {Old: chirp.Body, New: nil},
+			"user_id": {Old: chirp.UserID, New: nil},
+		},
+	}
+}
+
+// userDiff lists the account and profile fields that differ between
+// before and after.
+func userDiff(before, after database.User) auditDiff {
+	diff := auditDiff{}
+	add := func(field string, old, new any) {
+		if old != new {
+			diff[field] = auditChange{Old: old, New: new}
+		}
+	}
+	add("email", before.Email, after.Email)
+	add("handle", before.Handle.String, after.Handle.String)
+	add("display_name", before.DisplayName, after.DisplayName)
+	add("bio", before.Bio, after.Bio)
+	add("avatar_url", before.AvatarUrl, after.AvatarUrl)
+	add("is_chirpy_red", before.IsChirpyRed, after.IsChirpyRed)
+	if before.HashedPassword != after.HashedPassword {
+		diff["password"] = auditChange{Old: auditRedacted, New: auditRedacted}
+	}
+	return diff
+}
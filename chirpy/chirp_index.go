@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/chirptext"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// indexChirp stores the hashtags and mentions in a new chirp's body and
+// notifies the users it mentions.
+func indexChirp(ctx context.Context, tx database.Store, chirp database.Chirp) error {
+	if err := indexHashtags(ctx, tx, chirp); err != nil {
+		return err
+	}
+
+	for _, mention := range chirptext.Mentions(chirp.Body) {
+		user, err := resolveMention(ctx, tx, mention)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if user.ID == chirp.UserID {
+			continue
+		}
+		// Mentions across a block stay plain text: no link, no notification.
+		blocked, err := tx.IsBlockedBetween(ctx, database.IsBlockedBetweenParams{
+			UserA: user.ID,
+			UserB: chirp.UserID,
+		})
+		if err != nil {
+			return err
+		}
+		if blocked {
+			continue
+		}
+
+		err = tx.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID: chirp.ID,
+			UserID:  user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateNotification(ctx, database.CreateNotificationParams{
+			UserID:  user.ID,
+			ActorID: chirp.UserID,
+			Kind:    notificationMention,
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexHashtags stores the hashtags in a chirp's body.
+func indexHashtags(ctx context.Context, tx database.Store, chirp database.Chirp) error {
+	for _, tag := range chirptext.Hashtags(chirp.Body) {
+		err := tx.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			Tag:       tag,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveMention finds the user a mention refers to. Mentions that don't
+// match a user return sql.ErrNoRows.
+func resolveMention(ctx context.Context, tx database.Store, mention chirptext.Mention) (database.User, error) {
+	if mention.IsEmail {
+		return tx.GetUserByEmail(ctx, mention.Text)
+	}
+	if database.CheckHandle(mention.Text) != "" {
+		return database.User{}, sql.ErrNoRows
+	}
+	return tx.GetUserByHandle(ctx, mention.Text)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+)
+
+// chirpPublisher publishes scheduled chirps once they are due. Several
+// publishers, in one process or many, can share a database: each due
+// chirp is locked by the first to claim it and skipped by the others.
+type chirpPublisher struct {
+	cfg *apiConfig
+	// Interval is how often scheduled chirps are polled.
+	Interval time.Duration
+	// BatchSize is the most chirps published per poll.
+	BatchSize int32
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+func (cfg *apiConfig) newChirpPublisher() *chirpPublisher {
+	return &chirpPublisher{
+		cfg:       cfg,
+		Interval:  time.Second,
+		BatchSize: 100,
+		Now:       time.Now,
+	}
+}
+
+// Run publishes due chirps every Interval until ctx is done.
+func (p *chirpPublisher) Run(ctx context.Context) {
+	log.Printf("Publishing scheduled chirps every %s...", p.Interval)
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.PublishDue(ctx); err != nil {
+			log.Printf("Couldn't publish scheduled chirps: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishRetryDelay is how long a scheduled chirp that failed to publish
+// is left alone before the publisher tries it again.
+const publishRetryDelay = 10 * time.Minute
+
+// PublishDue moves the scheduled chirps that are due into the timeline,
+// keeping their IDs, and returns how many it published. Like a chirp
+// created directly, each is indexed, announced to webhooks and streamed.
+//
+// Each chirp is published in its own transaction, so one that fails is
+// logged and marked, to be retried after publishRetryDelay, without
+// holding back the rest. Chirps by suspended authors wait until the
+// suspension ends, and ones by deleted authors are never published.
+func (p *chirpPublisher) PublishDue(ctx context.Context) (int, error) {
+	now := p.Now().UTC()
+	published := 0
+	for attempted := int32(0); attempted < p.BatchSize; attempted++ {
+		var scheduled *database.ScheduledChirp
+		var chirp database.Chirp
+		err := p.cfg.db.InTx(ctx, func(tx database.Store) error {
+			due, err := tx.ClaimDueScheduledChirps(ctx, database.ClaimDueScheduledChirpsParams{
+				Now:               now,
+				RetryFailedBefore: sql.NullTime{Time: now.Add(-publishRetryDelay), Valid: true},
+				MaxChirps:         1,
+			})
+			if err != nil || len(due) == 0 {
+				return err
+			}
+			scheduled = &due[0]
+
+			chirp, err = tx.PublishScheduledChirp(ctx, database.PublishScheduledChirpParams{
+				ID:        scheduled.ID,
+				CreatedAt: now,
+				Body:      scheduled.Body,
+				UserID:    scheduled.UserID,
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := tx.DeleteScheduledChirp(ctx, scheduled.ID); err != nil {
+				return err
+			}
+			return chirpCreated(ctx, tx, chirp)
+		})
+		if scheduled == nil {
+			// Nothing was due, or it couldn't be claimed.
+			return published, err
+		}
+		if err != nil {
+			log.Printf("Couldn't publish scheduled chirp %s: %s", scheduled.ID, err)
+			err = p.cfg.db.MarkScheduledChirpFailed(ctx, database.MarkScheduledChirpFailedParams{
+				ID:       scheduled.ID,
+				FailedAt: sql.NullTime{Time: now, Valid: true},
+			})
+			if err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		p.cfg.publishChirpEvent(ctx, events.TypeChirpCreated, chirpFromDB(chirp))
+		published++
+	}
+	return published, nil
+}
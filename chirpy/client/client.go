@@ -0,0 +1,169 @@
+// Package client is a typed Go client for the chirpy API.
+//
+// The request and response types and one method per API operation are
+// generated from chirpy's OpenAPI document into client.gen.go. Run
+// go generate after changing openapi.json.
+package client
+
+//go:generate go run ../internal/tools/clientgen -spec ../openapi.json -out client.gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client calls a chirpy server. Set the credential fields that the
+// operations you call require.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	// AccessToken is sent as a bearer token to operations using the
+	// bearerAuth scheme.
+	AccessToken string
+	// RefreshToken is sent as a bearer token to /api/refresh and
+	// /api/revoke.
+	RefreshToken string
+	// APIKey is sent to operations using the polkaApiKey scheme.
+	APIKey string
+}
+
+// New returns a Client for the chirpy server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// APIError is returned when the server responds with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable error code from the response, such
+	// as "validation_failed".
+	Code    string
+	Message string
+	// Details lists problems with individual request fields.
+	Details []FieldError
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("chirpy: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("chirpy: %d %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) authorize(req *http.Request, security string) error {
+	switch security {
+	case "":
+		return nil
+	case "bearerAuth":
+		// Some operations only optionally authenticate, so anonymous
+		// clients send no token at all.
+		if c.AccessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+		}
+	case "refreshToken":
+		req.Header.Set("Authorization", "Bearer "+c.RefreshToken)
+	case "polkaApiKey":
+		req.Header.Set("Authorization", "ApiKey "+c.APIKey)
+	default:
+		return fmt.Errorf("chirpy: unknown security scheme %q", security)
+	}
+	return nil
+}
+
+// rawBody is a request body sent as is instead of being encoded as JSON.
+type rawBody struct {
+	contentType string
+	r           io.Reader
+}
+
+// do sends a request and decodes the response into out, which is either
+// nil, a *string for text responses, an *io.ReadCloser for streamed
+// responses or a pointer to a JSON value. body is nil, a rawBody or a
+// value to send as JSON.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, security string, body, out any) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	contentType := ""
+	switch body := body.(type) {
+	case nil:
+	case rawBody:
+		reqBody = body.r
+		contentType = body.contentType
+	default:
+		dat, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(dat)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if err := c.authorize(req, security); err != nil {
+		return err
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if stream, ok := out.(*io.ReadCloser); ok && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		*stream = resp.Body
+		return nil
+	}
+	defer resp.Body.Close()
+
+	dat, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		errBody := Error{}
+		if json.Unmarshal(dat, &errBody) == nil {
+			apiErr.Code = errBody.Code
+			apiErr.Message = errBody.Error
+			apiErr.Details = errBody.Details
+		}
+		return apiErr
+	}
+
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *string:
+		*out = string(dat)
+		return nil
+	default:
+		return json.Unmarshal(dat, out)
+	}
+}
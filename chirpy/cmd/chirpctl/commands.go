@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"golang.org/x/term"
+)
+
+func cmdLogin(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("login", "login -email EMAIL [-password PASSWORD]")
+	email := fs.String("email", c.conf.Email, "account email")
+	password := fs.String("password", "", "account password, read from the terminal or stdin if not given")
+	if err := c.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if *email == "" {
+		fs.Usage()
+		return errUsage
+	}
+	if *password == "" {
+		pw, err := c.readPassword()
+		if err != nil {
+			return err
+		}
+		*password = pw
+	}
+
+	resp, err := c.client.Login(ctx, client.Credentials{Email: *email, Password: *password})
+	if err != nil {
+		return err
+	}
+	c.conf = config{
+		Server:       c.client.BaseURL,
+		UserID:       resp.ID.String(),
+		Email:        resp.Email,
+		AccessToken:  resp.Token,
+		RefreshToken: resp.RefreshToken,
+	}
+	if err := saveConfig(c.configPath, c.conf); err != nil {
+		return err
+	}
+	return c.printUser(resp.User)
+}
+
+// readPassword prompts for a password without echoing it when stdin is a
+// terminal, and otherwise reads the first line of stdin.
+func (c *cli) readPassword() (string, error) {
+	if f, ok := c.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(c.stderr, "Password: ")
+		pw, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(c.stderr)
+		return string(pw), err
+	}
+	line, err := bufio.NewReader(c.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("no password given")
+	}
+	return line, nil
+}
+
+func cmdLogout(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("logout", "logout")
+	if err := c.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	if c.client.RefreshToken == "" {
+		return errNotLoggedIn
+	}
+	err := c.client.Revoke(ctx)
+	// A token the server already forgot is as good as revoked.
+	apiErr := &client.APIError{}
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized) {
+		return err
+	}
+	c.conf.AccessToken = ""
+	c.conf.RefreshToken = ""
+	if err := saveConfig(c.configPath, c.conf); err != nil {
+		return err
+	}
+	return c.printMessage("Logged out of " + c.client.BaseURL)
+}
+
+func cmdPost(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("post", "post [-at TIME] [BODY...]")
+	at := fs.String("at", "", "publish the chirp at this RFC 3339 `time` instead of now")
+	if err := c.parse(fs, args, 0, -1); err != nil {
+		return err
+	}
+
+	req := client.CreateChirpRequest{Body: strings.Join(fs.Args(), " ")}
+	if fs.NArg() == 0 {
+		dat, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+		req.Body = strings.TrimRight(string(dat), "\r\n")
+	}
+	if *at != "" {
+		publishAt, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return fmt.Errorf("-at must be an RFC 3339 time: %w", err)
+		}
+		req.PublishAt = &publishAt
+	}
+
+	var chirp client.Chirp
+	err := c.authed(ctx, func() (err error) {
+		chirp, err = c.client.CreateChirp(ctx, req)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return c.printChirp(chirp)
+}
+
+func cmdList(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("list", "list [-author ID | -mine | -user HANDLE | -hashtag TAG] [-sort asc|desc] [-limit N]")
+	author := fs.String("author", "", "only chirps by the user with this `ID`")
+	mine := fs.Bool("mine", false, "only chirps by the logged-in user")
+	handle := fs.String("user", "", "only chirps by the user with this `handle`")
+	hashtag := fs.String("hashtag", "", "only chirps using this hashtag")
+	sort := fs.String("sort", "", "sort by creation time, asc or desc")
+	limit := fs.Int("limit", 0, "show at most `N` chirps")
+	if err := c.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	filters := 0
+	for _, set := range []bool{*author != "", *mine, *handle != "", *hashtag != ""} {
+		if set {
+			filters++
+		}
+	}
+	if filters > 1 {
+		return errors.New("use only one of -author, -mine, -user and -hashtag")
+	}
+	if *mine {
+		if c.conf.UserID == "" || c.client.RefreshToken == "" {
+			return errNotLoggedIn
+		}
+		*author = c.conf.UserID
+	}
+	var sortParam *string
+	if *sort != "" {
+		sortParam = sort
+	}
+
+	var list func() ([]client.Chirp, error)
+	switch {
+	case *handle != "":
+		list = func() ([]client.Chirp, error) {
+			return c.client.GetProfileChirps(ctx, strings.TrimPrefix(*handle, "@"), &client.GetProfileChirpsParams{Sort: sortParam})
+		}
+	case *hashtag != "":
+		params := &client.GetHashtagChirpsParams{Sort: sortParam}
+		if *limit > 0 {
+			// The first page is all list shows; the server caps pages at 100.
+			pageSize := min(*limit, 100)
+			params.Limit = &pageSize
+		}
+		list = func() ([]client.Chirp, error) {
+			page, err := c.client.GetHashtagChirps(ctx, strings.TrimPrefix(*hashtag, "#"), params)
+			return page.Chirps, err
+		}
+	default:
+		params := &client.ListChirpsParams{Sort: sortParam}
+		if *author != "" {
+			authorID, err := uuid.Parse(*author)
+			if err != nil {
+				return fmt.Errorf("-author must be a user ID: %w", err)
+			}
+			params.AuthorID = &authorID
+		}
+		list = func() ([]client.Chirp, error) {
+			return c.client.ListChirps(ctx, params)
+		}
+	}
+
+	var chirps []client.Chirp
+	err := c.maybeAuthed(ctx, func() (err error) {
+		chirps, err = list()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if *limit > 0 && len(chirps) > *limit {
+		chirps = chirps[:*limit]
+	}
+	return c.printChirps(chirps)
+}
+
+// maybeAuthed calls fn as the logged-in user if there is one, so the
+// results account for their blocks and mutes, and anonymously otherwise.
+func (c *cli) maybeAuthed(ctx context.Context, fn func() error) error {
+	if c.client.RefreshToken == "" {
+		return fn()
+	}
+	return c.authed(ctx, fn)
+}
+
+func cmdGet(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("get", "get ID")
+	if err := c.parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	chirpID, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%q isn't a chirp ID", fs.Arg(0))
+	}
+
+	var chirp client.Chirp
+	err = c.maybeAuthed(ctx, func() (err error) {
+		chirp, err = c.client.GetChirp(ctx, chirpID, nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return c.printChirp(chirp)
+}
+
+func cmdDelete(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("delete", "delete ID")
+	if err := c.parse(fs, args, 1, 1); err != nil {
+		return err
+	}
+	chirpID, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%q isn't a chirp ID", fs.Arg(0))
+	}
+
+	err = c.authed(ctx, func() error {
+		return c.client.DeleteChirp(ctx, chirpID, nil)
+	})
+	if err != nil {
+		return err
+	}
+	return c.printMessage("Deleted chirp " + chirpID.String())
+}
+
+func cmdUser(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 || args[0] != "update" {
+		fmt.Fprintln(c.stderr, "usage: chirpctl user update [flags]")
+		return errUsage
+	}
+
+	fs := c.flagSet("user update", "user update [-email EMAIL] [-password PASSWORD] [-handle HANDLE] [-display-name NAME] [-bio BIO] [-avatar-url URL]")
+	req := client.UpdateUserRequest{}
+	fs.StringVar(&req.Email, "email", "", "new email")
+	fs.StringVar(&req.Password, "password", "", "new password")
+	fs.StringVar(&req.Handle, "handle", "", "new handle")
+	fs.StringVar(&req.DisplayName, "display-name", "", "new display name")
+	fs.StringVar(&req.Bio, "bio", "", "new bio")
+	fs.StringVar(&req.AvatarURL, "avatar-url", "", "new avatar URL")
+	if err := c.parse(fs, args[1:], 0, 0); err != nil {
+		return err
+	}
+	if req == (client.UpdateUserRequest{}) {
+		fmt.Fprintln(c.stderr, "chirpctl: nothing to update")
+		fs.Usage()
+		return errUsage
+	}
+
+	var user client.User
+	err := c.authed(ctx, func() (err error) {
+		user, err = c.client.UpdateUser(ctx, nil, req)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if user.Email != c.conf.Email {
+		c.conf.Email = user.Email
+		if err := saveConfig(c.configPath, c.conf); err != nil {
+			return err
+		}
+	}
+	return c.printUser(user)
+}
+
+// hitsPattern finds the hit count on the admin metrics page.
+var hitsPattern = regexp.MustCompile(`visited (\d+)\s*times`)
+
+func cmdAdmin(ctx context.Context, c *cli, args []string) error {
+	if len(args) == 0 || (args[0] != "metrics" && args[0] != "reset") {
+		fmt.Fprintln(c.stderr, "usage: chirpctl admin metrics|reset")
+		return errUsage
+	}
+	fs := c.flagSet("admin "+args[0], "admin "+args[0])
+	if err := c.parse(fs, args[1:], 0, 0); err != nil {
+		return err
+	}
+
+	if args[0] == "metrics" {
+		page, err := c.client.AdminMetrics(ctx)
+		if err != nil {
+			return err
+		}
+		match := hitsPattern.FindStringSubmatch(page)
+		if match == nil {
+			return errors.New("couldn't find the hit count on the metrics page")
+		}
+		hits, err := strconv.Atoi(match[1])
+		if err != nil {
+			return err
+		}
+		v := struct {
+			Hits int `json:"hits"`
+		}{hits}
+		return c.print(v, func(w io.Writer) {
+			fmt.Fprintln(w, "HITS")
+			fmt.Fprintln(w, hits)
+		})
+	}
+
+	msg, err := c.client.AdminReset(ctx)
+	if err != nil {
+		return err
+	}
+	// The reset deleted every user, so the saved tokens are no good.
+	if c.conf.RefreshToken != "" && c.client.RefreshToken != "" {
+		c.conf.AccessToken = ""
+		c.conf.RefreshToken = ""
+		if err := saveConfig(c.configPath, c.conf); err != nil {
+			return err
+		}
+	}
+	return c.printMessage(msg)
+}
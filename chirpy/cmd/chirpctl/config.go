@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// config is what chirpctl remembers between runs: the server it talks to
+// and the tokens of the user logged in to it.
+type config struct {
+	Server       string `json:"server,omitempty"`
+	UserID       string `json:"user_id,omitempty"`
+	Email        string `json:"email,omitempty"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// defaultConfigPath returns $CHIRPCTL_CONFIG, or config.json in the
+// chirpctl directory of the user's configuration directory.
+func defaultConfigPath() string {
+	if path := os.Getenv("CHIRPCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "chirpctl.json"
+	}
+	return filepath.Join(dir, "chirpctl", "config.json")
+}
+
+// loadConfig reads the config at path. A missing file is an empty config.
+func loadConfig(path string) (config, error) {
+	conf := config{}
+	dat, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return conf, nil
+	}
+	if err != nil {
+		return conf, err
+	}
+	if err := json.Unmarshal(dat, &conf); err != nil {
+		return conf, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return conf, nil
+}
+
+// saveConfig writes conf to path. The file holds the refresh token, so
+// only the user can read it, and it is replaced in one step so a failed
+// write can't lose the tokens it had.
+func saveConfig(path string, conf config) error {
+	dat, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".config-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(dat, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// CreateTemp already makes the file 0600.
+	return os.Rename(f.Name(), path)
+}
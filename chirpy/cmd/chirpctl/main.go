@@ -0,0 +1,184 @@
+// Command chirpctl is a command-line client for chirpy. It logs in once,
+// keeps the user's tokens in a config file and refreshes the access token
+// through /api/refresh when it expires.
+//
+// Usage:
+//
+//	chirpctl [-server URL] [-config PATH] [-o table|json] <command> [flags] [args]
+//
+// The commands are:
+//
+//	login -email EMAIL [-password PASSWORD]
+//	logout
+//	post [-at TIME] [BODY...]
+//	list [-author ID] [-mine] [-user HANDLE] [-hashtag TAG] [-sort asc|desc] [-limit N]
+//	get ID
+//	delete ID
+//	user update [-email EMAIL] [-password PASSWORD] [-handle HANDLE] [-display-name NAME] [-bio BIO] [-avatar-url URL]
+//	admin metrics
+//	admin reset
+//
+// The server defaults to $CHIRPY_SERVER, then the server logged in to,
+// then http://localhost:8080. The config file defaults to
+// $CHIRPCTL_CONFIG, then chirpctl/config.json in the user's
+// configuration directory. Output is a table unless -o json is given,
+// before or after the command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// errUsage is returned for bad command lines, after the problem has been
+// reported.
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "chirpctl:", describeError(err))
+		os.Exit(1)
+	}
+}
+
+// cli holds the state of one chirpctl invocation.
+type cli struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+
+	configPath string
+	conf       config
+	output     string
+	client     *client.Client
+}
+
+// command runs a subcommand with the arguments after its name.
+type command func(ctx context.Context, c *cli, args []string) error
+
+var commands = map[string]command{
+	"login":  cmdLogin,
+	"logout": cmdLogout,
+	"post":   cmdPost,
+	"list":   cmdList,
+	"get":    cmdGet,
+	"delete": cmdDelete,
+	"user":   cmdUser,
+	"admin":  cmdAdmin,
+}
+
+// run parses the global flags and runs the command named in args.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	c := &cli{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	fs := flag.NewFlagSet("chirpctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: chirpctl [-server URL] [-config PATH] [-o table|json] <command> [flags] [args]")
+		fmt.Fprintln(stderr, "commands: admin, delete, get, list, login, logout, post, user")
+		fs.PrintDefaults()
+	}
+	server := fs.String("server", os.Getenv("CHIRPY_SERVER"), "chirpy server `URL`")
+	fs.StringVar(&c.configPath, "config", defaultConfigPath(), "config file `path`")
+	fs.StringVar(&c.output, "o", "table", "output format, table or json")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "chirpctl: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return errUsage
+	}
+
+	conf, err := loadConfig(c.configPath)
+	if err != nil {
+		return err
+	}
+	c.conf = conf
+	switch {
+	case *server != "":
+	case conf.Server != "":
+		*server = conf.Server
+	default:
+		*server = defaultServer
+	}
+	c.client = client.New(*server)
+	// Tokens are only good on the server that issued them.
+	if c.client.BaseURL == strings.TrimSuffix(conf.Server, "/") {
+		c.client.AccessToken = conf.AccessToken
+		c.client.RefreshToken = conf.RefreshToken
+	}
+
+	return cmd(ctx, c, fs.Args()[1:])
+}
+
+// flagSet returns a FlagSet for a subcommand, which also accepts -o so
+// the output format can follow the command.
+func (c *cli) flagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: chirpctl %s\n", usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&c.output, "o", c.output, "output format, table or json")
+	return fs
+}
+
+// parse parses a subcommand's arguments and checks the output format and
+// the number of positional arguments, which must be between minArgs and
+// maxArgs, or at least minArgs if maxArgs is negative.
+func (c *cli) parse(fs *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if c.output != "table" && c.output != "json" {
+		fmt.Fprintf(c.stderr, "chirpctl: unknown output format %q, want table or json\n", c.output)
+		return errUsage
+	}
+	if fs.NArg() < minArgs || (maxArgs >= 0 && fs.NArg() > maxArgs) {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// describeError formats err for the terminal, listing the fields an API
+// error complains about.
+func describeError(err error) string {
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	b := strings.Builder{}
+	b.WriteString(err.Error())
+	for _, d := range apiErr.Details {
+		fmt.Fprintf(&b, "\n  %s: %s", d.Field, d.Message)
+	}
+	return b.String()
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+// fakeChirpy serves the few endpoints chirpctl calls, accepting one
+// access token at a time.
+type fakeChirpy struct {
+	mu           sync.Mutex
+	userID       uuid.UUID
+	access       string
+	refresh      string
+	refreshCount int
+	chirps       []client.Chirp
+}
+
+// accessToken returns a JWT expiring at exp.
+func accessToken(t *testing.T, exp time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(exp),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return token
+}
+
+func newFakeChirpy(t *testing.T) (*httptest.Server, *fakeChirpy) {
+	f := &fakeChirpy{userID: uuid.New(), refresh: "refresh-token"}
+	authorized := func(r *http.Request, token string) bool {
+		return r.Header.Get("Authorization") == "Bearer "+token
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/login", func(w http.ResponseWriter, r *http.Request) {
+		creds := client.Credentials{}
+		json.NewDecoder(r.Body).Decode(&creds)
+		if creds.Password != "hunter2" {
+			http.Error(w, `{"error": "Incorrect email or password"}`, http.StatusUnauthorized)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.access = accessToken(t, time.Now().Add(time.Hour))
+		json.NewEncoder(w).Encode(client.LoginResponse{
+			User:         client.User{ID: f.userID, Email: creds.Email},
+			Token:        f.access,
+			RefreshToken: f.refresh,
+		})
+	})
+	mux.HandleFunc("POST /api/refresh", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !authorized(r, f.refresh) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.refreshCount++
+		f.access = accessToken(t, time.Now().Add(time.Hour))
+		json.NewEncoder(w).Encode(client.TokenResponse{Token: f.access})
+	})
+	mux.HandleFunc("POST /api/revoke", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.refresh = ""
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /api/chirps", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !authorized(r, f.access) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		req := client.CreateChirpRequest{}
+		json.NewDecoder(r.Body).Decode(&req)
+		chirp := client.Chirp{ID: uuid.New(), CreatedAt: time.Now(), UserID: f.userID, Body: req.Body}
+		f.chirps = append(f.chirps, chirp)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(chirp)
+	})
+	mux.HandleFunc("GET /api/chirps", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		chirps := []client.Chirp{}
+		for _, chirp := range f.chirps {
+			if id := r.URL.Query().Get("author_id"); id == "" || id == chirp.UserID.String() {
+				chirps = append(chirps, chirp)
+			}
+		}
+		json.NewEncoder(w).Encode(chirps)
+	})
+	mux.HandleFunc("GET /admin/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<p>Chirpy has been visited 7times!</p>"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, f
+}
+
+func TestChirpctl(t *testing.T) {
+	srv, f := newFakeChirpy(t)
+	configPath := filepath.Join(t.TempDir(), "chirpctl", "config.json")
+	t.Setenv("CHIRPY_SERVER", "")
+
+	chirpctl := func(stdin string, args ...string) (string, error) {
+		t.Helper()
+		stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+		args = append([]string{"-server", srv.URL, "-config", configPath}, args...)
+		err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String(), err
+	}
+
+	if _, err := chirpctl("", "post", "too soon"); !errors.Is(err, errNotLoggedIn) {
+		t.Errorf("post before login error = %v, want %v", err, errNotLoggedIn)
+	}
+	out, err := chirpctl("wrong\n", "login", "-email", "a@example.com")
+	if apiErr := (&client.APIError{}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("login with a wrong password error = %v, want a 401", err)
+	}
+	out, err = chirpctl("hunter2\n", "login", "-email", "a@example.com")
+	if err != nil {
+		t.Fatalf("login error = %v", err)
+	}
+	if !strings.Contains(out, "a@example.com") {
+		t.Errorf("login output = %q, want the user", out)
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("config not saved: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("config permissions = %v, want 0600", perm)
+	}
+
+	// The server forgets the access token, so the post is refused once
+	// and retried after a refresh.
+	f.mu.Lock()
+	f.access = "forgotten"
+	f.mu.Unlock()
+	out, err = chirpctl("", "post", "hello", "world")
+	if err != nil {
+		t.Fatalf("post error = %v", err)
+	}
+	if !strings.HasPrefix(out, "ID") || !strings.Contains(out, "hello world") {
+		t.Errorf("post output = %q, want a table with the chirp", out)
+	}
+	if _, err := chirpctl("from stdin\n", "post"); err != nil {
+		t.Fatalf("post from stdin error = %v", err)
+	}
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if f.refreshCount != 1 || conf.AccessToken != f.access {
+		t.Errorf("refreshes = %d, saved token current = %v, want 1 refresh saved", f.refreshCount, conf.AccessToken == f.access)
+	}
+
+	// An expired token is refreshed before it is sent.
+	conf.AccessToken = accessToken(t, time.Now().Add(-time.Minute))
+	if err := saveConfig(configPath, conf); err != nil {
+		t.Fatalf("saveConfig() error = %v", err)
+	}
+	out, err = chirpctl("", "list", "-mine", "-o", "json")
+	if err != nil {
+		t.Fatalf("list error = %v", err)
+	}
+	chirps := []client.Chirp{}
+	if err := json.Unmarshal([]byte(out), &chirps); err != nil {
+		t.Fatalf("list -o json output %q: %v", out, err)
+	}
+	if len(chirps) != 2 || chirps[0].Body != "hello world" || chirps[1].Body != "from stdin" {
+		t.Errorf("list = %+v, want both chirps", chirps)
+	}
+	if f.refreshCount != 2 {
+		t.Errorf("refreshes = %d, want 2", f.refreshCount)
+	}
+
+	out, err = chirpctl("", "-o", "json", "list", "-limit", "1")
+	if err != nil || strings.Count(out, `"body"`) != 1 {
+		t.Errorf("list -limit 1 = %q, %v, want one chirp", out, err)
+	}
+	if _, err := chirpctl("", "list", "-mine", "-hashtag", "go"); err == nil {
+		t.Errorf("list with two filters succeeded, want an error")
+	}
+	if _, err := chirpctl("", "list", "-o", "yaml"); !errors.Is(err, errUsage) {
+		t.Errorf("list -o yaml error = %v, want a usage error", err)
+	}
+
+	out, err = chirpctl("", "admin", "metrics", "-o", "json")
+	if err != nil || strings.Join(strings.Fields(out), "") != `{"hits":7}` {
+		t.Errorf("admin metrics = %q, %v, want 7 hits", out, err)
+	}
+
+	if _, err := chirpctl("", "logout"); err != nil {
+		t.Fatalf("logout error = %v", err)
+	}
+	conf, _ = loadConfig(configPath)
+	if conf.AccessToken != "" || conf.RefreshToken != "" || f.refresh != "" {
+		t.Errorf("after logout config = %+v, server refresh token = %q, want both cleared", conf, f.refresh)
+	}
+	if _, err := chirpctl("", "delete", uuid.NewString()); !errors.Is(err, errNotLoggedIn) {
+		t.Errorf("delete after logout error = %v, want %v", err, errNotLoggedIn)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+// print writes v as indented JSON with -o json, and otherwise as the
+// table that writeTable writes.
+func (c *cli) print(v any, writeTable func(w io.Writer)) error {
+	if c.output == "json" {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	writeTable(tw)
+	return tw.Flush()
+}
+
+// printMessage reports the outcome of a command that has nothing else to
+// show.
+func (c *cli) printMessage(msg string) error {
+	v := struct {
+		Message string `json:"message"`
+	}{msg}
+	return c.print(v, func(w io.Writer) {
+		fmt.Fprintln(w, msg)
+	})
+}
+
+func (c *cli) printChirps(chirps []client.Chirp) error {
+	if chirps == nil {
+		chirps = []client.Chirp{}
+	}
+	return c.print(chirps, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tCREATED\tAUTHOR\tBODY")
+		for _, chirp := range chirps {
+			writeChirpRow(w, chirp)
+		}
+	})
+}
+
+func (c *cli) printChirp(chirp client.Chirp) error {
+	return c.print(chirp, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tCREATED\tAUTHOR\tBODY")
+		writeChirpRow(w, chirp)
+	})
+}
+
+func writeChirpRow(w io.Writer, chirp client.Chirp) {
+	created := chirp.CreatedAt.UTC().Format(time.RFC3339)
+	if chirp.PublishAt != nil && chirp.PublishAt.After(time.Now()) {
+		created = "scheduled " + chirp.PublishAt.UTC().Format(time.RFC3339)
+	}
+	// Tabs and newlines in the body would break up the table.
+	body := strings.Join(strings.Fields(chirp.Body), " ")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", chirp.ID, created, chirp.UserID, body)
+}
+
+func (c *cli) printUser(user client.User) error {
+	return c.print(user, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tEMAIL\tHANDLE\tDISPLAY NAME\tCHIRPY RED")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", user.ID, user.Email, user.Handle, user.DisplayName, user.IsChirpyRed)
+	})
+}
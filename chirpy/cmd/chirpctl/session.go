@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+// errNotLoggedIn is returned by commands that need a user when there are
+// no tokens for the server.
+var errNotLoggedIn = errors.New("not logged in, run chirpctl login first")
+
+// expiryLeeway is how long before its expiry an access token is replaced,
+// so it doesn't run out on the way to the server.
+const expiryLeeway = 30 * time.Second
+
+// authed calls fn as the logged-in user. An access token that has expired
+// is refreshed first, and if the server rejects the token anyway fn is
+// called once more with a fresh one. New tokens are saved to the config.
+func (c *cli) authed(ctx context.Context, fn func() error) error {
+	if c.client.RefreshToken == "" {
+		return errNotLoggedIn
+	}
+	if tokenExpired(c.client.AccessToken, time.Now().Add(expiryLeeway)) {
+		if err := c.refresh(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := fn()
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		return err
+	}
+	if err := c.refresh(ctx); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// refresh swaps the refresh token for a new access token and saves it.
+func (c *cli) refresh(ctx context.Context) error {
+	resp, err := c.client.Refresh(ctx)
+	apiErr := &client.APIError{}
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		return errors.New("session expired, run chirpctl login again")
+	}
+	if err != nil {
+		return err
+	}
+	c.client.AccessToken = resp.Token
+	c.conf.AccessToken = resp.Token
+	return saveConfig(c.configPath, c.conf)
+}
+
+// tokenExpired reports whether the access token has expired by now or
+// can't be read. The signature isn't checked: the server does that.
+func tokenExpired(token string, now time.Time) bool {
+	claims := jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return true
+	}
+	return claims.ExpiresAt != nil && !now.Before(claims.ExpiresAt.Time)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/compression"
+)
+
+// Cache-Control policies, set per route in registerRoutes.
+const (
+	// cacheRevalidate lets clients and shared caches keep a copy but has
+	// them check it is still current first, which costs a 304 when it is.
+	cacheRevalidate = "no-cache"
+	// cacheShort is for public data that may be up to a minute stale.
+	cacheShort = "public, max-age=60"
+	// cacheNoStore is for credentials and personal data, which must not
+	// be kept at all.
+	cacheNoStore = "no-store"
+)
+
+// errPreconditionFailed is returned from InTx callbacks when the request's
+// If-Match header doesn't match the row being changed.
+var errPreconditionFailed = errors.New("precondition failed")
+
+// withCacheControl sets the Cache-Control header of every response from
+// handler to policy.
+func withCacheControl(policy string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", policy)
+		handler(w, r)
+	}
+}
+
+// resourceETag returns the strong ETag of a single row. It changes
+// whenever the row's updated_at does.
+func resourceETag(id uuid.UUID, updatedAt time.Time) string {
+	h := sha256.New()
+	writeVersion(h, id, updatedAt)
+	return etagFromHash(h)
+}
+
+// chirpsETag returns the strong ETag of a list of chirps. It covers which
+// chirps are listed and in what order as well as each one's version, so
+// new, deleted and hidden chirps all change it.
+func chirpsETag(chirps []Chirp) string {
+	h := sha256.New()
+	for _, chirp := range chirps {
+		writeVersion(h, chirp.ID, chirp.UpdatedAt)
+	}
+	return etagFromHash(h)
+}
+
+// chirpPageETag is chirpsETag for a page of chirps, which can gain a
+// next page without its chirps changing.
+func chirpPageETag(chirps []Chirp, nextCursor string) string {
+	h := sha256.New()
+	for _, chirp := range chirps {
+		writeVersion(h, chirp.ID, chirp.UpdatedAt)
+	}
+	fmt.Fprintf(h, "next=%s;", nextCursor)
+	return etagFromHash(h)
+}
+
+func writeVersion(h hash.Hash, id uuid.UUID, updatedAt time.Time) {
+	fmt.Fprintf(h, "%s@%d;", id, updatedAt.UnixNano())
+}
+
+func etagFromHash(h hash.Hash) string {
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// checkNotModified sets the ETag header, and Last-Modified unless
+// lastModified is zero, then responds with 304 Not Modified if the
+// request's If-None-Match or If-Modified-Since header shows the client's
+// copy is current. It reports whether it responded.
+//
+// List endpoints pass a zero lastModified: a deleted chirp leaves no
+// timestamp behind, so only the ETag notices it is gone.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	// Which chirps a request can see depends on who is asking.
+	w.Header().Add("Vary", "Authorization")
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if !etagListMatches(inm, etag, false) {
+			return false
+		}
+	} else {
+		ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(ims) {
+			return false
+		}
+	}
+
+	// A 304 carries no body, so drop the headers that describe one.
+	w.Header().Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifMatch reports whether the request may change the resource whose
+// current ETag is etag: either it has no If-Match header or the header
+// names that ETag.
+func ifMatch(r *http.Request, etag string) bool {
+	im := r.Header.Get("If-Match")
+	return im == "" || etagListMatches(im, etag, true)
+}
+
+// respondPreconditionFailed responds to a request whose If-Match header
+// named an outdated version of the resource.
+func respondPreconditionFailed(w http.ResponseWriter, etag string) {
+	w.Header().Set("ETag", etag)
+	respondWithError(w, http.StatusPreconditionFailed, "Resource has changed; fetch it again and retry", nil)
+}
+
+// etagListMatches reports whether the comma-separated ETags in header,
+// or "*", match etag. Strong comparison, as If-Match requires, never
+// matches weak ETags; weak comparison, as If-None-Match uses, ignores the
+// W/ prefix. ETags of compressed responses match the uncompressed body's.
+func etagListMatches(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak, ok := strings.CutPrefix(candidate, "W/"); ok {
+			if strong {
+				continue
+			}
+			candidate = weak
+		}
+		if compression.TrimCoding(candidate) == etag {
+			return true
+		}
+	}
+	return false
+}
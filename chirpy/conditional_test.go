@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+func TestEtagListMatches(t *testing.T) {
+	const etag = `"abc"`
+	tests := []struct {
+		header string
+		strong bool
+		want   bool
+	}{
+		{`"abc"`, true, true},
+		{`"xyz", "abc"`, true, true},
+		{`"xyz"`, false, false},
+		{`*`, true, true},
+		{`W/"abc"`, false, true},
+		{`W/"abc"`, true, false},
+		{`"abc-gzip"`, true, true},
+		{`W/"abc-zstd"`, false, true},
+		{`"abc-br"`, false, false},
+	}
+	for _, tc := range tests {
+		if got := etagListMatches(tc.header, etag, tc.strong); got != tc.want {
+			t.Errorf("etagListMatches(%q, strong=%v) = %v, want %v", tc.header, tc.strong, got, tc.want)
+		}
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+
+	chirp, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "hello"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+
+	get := func(path string, header http.Header) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	chirpPath := "/api/chirps/" + chirp.ID.String()
+	first := get(chirpPath, nil)
+	etag, lastModified := first.Header.Get("ETag"), first.Header.Get("Last-Modified")
+	if first.StatusCode != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("GET %s = %d, ETag %q, Last-Modified %q, want 200 with validators", chirpPath, first.StatusCode, etag, lastModified)
+	}
+	if got := first.Header.Get("Cache-Control"); got != cacheRevalidate {
+		t.Errorf("GET %s Cache-Control = %q, want %q", chirpPath, got, cacheRevalidate)
+	}
+	if resp := get(chirpPath, http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("GET %s with If-None-Match = %d, want 304", chirpPath, resp.StatusCode)
+	}
+	if resp := get(chirpPath, http.Header{"If-Modified-Since": {lastModified}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("GET %s with If-Modified-Since = %d, want 304", chirpPath, resp.StatusCode)
+	}
+	earlier := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	if resp := get(chirpPath, http.Header{"If-Modified-Since": {earlier}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET %s with an old If-Modified-Since = %d, want 200", chirpPath, resp.StatusCode)
+	}
+
+	list := get("/api/chirps", nil)
+	listETag := list.Header.Get("ETag")
+	if resp := get("/api/chirps", http.Header{"If-None-Match": {listETag}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("GET /api/chirps with If-None-Match = %d, want 304", resp.StatusCode)
+	}
+	other, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "again"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if resp := get("/api/chirps", http.Header{"If-None-Match": {listETag}}); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /api/chirps after a new chirp = %d, want 200", resp.StatusCode)
+	}
+	if resp := get("/api/hashtags/trending", nil); resp.Header.Get("Cache-Control") != cacheShort {
+		t.Errorf("GET /api/hashtags/trending Cache-Control = %q, want %q", resp.Header.Get("Cache-Control"), cacheShort)
+	}
+
+	wantPreconditionFailed := func(name string, err error) {
+		t.Helper()
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusPreconditionFailed {
+			t.Errorf("%s error = %v, want 412", name, err)
+		}
+	}
+	stale := `"stale"`
+	wantPreconditionFailed("DeleteChirp(stale If-Match)", alice.DeleteChirp(ctx, other.ID, &client.DeleteChirpParams{IfMatch: &stale}))
+	otherETag := resourceETag(other.ID, other.UpdatedAt)
+	if err := alice.DeleteChirp(ctx, other.ID, &client.DeleteChirpParams{IfMatch: &otherETag}); err != nil {
+		t.Errorf("DeleteChirp(current If-Match) error = %v", err)
+	}
+
+	_, err = alice.UpdateUser(ctx, &client.UpdateUserParams{IfMatch: &stale}, client.UpdateUserRequest{Bio: "lost update"})
+	wantPreconditionFailed("UpdateUser(stale If-Match)", err)
+	user, err := alice.UpdateUser(ctx, nil, client.UpdateUserRequest{Bio: "first"})
+	if err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	userETag := resourceETag(user.ID, user.UpdatedAt)
+	if _, err := alice.UpdateUser(ctx, &client.UpdateUserParams{IfMatch: &userETag}, client.UpdateUserRequest{Bio: "second"}); err != nil {
+		t.Errorf("UpdateUser(current If-Match) error = %v", err)
+	}
+	_, err = alice.UpdateUser(ctx, &client.UpdateUserParams{IfMatch: &userETag}, client.UpdateUserRequest{Bio: "third"})
+	wantPreconditionFailed("UpdateUser(reused If-Match)", err)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// Account deletion policies, chosen with ACCOUNT_DELETION.
+const (
+	// accountDeletionHardDelete deletes the user, and through ON DELETE
+	// CASCADE everything they own.
+	accountDeletionHardDelete = "delete"
+	// accountDeletionAnonymize keeps the user's chirps but strips the
+	// account of everything that identifies them.
+	accountDeletionAnonymize = "anonymize"
+)
+
+// deletionSignInWindow is how recently a user without a password must
+// have signed in to delete their account.
+const deletionSignInWindow = 10 * time.Minute
+
+// Session is a refresh token as it appears in a data export. The token
+// itself is left out.
+type Session struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// Identity is an identity provider account linked to the user.
+type Identity struct {
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SubscriptionEvent is a change to the user's Chirpy Red subscription.
+type SubscriptionEvent struct {
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (cfg *apiConfig) handlerUsersExport(w http.ResponseWriter, r *http.Request, user database.User) {
+	dbChirps, err := cfg.db.GetChirpsByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+	dbMessages, err := cfg.db.GetMessagesBySender(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve messages", err)
+		return
+	}
+	refreshTokens, err := cfg.db.GetRefreshTokensByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve sessions", err)
+		return
+	}
+	dbIdentities, err := cfg.db.GetUserIdentitiesByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve linked identities", err)
+		return
+	}
+	dbEvents, err := cfg.db.GetSubscriptionEventsByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve subscription history", err)
+		return
+	}
+
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, chirpFromDB(dbChirp))
+	}
+	messages := []Message{}
+	for _, m := range dbMessages {
+		messages = append(messages, messageFromDB(m))
+	}
+	sessions := []Session{}
+	for _, refreshToken := range refreshTokens {
+		session := Session{
+			CreatedAt: refreshToken.CreatedAt,
+			ExpiresAt: refreshToken.ExpiresAt,
+		}
+		if refreshToken.RevokedAt.Valid {
+			session.RevokedAt = &refreshToken.RevokedAt.Time
+		}
+		sessions = append(sessions, session)
+	}
+	identities := []Identity{}
+	for _, identity := range dbIdentities {
+		identities = append(identities, Identity{
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	subscriptions := []SubscriptionEvent{}
+	for _, event := range dbEvents {
+		subscriptions = append(subscriptions, SubscriptionEvent{Event: event.Event, CreatedAt: event.CreatedAt})
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chirpy-export-%s.zip"`, time.Now().UTC().Format("2006-01-02")))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so a failure part way through can only
+	// be logged. The client sees a truncated, invalid archive.
+	zw := zip.NewWriter(w)
+	for _, file := range []struct {
+		name string
+		data any
+	}{
+		{"profile.json", userFromDB(user)},
+		{"chirps.json", chirps},
+		{"messages.json", messages},
+		{"sessions.json", sessions},
+		{"identities.json", identities},
+		{"subscriptions.json", subscriptions},
+	} {
+		f, err := zw.Create(file.name)
+		if err != nil {
+			log.Printf("Error exporting user %s: %s", user.ID, err)
+			return
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			log.Printf("Error exporting user %s: %s", user.ID, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("Error exporting user %s: %s", user.ID, err)
+	}
+}
+
+func (cfg *apiConfig) handlerUsersDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Password string `json:"password"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	if user.HashedPassword == auth.UnsetPassword {
+		// There is no password to ask for, so a fresh sign-in with the
+		// identity provider stands in for it.
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil || !auth.SignedInSince(token, cfg.jwtSecret, time.Now().Add(-deletionSignInWindow)) {
+			respondWithError(w, http.StatusForbidden, "Sign in again to confirm deleting your account", err)
+			return
+		}
+	} else {
+		v := validation{}
+		v.require("password", params.Password)
+		if err := v.err(); err != nil {
+			respondWithRequestError(w, err)
+			return
+		}
+
+		err = auth.CheckPasswordHash(params.Password, user.HashedPassword)
+		if err != nil {
+			respondWithError(w, http.StatusForbidden, "Incorrect password", err)
+			return
+		}
+	}
+
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		if err := tx.RevokeUserRefreshTokens(r.Context(), user.ID); err != nil {
+			return err
+		}
+		if cfg.accountDeletion != accountDeletionAnonymize {
+			return tx.DeleteUser(r.Context(), user.ID)
+		}
+		if err := tx.DeleteWebhookEndpointsByUser(r.Context(), user.ID); err != nil {
+			return err
+		}
+		if err := tx.DeleteScheduledChirpsByUser(r.Context(), user.ID); err != nil {
+			return err
+		}
+		if err := tx.DeleteUserIdentitiesByUser(r.Context(), user.ID); err != nil {
+			return err
+		}
+		_, err := tx.AnonymizeUser(r.Context(), user.ID)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+func TestExportUserData(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, login := newTestUser(t, srv, "alice@example.com")
+
+	if _, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "my first chirp"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	polka := client.New(srv.URL)
+	polka.APIKey = cfg.polkaKey
+	err := polka.PolkaWebhook(ctx, client.PolkaWebhookRequest{
+		Event: "user.upgraded",
+		Data:  client.PolkaWebhookData{UserID: login.ID},
+	})
+	if err != nil {
+		t.Fatalf("PolkaWebhook() error = %v", err)
+	}
+
+	body, err := alice.ExportUserData(ctx)
+	if err != nil {
+		t.Fatalf("ExportUserData() error = %v", err)
+	}
+	defer body.Close()
+	dat, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(dat), int64(len(dat)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+		contents, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(contents)
+	}
+
+	profile := client.User{}
+	if err := json.Unmarshal([]byte(files["profile.json"]), &profile); err != nil || profile.Email != "alice@example.com" || !profile.IsChirpyRed {
+		t.Errorf("profile.json = %s, want alice's upgraded profile", files["profile.json"])
+	}
+	chirps := []client.Chirp{}
+	if err := json.Unmarshal([]byte(files["chirps.json"]), &chirps); err != nil || len(chirps) != 1 {
+		t.Errorf("chirps.json = %s, want alice's chirp", files["chirps.json"])
+	}
+	if !strings.Contains(files["sessions.json"], "expires_at") || strings.Contains(files["sessions.json"], login.RefreshToken) {
+		t.Errorf("sessions.json = %s, want one session without its token", files["sessions.json"])
+	}
+	if !strings.Contains(files["subscriptions.json"], `"user.upgraded"`) {
+		t.Errorf("subscriptions.json = %s, want the upgrade", files["subscriptions.json"])
+	}
+}
+
+func TestDeleteCurrentUser(t *testing.T) {
+	for _, policy := range []string{accountDeletionHardDelete, accountDeletionAnonymize} {
+		t.Run(policy, func(t *testing.T) {
+			ctx := context.Background()
+			srv, cfg := newTestServer(t)
+			cfg.accountDeletion = policy
+			alice, login := newTestUser(t, srv, "alice@example.com")
+			bob, _ := newTestUser(t, srv, "bob@example.com")
+
+			if _, err := alice.UpdateUser(ctx, nil, client.UpdateUserRequest{Handle: "alice", Bio: "hello"}); err != nil {
+				t.Fatalf("UpdateUser() error = %v", err)
+			}
+			chirp, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "goodbye"})
+			if err != nil {
+				t.Fatalf("CreateChirp() error = %v", err)
+			}
+			if _, err := cfg.db.UpgradeToChirpyRed(ctx, login.ID); err != nil {
+				t.Fatalf("UpgradeToChirpyRed() error = %v", err)
+			}
+			_, err = cfg.db.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+				UserID:  login.ID,
+				Issuer:  "https://accounts.example.com",
+				Subject: "alice",
+				Email:   "alice@example.com",
+			})
+			if err != nil {
+				t.Fatalf("CreateUserIdentity() error = %v", err)
+			}
+			publishAt := time.Now().Add(time.Hour)
+			scheduled, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "from beyond", PublishAt: &publishAt})
+			if err != nil {
+				t.Fatalf("CreateChirp(publish_at) error = %v", err)
+			}
+
+			err = alice.DeleteCurrentUser(ctx, client.DeleteUserRequest{Password: "wrong"})
+			apiErr := &client.APIError{}
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+				t.Fatalf("DeleteCurrentUser(wrong password) error = %v, want 403", err)
+			}
+
+			if err := alice.DeleteCurrentUser(ctx, client.DeleteUserRequest{Password: "password"}); err != nil {
+				t.Fatalf("DeleteCurrentUser() error = %v", err)
+			}
+
+			if _, err := alice.Refresh(ctx); err == nil {
+				t.Error("Refresh() after deletion error = nil, want the refresh token revoked")
+			}
+			if _, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "still here?"}); err == nil {
+				t.Error("CreateChirp() after deletion error = nil, want the access token rejected")
+			}
+			if _, err := alice.Login(ctx, client.Credentials{Email: "alice@example.com", Password: "password"}); err == nil {
+				t.Error("Login() after deletion error = nil, want it to fail")
+			}
+			if _, err := bob.GetProfile(ctx, "alice", nil); err == nil {
+				t.Error("GetProfile(alice) after deletion error = nil, want 404")
+			}
+
+			// The scheduled chirp goes with the account under either policy.
+			publisher := cfg.newChirpPublisher()
+			publisher.Now = func() time.Time { return publishAt.Add(time.Minute) }
+			if n, err := publisher.PublishDue(ctx); err != nil || n != 0 {
+				t.Errorf("PublishDue() after deletion = %d, %v, want nothing published", n, err)
+			}
+			if _, err := cfg.db.GetScheduledChirp(ctx, scheduled.ID); err == nil {
+				t.Error("GetScheduledChirp() after deletion error = nil, want it deleted")
+			}
+			if identities, err := cfg.db.GetUserIdentitiesByUser(ctx, login.ID); err != nil || len(identities) != 0 {
+				t.Errorf("GetUserIdentitiesByUser() after deletion = %+v, %v, want none", identities, err)
+			}
+
+			got, err := bob.GetChirp(ctx, chirp.ID, nil)
+			switch policy {
+			case accountDeletionHardDelete:
+				if err == nil {
+					t.Errorf("GetChirp() = %+v, want the chirp deleted with its author", got)
+				}
+			case accountDeletionAnonymize:
+				if err != nil || got.UserID != login.ID {
+					t.Errorf("GetChirp() = %+v, %v, want the chirp kept", got, err)
+				}
+				user, err := cfg.db.GetUserByID(ctx, login.ID)
+				if err != nil || user.Email == "alice@example.com" || user.Bio != "" || !user.DeletedAt.Valid {
+					t.Errorf("GetUserByID() = %+v, %v, want an anonymized user", user, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// auditExportPageSize is how many events the export reads at a time.
+const auditExportPageSize = 500
+
+// AuditEvent is a security-sensitive action. ActorID is left out when
+// nobody signed in did it. Diff maps the fields the action changed to
+// their old and new values.
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Action     string          `json:"action"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   string          `json:"target_id,omitempty"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Diff       json.RawMessage `json:"diff"`
+}
+
+// handlerAuditList pages through the audit log, newest first, like
+// handlerMessagesList.
+func (cfg *apiConfig) handlerAuditList(w http.ResponseWriter, r *http.Request, user database.User) {
+	const defaultLimit, maxLimit = 50, 100
+	type response struct {
+		Events     []AuditEvent `json:"events"`
+		NextCursor string       `json:"next_cursor,omitempty"`
+	}
+
+	params, err := auditFilter(r)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+	// One more than the page tells us whether there's another page.
+	params.MaxEvents = int32(limit) + 1
+
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	dbEvents, err := cfg.db.GetAuditEvents(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve audit events", err)
+		return
+	}
+
+	resp := response{
+		Events: []AuditEvent{},
+	}
+	if len(dbEvents) > limit {
+		dbEvents = dbEvents[:limit]
+		resp.NextCursor = encodeCursor(dbEvents[limit-1].CreatedAt, dbEvents[limit-1].ID)
+	}
+	for _, e := range dbEvents {
+		resp.Events = append(resp.Events, auditEventFromDB(e))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerAuditExport streams every audit event matching the filters as
+// newline-delimited JSON, newest first.
+func (cfg *apiConfig) handlerAuditExport(w http.ResponseWriter, r *http.Request, user database.User) {
+	params, err := auditFilter(r)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+	params.MaxEvents = auditExportPageSize
+
+	started := false
+	enc := json.NewEncoder(w)
+	for {
+		dbEvents, err := cfg.db.GetAuditEvents(r.Context(), params)
+		if err != nil {
+			if !started {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve audit events", err)
+				return
+			}
+			// It's too late to change the status, so the export just
+			// ends early.
+			log.Printf("Couldn't export audit events: %s", err)
+			return
+		}
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		for _, e := range dbEvents {
+			if err := enc.Encode(auditEventFromDB(e)); err != nil {
+				return
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(dbEvents) < auditExportPageSize {
+			return
+		}
+		last := dbEvents[len(dbEvents)-1]
+		params.BeforeCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+}
+
+// auditFilter reads the audit log filters from r's query. Errors are
+// *requestErrors.
+func auditFilter(r *http.Request) (database.GetAuditEventsParams, error) {
+	query := r.URL.Query()
+	params := database.GetAuditEventsParams{}
+	v := validation{}
+
+	if action := query.Get("action"); action != "" {
+		params.Action = sql.NullString{String: action, Valid: true}
+	}
+	if actorID := query.Get("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			v.add("actor_id", "must be a UUID")
+		}
+		params.ActorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	if targetType := query.Get("target_type"); targetType != "" {
+		params.TargetType = sql.NullString{String: targetType, Valid: true}
+	}
+	if targetID := query.Get("target_id"); targetID != "" {
+		params.TargetID = sql.NullString{String: targetID, Valid: true}
+	}
+	for _, bound := range []struct {
+		field string
+		dst   *sql.NullTime
+	}{
+		{"since", &params.Since},
+		{"until", &params.Until},
+	} {
+		value := query.Get(bound.field)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			v.add(bound.field, "must be an RFC 3339 date-time")
+			continue
+		}
+		*bound.dst = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+
+	return params, v.err()
+}
+
+func auditEventFromDB(e database.AuditEvent) AuditEvent {
+	event := AuditEvent{
+		ID:         e.ID,
+		CreatedAt:  e.CreatedAt,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		IP:         e.Ip,
+		RequestID:  e.RequestID,
+		Diff:       e.Diff,
+	}
+	if e.ActorID.Valid {
+		event.ActorID = &e.ActorID.UUID
+	}
+	return event
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	admin, adminLogin := newTestUser(t, srv, "admin@example.com")
+	if _, err := cfg.db.SetUserAdmin(ctx, database.SetUserAdminParams{ID: adminLogin.ID, IsAdmin: true}); err != nil {
+		t.Fatalf("SetUserAdmin() error = %v", err)
+	}
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+
+	anonymous := client.New(srv.URL)
+	if _, err := anonymous.Login(ctx, client.Credentials{Email: "alice@example.com", Password: "wrong"}); err == nil {
+		t.Fatal("Login() with the wrong password succeeded")
+	}
+	if _, err := anonymous.Login(ctx, client.Credentials{Email: "nobody@example.com", Password: "password"}); err == nil {
+		t.Fatal("Login() of an unknown user succeeded")
+	}
+	if _, err := alice.UpdateUser(ctx, nil, client.UpdateUserRequest{Email: "alice@example.org", Password: "secret"}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if _, err := alice.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	polka := client.New(srv.URL)
+	polka.APIKey = cfg.polkaKey
+	err := polka.PolkaWebhook(ctx, client.PolkaWebhookRequest{
+		Event: "user.upgraded",
+		Data:  client.PolkaWebhookData{UserID: aliceLogin.ID},
+	})
+	if err != nil {
+		t.Fatalf("PolkaWebhook() error = %v", err)
+	}
+	chirp, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "soon gone"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if err := alice.DeleteChirp(ctx, chirp.ID, nil); err != nil {
+		t.Fatalf("DeleteChirp() error = %v", err)
+	}
+	if err := alice.Revoke(ctx); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	aliceID := aliceLogin.ID
+	page, err := admin.ListAuditEvents(ctx, &client.ListAuditEventsParams{ActorID: &aliceID})
+	if err != nil {
+		t.Fatalf("ListAuditEvents() error = %v", err)
+	}
+	actions := []string{}
+	for _, e := range page.Events {
+		actions = append(actions, e.Action)
+		if e.IP != "127.0.0.1" || e.RequestID == "" {
+			t.Errorf("event %s ip = %q, request ID = %q, want 127.0.0.1 and an ID", e.Action, e.IP, e.RequestID)
+		}
+	}
+	want := []string{auditTokenRevoked, auditChirpDeleted, auditTokenRefreshed, auditUserUpdated, auditLoginSucceeded}
+	if len(actions) != len(want) {
+		t.Fatalf("alice's audit events = %v, want %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Errorf("alice's audit events = %v, want %v", actions, want)
+			break
+		}
+	}
+
+	diff := map[string]auditChange{}
+	if err := json.Unmarshal(page.Events[3].Diff, &diff); err != nil {
+		t.Fatalf("decoding user.updated diff: %v", err)
+	}
+	if diff["email"].Old != "alice@example.com" || diff["email"].New != "alice@example.org" || diff["password"].New != auditRedacted {
+		t.Errorf("user.updated diff = %+v, want the email change and a redacted password", diff)
+	}
+	if len(diff) != 2 {
+		t.Errorf("user.updated diff = %+v, want only email and password", diff)
+	}
+	if page.Events[1].TargetID != chirp.ID.String() {
+		t.Errorf("chirp.deleted target = %q, want %s", page.Events[1].TargetID, chirp.ID)
+	}
+
+	failed := auditLoginFailed
+	page, err = admin.ListAuditEvents(ctx, &client.ListAuditEventsParams{Action: &failed})
+	if err != nil {
+		t.Fatalf("ListAuditEvents(action) error = %v", err)
+	}
+	if len(page.Events) != 2 || page.Events[0].TargetID != "nobody@example.com" || page.Events[1].TargetID != aliceID.String() {
+		t.Errorf("failed logins = %+v, want nobody@example.com then alice", page.Events)
+	}
+	if page.Events[0].ActorID != uuid.Nil {
+		t.Errorf("failed login actor = %s, want none", page.Events[0].ActorID)
+	}
+
+	upgraded := auditUserUpgraded
+	page, _ = admin.ListAuditEvents(ctx, &client.ListAuditEventsParams{Action: &upgraded})
+	if len(page.Events) != 1 || page.Events[0].TargetID != aliceID.String() {
+		t.Errorf("upgrades = %+v, want alice's", page.Events)
+	}
+
+	var apiErr *client.APIError
+	_, err = alice.ListAuditEvents(ctx, nil)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("ListAuditEvents() as a non-admin error = %v, want 403", err)
+	}
+}
+
+func TestAuditLogPagingAndExport(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	admin, adminLogin := newTestUser(t, srv, "admin@example.com")
+	if _, err := cfg.db.SetUserAdmin(ctx, database.SetUserAdminParams{ID: adminLogin.ID, IsAdmin: true}); err != nil {
+		t.Fatalf("SetUserAdmin() error = %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := admin.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+	}
+
+	seen := map[uuid.UUID]bool{}
+	limit := 2
+	params := &client.ListAuditEventsParams{Limit: &limit}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("ListAuditEvents() never ran out of pages")
+		}
+		page, err := admin.ListAuditEvents(ctx, params)
+		if err != nil {
+			t.Fatalf("ListAuditEvents() error = %v", err)
+		}
+		for _, e := range page.Events {
+			if seen[e.ID] {
+				t.Errorf("event %s is on more than one page", e.ID)
+			}
+			seen[e.ID] = true
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		params.Cursor = &page.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Errorf("paging found %d events, want 5", len(seen))
+	}
+
+	body, err := admin.ExportAuditEvents(ctx, nil)
+	if err != nil {
+		t.Fatalf("ExportAuditEvents() error = %v", err)
+	}
+	defer body.Close()
+	exported := 0
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		e := client.AuditEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("decoding exported line %q: %v", scanner.Text(), err)
+		}
+		if !seen[e.ID] {
+			t.Errorf("exported event %s wasn't listed", e.ID)
+		}
+		exported++
+	}
+	if exported != 5 {
+		t.Errorf("exported %d events, want 5", exported)
+	}
+
+	// The audit log outlives a reset, which it records.
+	if _, err := client.New(srv.URL).AdminReset(ctx); err != nil {
+		t.Fatalf("AdminReset() error = %v", err)
+	}
+	events, err := cfg.db.GetAuditEvents(ctx, database.GetAuditEventsParams{MaxEvents: 10})
+	if err != nil || len(events) != 6 || events[0].Action != auditAdminReset {
+		t.Errorf("audit events after reset = %d, %v, want the 5 from before and the reset", len(events), err)
+	}
+}
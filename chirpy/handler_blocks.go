@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// handlerUsersBlock blocks a user. Blocked users can't follow, mention
+// or message the blocker, so any follows between the two are removed.
+func (cfg *apiConfig) handlerUsersBlock(w http.ResponseWriter, r *http.Request, user database.User) {
+	blockedID, ok := cfg.relationshipTarget(w, r, user, "block")
+	if !ok {
+		return
+	}
+
+	err := cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		if _, err := tx.BlockUser(r.Context(), database.BlockUserParams{
+			BlockerID: user.ID,
+			BlockedID: blockedID,
+		}); err != nil {
+			return err
+		}
+		if err := tx.UnfollowUser(r.Context(), database.UnfollowUserParams{
+			FollowerID: user.ID,
+			FolloweeID: blockedID,
+		}); err != nil {
+			return err
+		}
+		return tx.UnfollowUser(r.Context(), database.UnfollowUserParams{
+			FollowerID: blockedID,
+			FolloweeID: user.ID,
+		})
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't block user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUsersUnblock(w http.ResponseWriter, r *http.Request, user database.User) {
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	err = cfg.db.UnblockUser(r.Context(), database.UnblockUserParams{
+		BlockerID: user.ID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unblock user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUsersMute hides a user's chirps from the requester's timelines
+// without them knowing.
+func (cfg *apiConfig) handlerUsersMute(w http.ResponseWriter, r *http.Request, user database.User) {
+	mutedID, ok := cfg.relationshipTarget(w, r, user, "mute")
+	if !ok {
+		return
+	}
+
+	_, err := cfg.db.MuteUser(r.Context(), database.MuteUserParams{
+		MuterID: user.ID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUsersUnmute(w http.ResponseWriter, r *http.Request, user database.User) {
+	mutedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	err = cfg.db.UnmuteUser(r.Context(), database.UnmuteUserParams{
+		MuterID: user.ID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unmute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// relationshipTarget parses the userID path value of a block or mute and
+// checks that it is another user who exists. It writes the error response
+// and returns false otherwise.
+func (cfg *apiConfig) relationshipTarget(w http.ResponseWriter, r *http.Request, user database.User, action string) (uuid.UUID, bool) {
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return uuid.Nil, false
+	}
+	if targetID == user.ID {
+		respondWithError(w, http.StatusBadRequest, "You can't "+action+" yourself", nil)
+		return uuid.Nil, false
+	}
+
+	target, err := cfg.db.GetUserByID(r.Context(), targetID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			return uuid.Nil, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return uuid.Nil, false
+	}
+	if target.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return uuid.Nil, false
+	}
+	return targetID, true
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+// chirpAuthors returns who wrote each chirp, in order.
+func chirpAuthors(chirps []client.Chirp) []uuid.UUID {
+	authors := []uuid.UUID{}
+	for _, chirp := range chirps {
+		authors = append(authors, chirp.UserID)
+	}
+	return authors
+}
+
+func TestMuteFiltersChirps(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+	carol, carolLogin := newTestUser(t, srv, "carol@example.com")
+
+	if _, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "bob on #go"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if _, err := carol.CreateChirp(ctx, client.CreateChirpRequest{Body: "carol on #go"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+
+	if err := alice.MuteUser(ctx, bobLogin.ID); err != nil {
+		t.Fatalf("MuteUser() error = %v", err)
+	}
+	if err := alice.MuteUser(ctx, bobLogin.ID); err != nil {
+		t.Fatalf("MuteUser() again error = %v", err)
+	}
+
+	chirps, err := alice.ListChirps(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListChirps() error = %v", err)
+	}
+	if authors := chirpAuthors(chirps); len(authors) != 1 || authors[0] != carolLogin.ID {
+		t.Errorf("ListChirps() authors = %v, want only carol", authors)
+	}
+	page, err := alice.GetHashtagChirps(ctx, "go", nil)
+	if err != nil {
+		t.Fatalf("GetHashtagChirps() error = %v", err)
+	}
+	if authors := chirpAuthors(page.Chirps); len(authors) != 1 || authors[0] != carolLogin.ID {
+		t.Errorf("GetHashtagChirps() authors = %v, want only carol", authors)
+	}
+
+	// Mutes only affect the user who muted.
+	if chirps, _ := client.New(srv.URL).ListChirps(ctx, nil); len(chirps) != 2 {
+		t.Errorf("anonymous ListChirps() = %d chirps, want 2", len(chirps))
+	}
+	if chirps, _ := bob.ListChirps(ctx, nil); len(chirps) != 2 {
+		t.Errorf("bob's ListChirps() = %d chirps, want 2", len(chirps))
+	}
+
+	if err := alice.UnmuteUser(ctx, bobLogin.ID); err != nil {
+		t.Fatalf("UnmuteUser() error = %v", err)
+	}
+	if chirps, _ := alice.ListChirps(ctx, nil); len(chirps) != 2 {
+		t.Errorf("ListChirps() after unmuting = %d chirps, want 2", len(chirps))
+	}
+}
+
+func TestMuteAndBlockTargets(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+
+	tests := []struct {
+		name   string
+		call   func(context.Context, uuid.UUID) error
+		userID uuid.UUID
+		status int
+	}{
+		{"mute yourself", alice.MuteUser, aliceLogin.ID, http.StatusBadRequest},
+		{"mute unknown user", alice.MuteUser, uuid.New(), http.StatusNotFound},
+		{"block yourself", alice.BlockUser, aliceLogin.ID, http.StatusBadRequest},
+		{"block unknown user", alice.BlockUser, uuid.New(), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(ctx, tt.userID)
+			var apiErr *client.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("error = %v, want status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestBlock(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+
+	if _, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "from alice"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if _, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "from bob"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if err := bob.FollowUser(ctx, aliceLogin.ID); err != nil {
+		t.Fatalf("FollowUser() error = %v", err)
+	}
+
+	if err := alice.BlockUser(ctx, bobLogin.ID); err != nil {
+		t.Fatalf("BlockUser() error = %v", err)
+	}
+
+	var apiErr *client.APIError
+	err := bob.FollowUser(ctx, aliceLogin.ID)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("FollowUser() of the blocker error = %v, want 403", err)
+	}
+	err = alice.FollowUser(ctx, bobLogin.ID)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("FollowUser() of the blocked user error = %v, want 403", err)
+	}
+
+	// Neither sees the other's chirps.
+	if chirps, _ := alice.ListChirps(ctx, nil); len(chirps) != 1 || chirps[0].UserID != aliceLogin.ID {
+		t.Errorf("alice's ListChirps() = %+v, want only her own", chirps)
+	}
+	if chirps, _ := bob.ListChirps(ctx, nil); len(chirps) != 1 || chirps[0].UserID != bobLogin.ID {
+		t.Errorf("bob's ListChirps() = %+v, want only his own", chirps)
+	}
+
+	// Mentions of the blocker don't notify them.
+	if _, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "hey @alice@example.com"}); err != nil {
+		t.Fatalf("CreateChirp() with a mention error = %v", err)
+	}
+	inbox, err := alice.ListNotifications(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListNotifications() error = %v", err)
+	}
+	if len(inbox.Notifications) != 1 || inbox.Notifications[0].Kind != "follow" {
+		t.Errorf("ListNotifications() = %+v, want only the follow from before the block", inbox)
+	}
+
+	if err := alice.UnblockUser(ctx, bobLogin.ID); err != nil {
+		t.Fatalf("UnblockUser() error = %v", err)
+	}
+	if chirps, _ := alice.ListChirps(ctx, nil); len(chirps) != 3 {
+		t.Errorf("ListChirps() after unblocking = %d chirps, want 3", len(chirps))
+	}
+	// The block removed bob's follow, so following again is new.
+	if err := bob.FollowUser(ctx, aliceLogin.ID); err != nil {
+		t.Fatalf("FollowUser() after unblocking error = %v", err)
+	}
+	if inbox, _ := alice.ListNotifications(ctx, nil); len(inbox.Notifications) != 2 {
+		t.Errorf("ListNotifications() = %+v, want a second follow", inbox)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// Bookmark is a chirp a user saved for later.
+type Bookmark struct {
+	Chirp        Chirp     `json:"chirp"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+}
+
+func (cfg *apiConfig) handlerChirpsBookmark(w http.ResponseWriter, r *http.Request, user database.User) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+	if !chirpVisibleTo(chirp, user) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", nil)
+		return
+	}
+
+	err = cfg.db.BookmarkChirp(r.Context(), database.BookmarkChirpParams{
+		UserID:  user.ID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't bookmark chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerChirpsUnbookmark(w http.ResponseWriter, r *http.Request, user database.User) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	err = cfg.db.UnbookmarkChirp(r.Context(), database.UnbookmarkChirpParams{
+		UserID:  user.ID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove bookmark", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerBookmarksList pages through the user's bookmarks, most recently
+// bookmarked first. The cursor is the bookmark time and chirp ID of the
+// last bookmark on the previous page.
+func (cfg *apiConfig) handlerBookmarksList(w http.ResponseWriter, r *http.Request, user database.User) {
+	const defaultLimit, maxLimit = 50, 100
+	type response struct {
+		Bookmarks  []Bookmark `json:"bookmarks"`
+		NextCursor string     `json:"next_cursor,omitempty"`
+	}
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	params := database.GetBookmarksParams{
+		UserID: user.ID,
+		// One more than the page tells us whether there's another page.
+		MaxBookmarks: int32(limit) + 1,
+	}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		bookmarkedAt, chirpID, err := decodeCursor(cursor)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		params.BeforeBookmarkedAt = sql.NullTime{Time: bookmarkedAt, Valid: true}
+		params.BeforeChirpID = uuid.NullUUID{UUID: chirpID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetBookmarks(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve bookmarks", err)
+		return
+	}
+
+	resp := response{
+		Bookmarks: []Bookmark{},
+	}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		resp.NextCursor = encodeCursor(rows[limit-1].BookmarkedAt, rows[limit-1].ID)
+	}
+	chirps := []Chirp{}
+	for _, row := range rows {
+		chirps = append(chirps, chirpFromDB(database.Chirp{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			Body:      row.Body,
+			UserID:    row.UserID,
+			HiddenAt:  row.HiddenAt,
+		}))
+	}
+	if err := cfg.withLinkPreviews(r.Context(), chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get link previews", err)
+		return
+	}
+	for i, row := range rows {
+		resp.Bookmarks = append(resp.Bookmarks, Bookmark{
+			Chirp:        chirps[i],
+			BookmarkedAt: row.BookmarkedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
-	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+	"github.com/lsherman98/boot.dev/chirpy/internal/webhooks"
 	"github.com/google/uuid"
 )
 
-func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request) {
+func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request, user database.User) {
 	chirpIDString := r.PathValue("chirpID")
 	chirpID, err := uuid.Parse(chirpIDString)
 	if err != nil {
@@ -15,32 +19,48 @@ func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
-		return
-	}
-
 	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
 		return
 	}
-	if dbChirp.UserID != userID {
+	if dbChirp.UserID != user.ID {
 		respondWithError(w, http.StatusForbidden, "You can't delete this chirp", err)
 		return
 	}
 
-	err = cfg.db.DeleteChirp(r.Context(), chirpID)
+	var currentETag string
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		var err error
+		dbChirp, err = tx.GetChirpForUpdate(r.Context(), chirpID)
+		if err != nil {
+			return err
+		}
+		currentETag = resourceETag(dbChirp.ID, dbChirp.UpdatedAt)
+		if !ifMatch(r, currentETag) {
+			return errPreconditionFailed
+		}
+
+		if err := tx.DeleteChirp(r.Context(), chirpID); err != nil {
+			return err
+		}
+		if err := writeAudit(r, tx, chirpDeletedRecord(user.ID, dbChirp)); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(r.Context(), tx, webhooks.EventChirpDeleted, dbChirp.UserID, chirpFromDB(dbChirp))
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
+		case errors.Is(err, errPreconditionFailed):
+			respondPreconditionFailed(w, currentETag)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Couldn't delete chirp", err)
+		}
 		return
 	}
+	cfg.publishChirpEvent(r.Context(), events.TypeChirpDeleted, chirpFromDB(dbChirp))
 
 	w.WriteHeader(http.StatusNoContent)
 }
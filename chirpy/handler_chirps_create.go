@@ -1,15 +1,19 @@
 package main
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
-	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
 	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+	"github.com/lsherman98/boot.dev/chirpy/internal/unfurl"
+	"github.com/lsherman98/boot.dev/chirpy/internal/webhooks"
 )
 
 type Chirp struct {
@@ -18,60 +22,113 @@ type Chirp struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	UserID    uuid.UUID `json:"user_id"`
 	Body      string    `json:"body"`
+	// PublishAt is set on chirps that are scheduled but not yet
+	// published.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	// Hidden is set on chirps a moderator has hidden. Only their author
+	// and admins see them.
+	Hidden bool `json:"hidden,omitempty"`
+	// LinkPreviews are the previews of the links in the body that have
+	// been fetched so far, in the order the links appear.
+	LinkPreviews []LinkPreview `json:"link_previews,omitempty"`
 }
 
-func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request) {
+func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
 	type parameters struct {
 		Body string `json:"body"`
+		// PublishAt schedules the chirp instead of publishing it now.
+		PublishAt *time.Time `json:"publish_at"`
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		respondWithRequestError(w, err)
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
+	cleaned, err := validateChirp(params.Body)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithRequestError(w, err)
 		return
 	}
 
-	cleaned, err := validateChirp(params.Body)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+	if params.PublishAt != nil {
+		cfg.scheduleChirp(w, r, user, cleaned, *params.PublishAt)
 		return
 	}
 
-	chirp, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		UserID: userID,
-		Body:   cleaned,
+	var chirp database.Chirp
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		var err error
+		chirp, err = tx.CreateChirp(r.Context(), database.CreateChirpParams{
+			UserID: user.ID,
+			Body:   cleaned,
+		})
+		if err != nil {
+			return err
+		}
+		return chirpCreated(r.Context(), tx, chirp)
 	})
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create chirp", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, Chirp{
+	resp := chirpFromDB(chirp)
+	// Links already in the preview cache have their previews right away.
+	chirps := []Chirp{resp}
+	if err := cfg.withLinkPreviews(r.Context(), chirps); err != nil {
+		log.Printf("Couldn't get link previews of chirp %s: %s", chirp.ID, err)
+	}
+	resp = chirps[0]
+	cfg.publishChirpEvent(r.Context(), events.TypeChirpCreated, resp)
+
+	w.Header().Set("ETag", resourceETag(chirp.ID, chirp.UpdatedAt))
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+// chirpCreated indexes a newly published chirp and queues its link
+// previews and webhooks.
+func chirpCreated(ctx context.Context, tx database.Store, chirp database.Chirp) error {
+	if err := indexChirp(ctx, tx, chirp); err != nil {
+		return err
+	}
+	if err := unfurl.Queue(ctx, tx, chirp); err != nil {
+		return err
+	}
+	return webhooks.Enqueue(ctx, tx, webhooks.EventChirpCreated, chirp.UserID, chirpFromDB(chirp))
+}
+
+func chirpFromDB(chirp database.Chirp) Chirp {
+	return Chirp{
 		ID:        chirp.ID,
 		CreatedAt: chirp.CreatedAt,
 		UpdatedAt: chirp.UpdatedAt,
 		UserID:    chirp.UserID,
 		Body:      chirp.Body,
-	})
+		Hidden:    chirp.HiddenAt.Valid,
+	}
 }
 
+// chirpVisibleTo reports whether viewer, which is the zero User for
+// anonymous requests, may see chirp.
+func chirpVisibleTo(chirp database.Chirp, viewer database.User) bool {
+	return !chirp.HiddenAt.Valid || chirp.UserID == viewer.ID || viewer.IsAdmin
+}
+
+// validateChirp checks a chirp body and returns it with bad words
+// masked. Errors are *requestErrors describing the problem with the
+// body field.
 func validateChirp(body string) (string, error) {
 	const maxChirpLength = 140
-	if len(body) > maxChirpLength {
-		return "", errors.New("Chirp is too long")
+	v := validation{}
+	v.require("body", body)
+	if utf8.RuneCountInString(body) > maxChirpLength {
+		v.add("body", fmt.Sprintf("must be at most %d characters", maxChirpLength))
+	}
+	if err := v.err(); err != nil {
+		return "", err
 	}
 
 	badWords := map[string]struct{}{
@@ -3,6 +3,7 @@ package main
 import (
 	"net/http"
 	"sort"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -20,18 +21,27 @@ func (cfg *apiConfig) handlerChirpsGet(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", err)
 		return
 	}
+	if !chirpVisibleTo(dbChirp, cfg.optionalUser(r)) {
+		respondWithError(w, http.StatusNotFound, "Couldn't get chirp", nil)
+		return
+	}
 
-	respondWithJSON(w, http.StatusOK, Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		UserID:    dbChirp.UserID,
-		Body:      dbChirp.Body,
-	})
+	if checkNotModified(w, r, resourceETag(dbChirp.ID, dbChirp.UpdatedAt), dbChirp.UpdatedAt) {
+		return
+	}
+
+	chirps := []Chirp{chirpFromDB(dbChirp)}
+	if err := cfg.withLinkPreviews(r.Context(), chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get link previews", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps[0])
 }
 
 func (cfg *apiConfig) handlerChirpsRetrieve(w http.ResponseWriter, r *http.Request) {
-	dbChirps, err := cfg.db.GetChirps(r.Context())
+	viewer := cfg.optionalUser(r)
+	dbChirps, err := cfg.db.GetChirps(r.Context(), viewer.ID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
 		return
@@ -58,22 +68,32 @@ func (cfg *apiConfig) handlerChirpsRetrieve(w http.ResponseWriter, r *http.Reque
 		if authorID != uuid.Nil && dbChirp.UserID != authorID {
 			continue
 		}
+		if !chirpVisibleTo(dbChirp, viewer) {
+			continue
+		}
 
-		chirps = append(chirps, Chirp{
-			ID:        dbChirp.ID,
-			CreatedAt: dbChirp.CreatedAt,
-			UpdatedAt: dbChirp.UpdatedAt,
-			UserID:    dbChirp.UserID,
-			Body:      dbChirp.Body,
-		})
+		chirps = append(chirps, chirpFromDB(dbChirp))
 	}
 
+	sortChirps(chirps, sortDirection)
+	if err := cfg.withLinkPreviews(r.Context(), chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get link previews", err)
+		return
+	}
+	if checkNotModified(w, r, chirpsETag(chirps), time.Time{}) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// sortChirps sorts chirps by creation time, oldest first unless
+// direction is "desc".
+func sortChirps(chirps []Chirp, direction string) {
 	sort.Slice(chirps, func(i, j int) bool {
-		if sortDirection == "desc" {
+		if direction == "desc" {
 			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
 		}
 		return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
 	})
-
-	respondWithJSON(w, http.StatusOK, chirps)
 }
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/unfurl"
+)
+
+const (
+	// maxImportBodyBytes caps the size of import request bodies.
+	maxImportBodyBytes = 10 << 20
+	// maxImportLineBytes caps the size of an NDJSON row. Longer lines are
+	// reported instead of imported.
+	maxImportLineBytes = 64 << 10
+	// maxImportRows caps how many chirps one import can create.
+	maxImportRows = 10000
+	// importBatchSize is how many chirps each insert creates.
+	importBatchSize = 500
+	// chirpExportPageSize is how many chirps the export reads at a time.
+	chirpExportPageSize = 500
+)
+
+// errTooManyImportRows is returned by the import readers when the import
+// has more than maxImportRows rows.
+var errTooManyImportRows = fmt.Errorf("import must not have more than %d rows", maxImportRows)
+
+// chirpCSVHeader is the header row of CSV exports. Imports read the body
+// and created_at columns and ignore the rest.
+var chirpCSVHeader = []string{"id", "created_at", "updated_at", "body", "hidden"}
+
+// ImportError describes a row that wasn't imported. Line is the line of
+// the request body the row starts on, counting from 1.
+type ImportError struct {
+	Line    int          `json:"line"`
+	Message string       `json:"message"`
+	Details []fieldError `json:"details,omitempty"`
+}
+
+// importRow is a chirp read from an import, before it is validated.
+type importRow struct {
+	line      int
+	body      string
+	createdAt string
+}
+
+// handlerChirpsImport creates chirps in bulk from NDJSON, one object with
+// a body and optional created_at per line, or from CSV with a header row
+// naming those columns. Other fields and columns are ignored, so exports
+// can be imported as they are.
+//
+// Rows are validated like new chirps and keep their original creation
+// time. Valid rows are all imported, in one transaction, and invalid ones
+// are reported by line.
+func (cfg *apiConfig) handlerChirpsImport(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		Imported int           `json:"imported"`
+		Errors   []ImportError `json:"errors"`
+	}
+
+	var read func(io.Reader) ([]importRow, []ImportError, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-ndjson":
+		read = readNDJSONImport
+	case "text/csv":
+		read = readCSVImport
+	default:
+		respondWithRequestError(w, &requestError{
+			status: http.StatusUnsupportedMediaType,
+			code:   errCodeUnsupportedMediaType,
+			msg:    "Content-Type must be application/x-ndjson or text/csv",
+		})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+	rows, rowErrors, err := read(r.Body)
+	if err != nil {
+		respondWithRequestError(w, importReadError(err))
+		return
+	}
+
+	resp := response{
+		Errors: rowErrors,
+	}
+	now := time.Now().UTC()
+	bodies := []string{}
+	createdAts := []time.Time{}
+	for _, row := range rows {
+		cleaned, createdAt, err := validateImportRow(row, now)
+		if err != nil {
+			reqErr := &requestError{}
+			errors.As(err, &reqErr)
+			resp.Errors = append(resp.Errors, ImportError{
+				Line:    row.line,
+				Message: reqErr.msg,
+				Details: reqErr.details,
+			})
+			continue
+		}
+		bodies = append(bodies, cleaned)
+		createdAts = append(createdAts, createdAt)
+	}
+	slices.SortFunc(resp.Errors, func(a, b ImportError) int {
+		return a.Line - b.Line
+	})
+
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		for start := 0; start < len(bodies); start += importBatchSize {
+			end := min(start+importBatchSize, len(bodies))
+			chirps, err := tx.ImportChirps(r.Context(), database.ImportChirpsParams{
+				UserID:     user.ID,
+				Bodies:     bodies[start:end],
+				CreatedAts: createdAts[start:end],
+			})
+			if err != nil {
+				return err
+			}
+			for _, chirp := range chirps {
+				if err := chirpImported(r.Context(), tx, chirp); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't import chirps", err)
+		return
+	}
+	resp.Imported = len(bodies)
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// chirpImported indexes an imported chirp's hashtags and queues its link
+// previews. Unlike chirpCreated it leaves mentions as plain text, since
+// they named users of another platform, and sends no webhooks: the chirp
+// isn't new.
+func chirpImported(ctx context.Context, tx database.Store, chirp database.Chirp) error {
+	if err := indexHashtags(ctx, tx, chirp); err != nil {
+		return err
+	}
+	return unfurl.Queue(ctx, tx, chirp)
+}
+
+// validateImportRow checks a row like validateChirp checks a new chirp's
+// body, and parses its creation time, which defaults to now. Errors are
+// *requestErrors listing every problem with the row.
+func validateImportRow(row importRow, now time.Time) (string, time.Time, error) {
+	v := validation{}
+	cleaned, err := validateChirp(row.body)
+	if reqErr := (&requestError{}); errors.As(err, &reqErr) {
+		v = append(v, reqErr.details...)
+	}
+
+	createdAt := now
+	if row.createdAt != "" {
+		t, err := time.Parse(time.RFC3339, row.createdAt)
+		switch {
+		case err != nil:
+			v.add("created_at", "must be an RFC 3339 date-time")
+		case t.After(now):
+			v.add("created_at", "must not be in the future")
+		default:
+			createdAt = t.UTC()
+		}
+	}
+	return cleaned, createdAt, v.err()
+}
+
+// readNDJSONImport reads one row from each non-blank line. Lines that
+// aren't JSON objects or are longer than maxImportLineBytes are reported
+// instead.
+func readNDJSONImport(r io.Reader) ([]importRow, []ImportError, error) {
+	rows := []importRow{}
+	rowErrors := []ImportError{}
+	// The body is capped, so whole lines can be read however long they
+	// are and the long ones reported.
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		dat, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return nil, nil, readErr
+		}
+		if len(bytes.TrimSpace(dat)) > 0 {
+			if len(rows)+len(rowErrors) >= maxImportRows {
+				return nil, nil, errTooManyImportRows
+			}
+			row, rowErr := parseNDJSONRow(line, dat)
+			if rowErr != nil {
+				rowErrors = append(rowErrors, *rowErr)
+			} else {
+				rows = append(rows, row)
+			}
+		}
+		if readErr != nil {
+			return rows, rowErrors, nil
+		}
+	}
+}
+
+// parseNDJSONRow reads the row on one line of an NDJSON import.
+func parseNDJSONRow(line int, dat []byte) (importRow, *ImportError) {
+	if len(bytes.TrimRight(dat, "\r\n")) > maxImportLineBytes {
+		return importRow{}, &ImportError{
+			Line:    line,
+			Message: fmt.Sprintf("Row must not be longer than %d bytes", maxImportLineBytes),
+		}
+	}
+
+	record := struct {
+		Body      string `json:"body"`
+		CreatedAt string `json:"created_at"`
+	}{}
+	err := json.Unmarshal(dat, &record)
+	typeErr := &json.UnmarshalTypeError{}
+	switch {
+	case errors.As(err, &typeErr):
+		return importRow{}, &ImportError{
+			Line:    line,
+			Message: "Row contains an invalid value",
+			Details: []fieldError{{Field: typeErr.Field, Message: fmt.Sprintf("must be a %s", typeErr.Type)}},
+		}
+	case err != nil:
+		return importRow{}, &ImportError{
+			Line:    line,
+			Message: "Row contains malformed JSON",
+		}
+	}
+	return importRow{
+		line:      line,
+		body:      record.Body,
+		createdAt: record.CreatedAt,
+	}, nil
+}
+
+// readCSVImport reads the body and created_at columns of each record
+// after the header row. Records that aren't valid CSV are reported
+// instead.
+func readCSVImport(r io.Reader) ([]importRow, []ImportError, error) {
+	reader := csv.NewReader(r)
+	// Missing columns are caught by validation instead.
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("import must start with a header row")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	bodyColumn, createdAtColumn := -1, -1
+	for i, name := range header {
+		// Spreadsheets tend to start their CSV files with a byte order
+		// mark.
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch name {
+		case "body":
+			bodyColumn = i
+		case "created_at":
+			createdAtColumn = i
+		}
+	}
+	if bodyColumn == -1 {
+		return nil, nil, errors.New("header row must have a body column")
+	}
+
+	rows := []importRow{}
+	rowErrors := []ImportError{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		parseErr := &csv.ParseError{}
+		if err != nil && !errors.As(err, &parseErr) {
+			return nil, nil, err
+		}
+		if len(rows)+len(rowErrors) >= maxImportRows {
+			return nil, nil, errTooManyImportRows
+		}
+		if err != nil {
+			// The reader picks up again after the broken record.
+			rowErrors = append(rowErrors, ImportError{
+				Line:    parseErr.StartLine,
+				Message: "Row contains malformed CSV: " + parseErr.Err.Error(),
+			})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importRow{line: line}
+		if bodyColumn < len(record) {
+			row.body = record[bodyColumn]
+		}
+		if createdAtColumn != -1 && createdAtColumn < len(record) {
+			row.createdAt = record[createdAtColumn]
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+// importReadError describes an import that couldn't be read at all.
+func importReadError(err error) *requestError {
+	maxBytesErr := &http.MaxBytesError{}
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return decodeError(err)
+	case errors.Is(err, errTooManyImportRows):
+		return &requestError{
+			status: http.StatusRequestEntityTooLarge,
+			code:   errCodeBodyTooLarge,
+			msg:    fmt.Sprintf("Import must not have more than %d rows", maxImportRows),
+			err:    err,
+		}
+	}
+	return &requestError{
+		status: http.StatusBadRequest,
+		code:   errCodeBadRequest,
+		msg:    "Couldn't read import: " + err.Error(),
+		err:    err,
+	}
+}
+
+// handlerChirpsExport streams all of the user's chirps, hidden ones
+// included, oldest first. They are newline-delimited JSON by default and
+// CSV with format=csv.
+func (cfg *apiConfig) handlerChirpsExport(w http.ResponseWriter, r *http.Request, user database.User) {
+	var contentType string
+	var encode func(Chirp) error
+	var flush func() error
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "", "ndjson":
+		format = "ndjson"
+		contentType = "application/x-ndjson"
+		enc := json.NewEncoder(w)
+		encode = func(chirp Chirp) error {
+			return enc.Encode(chirp)
+		}
+		flush = func() error { return nil }
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+		csvWriter := csv.NewWriter(w)
+		// Buffered until the first flush, after the headers are sent.
+		csvWriter.Write(chirpCSVHeader)
+		encode = func(chirp Chirp) error {
+			return csvWriter.Write([]string{
+				chirp.ID.String(),
+				chirp.CreatedAt.Format(time.RFC3339Nano),
+				chirp.UpdatedAt.Format(time.RFC3339Nano),
+				chirp.Body,
+				strconv.FormatBool(chirp.Hidden),
+			})
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	default:
+		v := validation{}
+		v.add("format", "must be ndjson or csv")
+		respondWithRequestError(w, v.err())
+		return
+	}
+
+	params := database.GetChirpsByUserPageParams{
+		UserID:    user.ID,
+		MaxChirps: chirpExportPageSize,
+	}
+	started := false
+	for {
+		dbChirps, err := cfg.db.GetChirpsByUserPage(r.Context(), params)
+		if err != nil {
+			if !started {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+				return
+			}
+			// It's too late to change the status, so the export just
+			// ends early.
+			log.Printf("Couldn't export chirps: %s", err)
+			return
+		}
+		if !started {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="chirps.%s"`, format))
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		for _, dbChirp := range dbChirps {
+			if err := encode(chirpFromDB(dbChirp)); err != nil {
+				return
+			}
+		}
+		if err := flush(); err != nil {
+			return
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(dbChirps) < chirpExportPageSize {
+			return
+		}
+		last := dbChirps[len(dbChirps)-1]
+		params.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+// exportedChirps reads an NDJSON export.
+func exportedChirps(t *testing.T, c *client.Client) []client.Chirp {
+	t.Helper()
+	body, err := c.ExportChirps(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ExportChirps() error = %v", err)
+	}
+	defer body.Close()
+
+	chirps := []client.Chirp{}
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		chirp := client.Chirp{}
+		if err := json.Unmarshal(scanner.Bytes(), &chirp); err != nil {
+			t.Fatalf("export line %q: %v", scanner.Text(), err)
+		}
+		chirps = append(chirps, chirp)
+	}
+	return chirps
+}
+
+func TestChirpsImportExport(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+	bob, _ := newTestUser(t, srv, "bob@example.com")
+
+	ndjson := strings.Join([]string{
+		`{"body": "hello from 2020 #migrated", "created_at": "2020-01-02T03:04:05Z"}`,
+		``,
+		`{"body": "no timestamp, what a kerfuffle", "source": "elsewhere"}`,
+		`{"body": "`,
+		`{"body": 42}`,
+		`{"body": "` + strings.Repeat("a", 141) + `", "created_at": "yesterday"}`,
+		`{"body": "from the future", "created_at": "2999-01-01T00:00:00Z"}`,
+		`{"body": "from 2021", "created_at": "2021-06-01T12:00:00+02:00"}`,
+	}, "\n")
+	result, err := alice.ImportChirps(ctx, "application/x-ndjson", strings.NewReader(ndjson))
+	if err != nil {
+		t.Fatalf("ImportChirps() error = %v", err)
+	}
+	if result.Imported != 3 {
+		t.Errorf("imported = %d, want 3", result.Imported)
+	}
+	lines := []int{}
+	for _, e := range result.Errors {
+		lines = append(lines, e.Line)
+	}
+	if fmt.Sprint(lines) != "[4 5 6 7]" {
+		t.Fatalf("error lines = %v, want [4 5 6 7]: %+v", lines, result.Errors)
+	}
+	if len(result.Errors[2].Details) != 2 {
+		t.Errorf("line 6 details = %+v, want body and created_at", result.Errors[2].Details)
+	}
+	if d := result.Errors[3].Details; len(d) != 1 || d[0].Field != "created_at" {
+		t.Errorf("line 7 details = %+v, want created_at", d)
+	}
+
+	chirps := exportedChirps(t, alice)
+	if len(chirps) != 3 {
+		t.Fatalf("exported %d chirps, want 3", len(chirps))
+	}
+	wantCreated := []time.Time{
+		time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+	}
+	for i, want := range wantCreated {
+		if !chirps[i].CreatedAt.Equal(want) || !chirps[i].UpdatedAt.Equal(want) {
+			t.Errorf("chirp %d created_at = %v, updated_at = %v, want both %v", i, chirps[i].CreatedAt, chirps[i].UpdatedAt, want)
+		}
+	}
+	if chirps[2].Body != "no timestamp, what a ****" || time.Since(chirps[2].CreatedAt) > time.Minute {
+		t.Errorf("last chirp = %+v, want the masked body created now", chirps[2])
+	}
+
+	tagged, err := bob.GetHashtagChirps(ctx, "migrated", nil)
+	if err != nil || len(tagged.Chirps) != 1 || tagged.Chirps[0].ID != chirps[0].ID {
+		t.Errorf("GetHashtagChirps(migrated) = %+v, %v, want the imported chirp", tagged, err)
+	}
+
+	// A CSV export imports as it is.
+	format := "csv"
+	csvExport, err := alice.ExportChirps(ctx, &client.ExportChirpsParams{Format: &format})
+	if err != nil {
+		t.Fatalf("ExportChirps(csv) error = %v", err)
+	}
+	dat, err := io.ReadAll(csvExport)
+	csvExport.Close()
+	if err != nil {
+		t.Fatalf("reading the CSV export: %v", err)
+	}
+	if !strings.HasPrefix(string(dat), "id,created_at,updated_at,body,hidden\n") {
+		t.Errorf("CSV export = %q, want a header row", dat)
+	}
+	result, err = bob.ImportChirps(ctx, "text/csv", strings.NewReader(string(dat)))
+	if err != nil || result.Imported != 3 || len(result.Errors) != 0 {
+		t.Fatalf("ImportChirps(csv) = %+v, %v, want 3 imported", result, err)
+	}
+	bobChirps := exportedChirps(t, bob)
+	for i := range chirps {
+		if bobChirps[i].Body != chirps[i].Body || !bobChirps[i].CreatedAt.Equal(chirps[i].CreatedAt) {
+			t.Errorf("bob's chirp %d = %+v, want a copy of %+v", i, bobChirps[i], chirps[i])
+		}
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"json", "application/json", `{"body": "hi"}`, http.StatusUnsupportedMediaType},
+		{"csv without header", "text/csv", "", http.StatusBadRequest},
+		{"csv without body column", "text/csv", "text\nhi\n", http.StatusBadRequest},
+		{"too many rows", "application/x-ndjson", strings.Repeat(`{"body": "hi"}`+"\n", maxImportRows+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := alice.ImportChirps(ctx, tc.contentType, strings.NewReader(tc.body))
+			apiErr := &client.APIError{}
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status {
+				t.Errorf("ImportChirps() error = %v, want a %d", err, tc.status)
+			}
+		})
+	}
+}
+
+func TestChirpsImportReportsUnreadableRows(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantLines   string
+	}{
+		{
+			name:        "ndjson line too long",
+			contentType: "application/x-ndjson",
+			body: strings.Join([]string{
+				`{"body": "before"}`,
+				`{"body": "` + strings.Repeat("a", maxImportLineBytes) + `"}`,
+				`{"body": "after"}`,
+			}, "\n"),
+			wantLines: "[2]",
+		},
+		{
+			name:        "csv bad quotes",
+			contentType: "text/csv",
+			body:        "body,created_at\nbefore,\n\"half\"quoted,\nbare \" quote,\nafter,\n",
+			wantLines:   "[3 4]",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := alice.ImportChirps(ctx, tc.contentType, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("ImportChirps() error = %v", err)
+			}
+			lines := []int{}
+			for _, e := range result.Errors {
+				lines = append(lines, e.Line)
+			}
+			if result.Imported != 2 || fmt.Sprint(lines) != tc.wantLines {
+				t.Errorf("ImportChirps() = %+v, want 2 imported and errors on lines %s", result, tc.wantLines)
+			}
+		})
+	}
+}
+
+func TestChirpsImportDoesNotTrend(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+
+	lastWeek := time.Now().UTC().Add(-7 * 24 * time.Hour).Format(time.RFC3339)
+	ndjson := strings.Join([]string{
+		`{"body": "old news #retro", "created_at": "2020-01-02T03:04:05Z"}`,
+		`{"body": "still old #retro", "created_at": "` + lastWeek + `"}`,
+		`{"body": "just now #fresh"}`,
+	}, "\n")
+	result, err := alice.ImportChirps(ctx, "application/x-ndjson", strings.NewReader(ndjson))
+	if err != nil || result.Imported != 3 {
+		t.Fatalf("ImportChirps() = %+v, %v, want 3 imported", result, err)
+	}
+
+	// Back-dated chirps count when they were made, not when imported.
+	trending, err := alice.GetTrendingHashtags(ctx, nil)
+	if err != nil || len(trending) != 1 || trending[0].Tag != "fresh" {
+		t.Errorf("GetTrendingHashtags() = %+v, %v, want only #fresh", trending, err)
+	}
+}
+
+func TestChirpsImportExportPages(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	carol, _ := newTestUser(t, srv, "carol@example.com")
+
+	// More rows than an insert batch or an export page holds.
+	const n = importBatchSize + chirpExportPageSize + 1
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := strings.Builder{}
+	for i := range n {
+		fmt.Fprintf(&b, `{"body": "chirp %d", "created_at": %q}`+"\n", i, start.Add(time.Duration(i)*time.Second).Format(time.RFC3339))
+	}
+	result, err := carol.ImportChirps(ctx, "application/x-ndjson", strings.NewReader(b.String()))
+	if err != nil || result.Imported != n {
+		t.Fatalf("ImportChirps() = %d imported, %v, want %d", result.Imported, err, n)
+	}
+
+	chirps := exportedChirps(t, carol)
+	if len(chirps) != n {
+		t.Fatalf("exported %d chirps, want %d", len(chirps), n)
+	}
+	for i, chirp := range chirps {
+		if want := fmt.Sprintf("chirp %d", i); chirp.Body != want {
+			t.Fatalf("chirp %d = %q, want %q", i, chirp.Body, want)
+		}
+	}
+}
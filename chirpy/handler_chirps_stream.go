@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment so proxies
+// don't time the connection out.
+const streamKeepAlive = 15 * time.Second
+
+func (cfg *apiConfig) handlerChirpsStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming is not supported", nil)
+		return
+	}
+
+	authorID := uuid.Nil
+	authorIDString := r.URL.Query().Get("author_id")
+	if authorIDString != "" {
+		var err error
+		authorID, err = uuid.Parse(authorIDString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid author ID", err)
+			return
+		}
+	}
+
+	lastEventID := int64(0)
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		lastEventID, err = strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid Last-Event-ID", err)
+			return
+		}
+	}
+
+	// Signed in users also get the events of their conversations, which
+	// author_id doesn't narrow down, and don't get chirps from the
+	// authors they muted or blocked when they connected.
+	viewer := cfg.optionalUser(r)
+	hidden := map[uuid.UUID]bool{}
+	if viewer.ID != uuid.Nil {
+		hiddenIDs, err := cfg.db.GetHiddenAuthorIDs(r.Context(), viewer.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get muted users", err)
+			return
+		}
+		for _, id := range hiddenIDs {
+			hidden[id] = true
+		}
+	}
+	filter := func(e events.Event) bool {
+		if len(e.Recipients) > 0 {
+			return e.VisibleTo(viewer.ID)
+		}
+		if hidden[e.AuthorID] {
+			return false
+		}
+		return authorID == uuid.Nil || e.AuthorID == authorID
+	}
+	sub, missed := cfg.events.Subscribe(lastEventID, filter)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+
+	for _, e := range missed {
+		writeStreamEvent(w, e)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				// We fell behind; the client will reconnect with the
+				// last ID it saw and catch up from history.
+				return
+			}
+			writeStreamEvent(w, e)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, e events.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
+
+// publishEvent publishes e to stream subscribers. Failures are only logged
+// since the change itself has already happened.
+func (cfg *apiConfig) publishEvent(ctx context.Context, e events.Event) {
+	if cfg.eventPublisher == nil {
+		return
+	}
+	if err := cfg.eventPublisher.Publish(ctx, e); err != nil {
+		log.Printf("Couldn't publish %s event: %s", e.Type, err)
+	}
+}
+
+// publishChirpEvent tells stream subscribers about a change to chirp.
+func (cfg *apiConfig) publishChirpEvent(ctx context.Context, eventType string, chirp Chirp) {
+	data, err := json.Marshal(chirp)
+	if err != nil {
+		log.Printf("Couldn't encode %s event: %s", eventType, err)
+		return
+	}
+	cfg.publishEvent(ctx, events.Event{
+		Type:     eventType,
+		AuthorID: chirp.UserID,
+		Data:     data,
+	})
+}
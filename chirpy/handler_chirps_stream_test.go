@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+)
+
+type streamEvent struct {
+	id        string
+	eventType string
+	data      string
+}
+
+// readStreamEvent reads the next event from an SSE stream, skipping
+// comments and retry hints.
+func readStreamEvent(t *testing.T, r *bufio.Reader) streamEvent {
+	t.Helper()
+	e := streamEvent{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if e.eventType != "" {
+				return e
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			e.id = value
+		case "event":
+			e.eventType = value
+		case "data":
+			e.data = value
+		}
+	}
+}
+
+func TestChirpsStream(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, _ := newTestUser(t, srv, "bob@example.com")
+	aliceID := aliceLogin.ID
+
+	stream, err := alice.StreamChirps(ctx, &client.StreamChirpsParams{AuthorID: &aliceID})
+	if err != nil {
+		t.Fatalf("StreamChirps() error = %v", err)
+	}
+	defer stream.Close()
+	r := bufio.NewReader(stream)
+
+	if _, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "from bob"}); err != nil {
+		t.Fatal(err)
+	}
+	chirp, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "from alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.DeleteChirp(ctx, chirp.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	created := readStreamEvent(t, r)
+	if created.eventType != events.TypeChirpCreated {
+		t.Fatalf("first event = %+v, want %s", created, events.TypeChirpCreated)
+	}
+	got := client.Chirp{}
+	if err := json.Unmarshal([]byte(created.data), &got); err != nil || got.ID != chirp.ID {
+		t.Errorf("created event data = %s, want chirp %v", created.data, chirp.ID)
+	}
+
+	deleted := readStreamEvent(t, r)
+	if deleted.eventType != events.TypeChirpDeleted {
+		t.Fatalf("second event = %+v, want %s", deleted, events.TypeChirpDeleted)
+	}
+
+	// Reconnecting with the ID of the created event replays the deletion.
+	lastEventID, err := strconv.ParseInt(created.id, 10, 64)
+	if err != nil {
+		t.Fatalf("event ID %q isn't an integer", created.id)
+	}
+	resumed, err := alice.StreamChirps(ctx, &client.StreamChirpsParams{AuthorID: &aliceID, LastEventID: &lastEventID})
+	if err != nil {
+		t.Fatalf("StreamChirps() resume error = %v", err)
+	}
+	defer resumed.Close()
+	replayed := readStreamEvent(t, bufio.NewReader(resumed))
+	if replayed.id != deleted.id || replayed.eventType != events.TypeChirpDeleted {
+		t.Errorf("resumed stream sent %+v, want %+v", replayed, deleted)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/chirptext"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+type TrendingHashtag struct {
+	Tag  string `json:"tag"`
+	Uses int    `json:"uses"`
+}
+
+// handlerHashtagChirps pages through the chirps using a hashtag, oldest
+// first unless sort is desc.
+func (cfg *apiConfig) handlerHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	const defaultLimit, maxLimit = 50, 100
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	tag := r.PathValue("tag")
+	if !chirptext.ValidHashtag(tag) {
+		respondWithError(w, http.StatusBadRequest, "Invalid hashtag", nil)
+		return
+	}
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	viewer := cfg.optionalUser(r)
+	params := database.GetChirpsByHashtagParams{
+		Tag:           chirptext.NormalizeHashtag(tag),
+		ViewerID:      viewer.ID,
+		ViewerIsAdmin: viewer.IsAdmin,
+		NewestFirst:   r.URL.Query().Get("sort") == "desc",
+		// One more than the page tells us whether there's another page.
+		MaxChirps: int32(limit) + 1,
+	}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		params.AfterCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByHashtag(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	resp := response{
+		Chirps: []Chirp{},
+	}
+	if len(dbChirps) > limit {
+		dbChirps = dbChirps[:limit]
+		resp.NextCursor = encodeCursor(dbChirps[limit-1].CreatedAt, dbChirps[limit-1].ID)
+	}
+	for _, dbChirp := range dbChirps {
+		resp.Chirps = append(resp.Chirps, chirpFromDB(dbChirp))
+	}
+	if err := cfg.withLinkPreviews(r.Context(), resp.Chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get link previews", err)
+		return
+	}
+	if checkNotModified(w, r, chirpPageETag(resp.Chirps, resp.NextCursor), time.Time{}) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerHashtagsTrending(w http.ResponseWriter, r *http.Request) {
+	const defaultWindow, maxWindow = 24 * time.Hour, 7 * 24 * time.Hour
+	const defaultLimit, maxLimit = 10, 50
+
+	window := defaultWindow
+	if windowString := r.URL.Query().Get("window"); windowString != "" {
+		var err error
+		window, err = time.ParseDuration(windowString)
+		if err != nil || window <= 0 || window > maxWindow {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("window must be a duration up to %s", maxWindow), err)
+			return
+		}
+	}
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	rows, err := cfg.db.GetTrendingHashtags(r.Context(), database.GetTrendingHashtagsParams{
+		Since:   time.Now().UTC().Add(-window),
+		MaxTags: int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve trending hashtags", err)
+		return
+	}
+
+	trending := []TrendingHashtag{}
+	for _, row := range rows {
+		trending = append(trending, TrendingHashtag{Tag: row.Tag, Uses: int(row.Uses)})
+	}
+
+	respondWithJSON(w, http.StatusOK, trending)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+func TestHashtagChirpsPaging(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+
+	for i := range 5 {
+		if _, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: fmt.Sprintf("#paged %d", i)}); err != nil {
+			t.Fatalf("CreateChirp() error = %v", err)
+		}
+	}
+
+	limit := 2
+	for _, sort := range []string{"asc", "desc"} {
+		bodies := []string{}
+		params := &client.GetHashtagChirpsParams{Sort: &sort, Limit: &limit}
+		for pages := 1; ; pages++ {
+			page, err := alice.GetHashtagChirps(ctx, "paged", params)
+			if err != nil {
+				t.Fatalf("GetHashtagChirps(sort=%s) page %d error = %v", sort, pages, err)
+			}
+			for _, chirp := range page.Chirps {
+				bodies = append(bodies, chirp.Body)
+			}
+			if page.NextCursor == "" {
+				if pages != 3 {
+					t.Errorf("sort=%s took %d pages, want 3", sort, pages)
+				}
+				break
+			}
+			if pages == 3 {
+				t.Fatalf("sort=%s has a page after the last chirp", sort)
+			}
+			params.Cursor = &page.NextCursor
+		}
+
+		want := []string{"#paged 0", "#paged 1", "#paged 2", "#paged 3", "#paged 4"}
+		if sort == "desc" {
+			want = []string{"#paged 4", "#paged 3", "#paged 2", "#paged 1", "#paged 0"}
+		}
+		if fmt.Sprint(bodies) != fmt.Sprint(want) {
+			t.Errorf("sort=%s pages = %q, want %q", sort, bodies, want)
+		}
+	}
+
+	badCursor := "not-a-cursor"
+	_, err := alice.GetHashtagChirps(ctx, "paged", &client.GetHashtagChirpsParams{Cursor: &badCursor})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("GetHashtagChirps() with a bad cursor error = %v, want a 400", err)
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// maxListNameLength caps the length of a list's name, in characters.
+const maxListNameLength = 50
+
+// List is a named group of users. Private lists are only visible to
+// their owner.
+type List struct {
+	ID        uuid.UUID   `json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	OwnerID   uuid.UUID   `json:"owner_id"`
+	Name      string      `json:"name"`
+	Private   bool        `json:"private"`
+	MemberIDs []uuid.UUID `json:"member_ids"`
+}
+
+func (cfg *apiConfig) handlerListsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name    string `json:"name"`
+		Private bool   `json:"private"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	name := strings.TrimSpace(params.Name)
+	v := validation{}
+	v.require("name", name)
+	if utf8.RuneCountInString(name) > maxListNameLength {
+		v.add("name", fmt.Sprintf("must be at most %d characters", maxListNameLength))
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	list, err := cfg.db.CreateUserList(r.Context(), database.CreateUserListParams{
+		OwnerID:   user.ID,
+		Name:      name,
+		IsPrivate: params.Private,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create list", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, List{
+		ID:        list.ID,
+		CreatedAt: list.CreatedAt,
+		UpdatedAt: list.UpdatedAt,
+		OwnerID:   list.OwnerID,
+		Name:      list.Name,
+		Private:   list.IsPrivate,
+		MemberIDs: []uuid.UUID{},
+	})
+}
+
+// handlerListsList returns the user's own lists, oldest first.
+func (cfg *apiConfig) handlerListsList(w http.ResponseWriter, r *http.Request, user database.User) {
+	dbLists, err := cfg.db.GetUserListsByOwner(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve lists", err)
+		return
+	}
+	lists, err := cfg.listsFromDB(r.Context(), dbLists)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get list members", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, lists)
+}
+
+func (cfg *apiConfig) handlerListGet(w http.ResponseWriter, r *http.Request) {
+	list, ok := cfg.visibleList(w, r, cfg.optionalUser(r).ID)
+	if !ok {
+		return
+	}
+	lists, err := cfg.listsFromDB(r.Context(), []database.UserList{list})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get list members", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, lists[0])
+}
+
+func (cfg *apiConfig) handlerListsDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return
+	}
+
+	n, err := cfg.db.DeleteUserList(r.Context(), database.DeleteUserListParams{
+		ID:      listID,
+		OwnerID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete list", err)
+		return
+	}
+	if n == 0 {
+		respondWithError(w, http.StatusNotFound, "Couldn't find list", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerListMembersAdd(w http.ResponseWriter, r *http.Request, user database.User) {
+	list, ok := cfg.ownedList(w, r, user)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	member, err := cfg.db.GetUserByID(r.Context(), memberID)
+	if err != nil || member.DeletedAt.Valid {
+		if err == nil || errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	blocked, err := cfg.db.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{
+		UserA: user.ID,
+		UserB: memberID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You can't add this user to a list", nil)
+		return
+	}
+
+	_, err = cfg.db.AddUserListMember(r.Context(), database.AddUserListMemberParams{
+		UserID:  memberID,
+		ListID:  list.ID,
+		OwnerID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't add list member", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerListMembersRemove(w http.ResponseWriter, r *http.Request, user database.User) {
+	list, ok := cfg.ownedList(w, r, user)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return
+	}
+
+	err = cfg.db.RemoveUserListMember(r.Context(), database.RemoveUserListMemberParams{
+		ListID:  list.ID,
+		UserID:  memberID,
+		OwnerID: user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove list member", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerListChirps pages through the chirps of a list's members, newest
+// first, leaving out authors the viewer muted or has a block with.
+func (cfg *apiConfig) handlerListChirps(w http.ResponseWriter, r *http.Request) {
+	const defaultLimit, maxLimit = 50, 100
+	type response struct {
+		Chirps     []Chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	viewer := cfg.optionalUser(r)
+	params := database.GetUserListChirpsParams{
+		ViewerID:      viewer.ID,
+		ViewerIsAdmin: viewer.IsAdmin,
+		// One more than the page tells us whether there's another page.
+		MaxChirps: int32(limit) + 1,
+	}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	list, ok := cfg.visibleList(w, r, viewer.ID)
+	if !ok {
+		return
+	}
+	params.ListID = list.ID
+
+	dbChirps, err := cfg.db.GetUserListChirps(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	resp := response{
+		Chirps: []Chirp{},
+	}
+	if len(dbChirps) > limit {
+		dbChirps = dbChirps[:limit]
+		resp.NextCursor = encodeCursor(dbChirps[limit-1].CreatedAt, dbChirps[limit-1].ID)
+	}
+	for _, dbChirp := range dbChirps {
+		resp.Chirps = append(resp.Chirps, chirpFromDB(dbChirp))
+	}
+	if err := cfg.withLinkPreviews(r.Context(), resp.Chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get link previews", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// visibleList parses the listID path value and gets the list if viewerID
+// may see it, writing a 404 otherwise so private lists can't be probed
+// for.
+func (cfg *apiConfig) visibleList(w http.ResponseWriter, r *http.Request, viewerID uuid.UUID) (database.UserList, bool) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid list ID", err)
+		return database.UserList{}, false
+	}
+
+	list, err := cfg.db.GetUserList(r.Context(), database.GetUserListParams{
+		ID:       listID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find list", err)
+			return database.UserList{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get list", err)
+		return database.UserList{}, false
+	}
+	return list, true
+}
+
+// ownedList is visibleList for changes only the owner may make. Other
+// users get a 403 for public lists and a 404 for private ones.
+func (cfg *apiConfig) ownedList(w http.ResponseWriter, r *http.Request, user database.User) (database.UserList, bool) {
+	list, ok := cfg.visibleList(w, r, user.ID)
+	if !ok {
+		return database.UserList{}, false
+	}
+	if list.OwnerID != user.ID {
+		respondWithError(w, http.StatusForbidden, "You can't change this list", nil)
+		return database.UserList{}, false
+	}
+	return list, true
+}
+
+// listsFromDB builds the responses for lists, including their members.
+func (cfg *apiConfig) listsFromDB(ctx context.Context, dbLists []database.UserList) ([]List, error) {
+	ids := []uuid.UUID{}
+	for _, l := range dbLists {
+		ids = append(ids, l.ID)
+	}
+	members, err := cfg.db.GetUserListMembers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byList := map[uuid.UUID][]uuid.UUID{}
+	for _, m := range members {
+		byList[m.ListID] = append(byList[m.ListID], m.UserID)
+	}
+
+	lists := []List{}
+	for _, l := range dbLists {
+		list := List{
+			ID:        l.ID,
+			CreatedAt: l.CreatedAt,
+			UpdatedAt: l.UpdatedAt,
+			OwnerID:   l.OwnerID,
+			Name:      l.Name,
+			Private:   l.IsPrivate,
+			MemberIDs: byList[l.ID],
+		}
+		if list.MemberIDs == nil {
+			list.MemberIDs = []uuid.UUID{}
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+func TestBookmarks(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+	bob, _ := newTestUser(t, srv, "bob@example.com")
+
+	chirps := []client.Chirp{}
+	for _, body := range []string{"first", "second", "third"} {
+		chirp, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: body})
+		if err != nil {
+			t.Fatalf("CreateChirp() error = %v", err)
+		}
+		chirps = append(chirps, chirp)
+	}
+	// Bookmarked out of order, so bookmark time and chirp time differ.
+	for _, i := range []int{1, 0, 2, 0} {
+		if err := alice.BookmarkChirp(ctx, chirps[i].ID); err != nil {
+			t.Fatalf("BookmarkChirp(%s) error = %v", chirps[i].Body, err)
+		}
+	}
+
+	bodies := []string{}
+	limit := 2
+	params := &client.ListBookmarksParams{Limit: &limit}
+	for pages := 0; ; pages++ {
+		if pages > 2 {
+			t.Fatal("ListBookmarks() never ran out of pages")
+		}
+		page, err := alice.ListBookmarks(ctx, params)
+		if err != nil {
+			t.Fatalf("ListBookmarks() error = %v", err)
+		}
+		for _, b := range page.Bookmarks {
+			bodies = append(bodies, b.Chirp.Body)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		params.Cursor = &page.NextCursor
+	}
+	want := []string{"third", "first", "second"}
+	if len(bodies) != len(want) || bodies[0] != want[0] || bodies[1] != want[1] || bodies[2] != want[2] {
+		t.Errorf("bookmarks = %v, want %v", bodies, want)
+	}
+
+	if page, _ := bob.ListBookmarks(ctx, nil); len(page.Bookmarks) != 0 {
+		t.Errorf("bob's bookmarks = %d, want none", len(page.Bookmarks))
+	}
+
+	if err := alice.UnbookmarkChirp(ctx, chirps[2].ID); err != nil {
+		t.Fatalf("UnbookmarkChirp() error = %v", err)
+	}
+	if err := bob.DeleteChirp(ctx, chirps[0].ID, nil); err != nil {
+		t.Fatalf("DeleteChirp() error = %v", err)
+	}
+	page, err := alice.ListBookmarks(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListBookmarks() error = %v", err)
+	}
+	if len(page.Bookmarks) != 1 || page.Bookmarks[0].Chirp.ID != chirps[1].ID {
+		t.Errorf("bookmarks after removing two = %+v, want only the second chirp", page.Bookmarks)
+	}
+
+	var apiErr *client.APIError
+	err = alice.BookmarkChirp(ctx, chirps[0].ID)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("BookmarkChirp() of a deleted chirp error = %v, want 404", err)
+	}
+}
+
+func TestLists(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+	carol, carolLogin := newTestUser(t, srv, "carol@example.com")
+	dave, daveLogin := newTestUser(t, srv, "dave@example.com")
+
+	for _, author := range []*client.Client{bob, carol, dave, alice} {
+		if _, err := author.CreateChirp(ctx, client.CreateChirpRequest{Body: "hello"}); err != nil {
+			t.Fatalf("CreateChirp() error = %v", err)
+		}
+	}
+
+	var apiErr *client.APIError
+	if _, err := alice.CreateList(ctx, client.CreateListRequest{Name: "  "}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("CreateList() with a blank name error = %v, want 400", err)
+	}
+
+	public, err := alice.CreateList(ctx, client.CreateListRequest{Name: "Friends"})
+	if err != nil {
+		t.Fatalf("CreateList() error = %v", err)
+	}
+	private, err := alice.CreateList(ctx, client.CreateListRequest{Name: "Secret", Private: true})
+	if err != nil {
+		t.Fatalf("CreateList(private) error = %v", err)
+	}
+	for _, id := range []uuid.UUID{bobLogin.ID, carolLogin.ID, daveLogin.ID} {
+		if err := alice.AddListMember(ctx, public.ID, id); err != nil {
+			t.Fatalf("AddListMember() error = %v", err)
+		}
+	}
+	if err := alice.AddListMember(ctx, private.ID, bobLogin.ID); err != nil {
+		t.Fatalf("AddListMember(private) error = %v", err)
+	}
+
+	lists, err := alice.ListLists(ctx)
+	if err != nil {
+		t.Fatalf("ListLists() error = %v", err)
+	}
+	if len(lists) != 2 || lists[0].ID != public.ID || len(lists[0].MemberIDs) != 3 || len(lists[1].MemberIDs) != 1 {
+		t.Errorf("ListLists() = %+v, want both lists with their members", lists)
+	}
+
+	anonymous := client.New(srv.URL)
+	if _, err := anonymous.GetList(ctx, public.ID); err != nil {
+		t.Errorf("anonymous GetList(public) error = %v", err)
+	}
+	for name, c := range map[string]*client.Client{"anonymous": anonymous, "bob": bob} {
+		if _, err := c.GetList(ctx, private.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			t.Errorf("%s GetList(private) error = %v, want 404", name, err)
+		}
+		if _, err := c.GetListChirps(ctx, private.ID, nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			t.Errorf("%s GetListChirps(private) error = %v, want 404", name, err)
+		}
+	}
+	if err := bob.AddListMember(ctx, public.ID, bobLogin.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("AddListMember() to someone else's list error = %v, want 403", err)
+	}
+	if err := bob.DeleteList(ctx, public.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("DeleteList() of someone else's list error = %v, want 404", err)
+	}
+
+	page, err := anonymous.GetListChirps(ctx, public.ID, nil)
+	if err != nil {
+		t.Fatalf("GetListChirps() error = %v", err)
+	}
+	if authors := chirpAuthors(page.Chirps); len(authors) != 3 || authors[0] != daveLogin.ID || authors[2] != bobLogin.ID {
+		t.Errorf("GetListChirps() authors = %v, want dave, carol, bob", authors)
+	}
+
+	limit := 2
+	page, err = anonymous.GetListChirps(ctx, public.ID, &client.GetListChirpsParams{Limit: &limit})
+	if err != nil || len(page.Chirps) != 2 || page.NextCursor == "" {
+		t.Fatalf("GetListChirps(limit 2) = %+v, %v, want 2 chirps and a cursor", page, err)
+	}
+	page, err = anonymous.GetListChirps(ctx, public.ID, &client.GetListChirpsParams{Limit: &limit, Cursor: &page.NextCursor})
+	if err != nil || len(page.Chirps) != 1 || page.Chirps[0].UserID != bobLogin.ID || page.NextCursor != "" {
+		t.Errorf("GetListChirps() second page = %+v, %v, want only bob's chirp", page, err)
+	}
+
+	// Viewers don't see members they muted or have a block with.
+	if err := alice.MuteUser(ctx, carolLogin.ID); err != nil {
+		t.Fatalf("MuteUser() error = %v", err)
+	}
+	if err := dave.BlockUser(ctx, aliceLogin.ID); err != nil {
+		t.Fatalf("BlockUser() error = %v", err)
+	}
+	page, err = alice.GetListChirps(ctx, public.ID, nil)
+	if err != nil {
+		t.Fatalf("GetListChirps() error = %v", err)
+	}
+	if authors := chirpAuthors(page.Chirps); len(authors) != 1 || authors[0] != bobLogin.ID {
+		t.Errorf("GetListChirps() authors with a mute and a block = %v, want only bob", authors)
+	}
+	if err := alice.AddListMember(ctx, private.ID, daveLogin.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("AddListMember() across a block error = %v, want 403", err)
+	}
+
+	if err := alice.RemoveListMember(ctx, public.ID, bobLogin.ID); err != nil {
+		t.Fatalf("RemoveListMember() error = %v", err)
+	}
+	if list, _ := alice.GetList(ctx, public.ID); len(list.MemberIDs) != 2 {
+		t.Errorf("members after removing bob = %v, want 2", list.MemberIDs)
+	}
+
+	if err := alice.DeleteList(ctx, public.ID); err != nil {
+		t.Fatalf("DeleteList() error = %v", err)
+	}
+	if _, err := alice.GetList(ctx, public.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("GetList() after deleting error = %v, want 404", err)
+	}
+}
+
+func TestListChirpsHidden(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+	admin, adminLogin := newTestUser(t, srv, "admin@example.com")
+	if _, err := cfg.db.SetUserAdmin(ctx, database.SetUserAdminParams{ID: adminLogin.ID, IsAdmin: true}); err != nil {
+		t.Fatalf("SetUserAdmin() error = %v", err)
+	}
+
+	chirp, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "hello"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	_, err = cfg.db.SetChirpHidden(ctx, database.SetChirpHiddenParams{ID: chirp.ID, HiddenAt: sql.NullTime{Time: time.Now(), Valid: true}})
+	if err != nil {
+		t.Fatalf("SetChirpHidden() error = %v", err)
+	}
+	list, err := alice.CreateList(ctx, client.CreateListRequest{Name: "Friends"})
+	if err != nil {
+		t.Fatalf("CreateList() error = %v", err)
+	}
+	if err := alice.AddListMember(ctx, list.ID, bobLogin.ID); err != nil {
+		t.Fatalf("AddListMember() error = %v", err)
+	}
+
+	// Like everywhere else, a hidden chirp is only seen by its author and
+	// admins.
+	viewers := []struct {
+		name   string
+		client *client.Client
+		want   int
+	}{
+		{"alice", alice, 0},
+		{"bob", bob, 1},
+		{"admin", admin, 1},
+	}
+	for _, viewer := range viewers {
+		page, err := viewer.client.GetListChirps(ctx, list.ID, nil)
+		if err != nil || len(page.Chirps) != viewer.want {
+			t.Errorf("%s GetListChirps() = %+v, %v, want %d chirps", viewer.name, page, err, viewer.want)
+		}
+	}
+}
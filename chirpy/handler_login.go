@@ -1,7 +1,7 @@
 package main
 
 import (
-	"encoding/json"
+	"log"
 	"net/http"
 	"time"
 
@@ -14,33 +14,66 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 		Email    string `json:"email"`
 	}
-	type response struct {
-		User
-		Token        string `json:"token"`
-		RefreshToken string `json:"refresh_token"`
-	}
 
-	decoder := json.NewDecoder(r.Body)
 	params := parameters{}
-	err := decoder.Decode(&params)
+	err := decodeJSON(w, r, &params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	v.require("email", params.Email)
+	v.require("password", params.Password)
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
 		return
 	}
 
 	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
 	if err != nil {
+		cfg.audit(r, auditRecord{Action: auditLoginFailed, TargetType: auditTargetEmail, TargetID: params.Email})
 		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
 		return
 	}
 
+	// Accounts without a password fail like a wrong password does, so
+	// logins can't be used to find out which accounts have one.
 	err = auth.CheckPasswordHash(params.Password, user.HashedPassword)
 	if err != nil {
+		cfg.audit(r, auditRecord{Action: auditLoginFailed, TargetType: auditTargetUser, TargetID: user.ID.String()})
 		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(
+	// Upgrade hashes made with an older algorithm or weaker parameters
+	// while the password is at hand. Failing to is no reason to fail the
+	// login.
+	if cfg.passwordHashing.NeedsRehash(user.HashedPassword) {
+		if hashedPassword, err := cfg.passwordHashing.Hash(params.Password); err != nil {
+			log.Printf("Couldn't rehash password of user %s: %s", user.ID, err)
+		} else if user, err = cfg.db.SetUserPassword(r.Context(), database.SetUserPasswordParams{
+			ID:             user.ID,
+			HashedPassword: hashedPassword,
+		}); err != nil {
+			log.Printf("Couldn't rehash password of user %s: %s", user.ID, err)
+		}
+	}
+
+	cfg.audit(r, auditRecord{Action: auditLoginSucceeded, ActorID: user.ID, TargetType: auditTargetUser, TargetID: user.ID.String()})
+	cfg.respondWithTokens(w, r, user)
+}
+
+// respondWithTokens issues user a new access and refresh token pair and
+// responds with them along with the user.
+func (cfg *apiConfig) respondWithTokens(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		User
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	accessToken, err := auth.MakeSignInJWT(
 		user.ID,
 		cfg.jwtSecret,
 		time.Hour,
@@ -67,13 +100,7 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+		User:         userFromDB(user),
 		Token:        accessToken,
 		RefreshToken: refreshToken,
 	})
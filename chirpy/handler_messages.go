@@ -0,0 +1,504 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+)
+
+// Conversation is a private conversation between two users.
+type Conversation struct {
+	ID           uuid.UUID     `json:"id"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	Participants []Participant `json:"participants"`
+	// UnreadCount is how many messages from the other participant the
+	// requester hasn't read.
+	UnreadCount int `json:"unread_count"`
+}
+
+// Participant is a user in a conversation. They have read every message
+// sent at or before LastReadAt.
+type Participant struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+// conversationRead is the data of a conversation.read event.
+type conversationRead struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	LastReadAt     time.Time `json:"last_read_at"`
+}
+
+func (cfg *apiConfig) handlerConversationsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	switch params.UserID {
+	case uuid.Nil:
+		v.add("user_id", "is required")
+	case user.ID:
+		v.add("user_id", "must be another user")
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	other, err := cfg.db.GetUserByID(r.Context(), params.UserID)
+	if err != nil || other.DeletedAt.Valid {
+		if err == nil || errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	if !cfg.canMessage(w, r, user.ID, other.ID) {
+		return
+	}
+
+	var conversation database.Conversation
+	created := false
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		pair := database.GetConversationBetweenParams{UserA: user.ID, UserB: other.ID}
+		var err error
+		conversation, err = tx.GetConversationBetween(r.Context(), pair)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		conversation, err = tx.CreateConversation(r.Context(), database.CreateConversationParams{
+			UserA: user.ID,
+			UserB: other.ID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// Someone else started it since we looked.
+			conversation, err = tx.GetConversationBetween(r.Context(), pair)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		created = true
+		for _, id := range []uuid.UUID{user.ID, other.ID} {
+			err := tx.AddConversationParticipant(r.Context(), database.AddConversationParticipantParams{
+				ConversationID: conversation.ID,
+				UserID:         id,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadMessages(r.Context(), database.CountUnreadMessagesParams{
+		ConversationID: conversation.ID,
+		UserID:         user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count messages", err)
+		return
+	}
+	resp, err := cfg.conversationsFromDB(r.Context(), []database.Conversation{conversation}, []int64{unread})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get participants", err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, resp[0])
+}
+
+func (cfg *apiConfig) handlerConversationsList(w http.ResponseWriter, r *http.Request, user database.User) {
+	const defaultLimit, maxLimit = 50, 100
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	rows, err := cfg.db.GetConversationsByUser(r.Context(), database.GetConversationsByUserParams{
+		UserID:           user.ID,
+		MaxConversations: int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve conversations", err)
+		return
+	}
+
+	conversations := []database.Conversation{}
+	unread := []int64{}
+	for _, row := range rows {
+		conversations = append(conversations, database.Conversation{
+			ID:        row.ID,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+			UserLow:   row.UserLow,
+			UserHigh:  row.UserHigh,
+		})
+		unread = append(unread, row.UnreadCount)
+	}
+	resp, err := cfg.conversationsFromDB(r.Context(), conversations, unread)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get participants", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerConversationGet(w http.ResponseWriter, r *http.Request, user database.User) {
+	conversation, ok := cfg.participantConversation(w, r, user)
+	if !ok {
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadMessages(r.Context(), database.CountUnreadMessagesParams{
+		ConversationID: conversation.ID,
+		UserID:         user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count messages", err)
+		return
+	}
+	resp, err := cfg.conversationsFromDB(r.Context(), []database.Conversation{conversation}, []int64{unread})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get participants", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, resp[0])
+}
+
+// handlerMessagesList pages through a conversation's messages, newest
+// first. Each page's next_cursor is passed as cursor to get the page of
+// older messages after it; it is left out on the last page.
+func (cfg *apiConfig) handlerMessagesList(w http.ResponseWriter, r *http.Request, user database.User) {
+	const defaultLimit, maxLimit = 50, 100
+	type response struct {
+		Messages   []Message `json:"messages"`
+		NextCursor string    `json:"next_cursor,omitempty"`
+	}
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	params := database.GetMessagesParams{
+		// One more than the page tells us whether there's another page.
+		MaxMessages: int32(limit) + 1,
+	}
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		params.BeforeCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.BeforeID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	conversation, ok := cfg.participantConversation(w, r, user)
+	if !ok {
+		return
+	}
+	params.ConversationID = conversation.ID
+
+	dbMessages, err := cfg.db.GetMessages(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve messages", err)
+		return
+	}
+
+	resp := response{
+		Messages: []Message{},
+	}
+	if len(dbMessages) > limit {
+		dbMessages = dbMessages[:limit]
+		resp.NextCursor = encodeCursor(dbMessages[limit-1].CreatedAt, dbMessages[limit-1].ID)
+	}
+	for _, m := range dbMessages {
+		resp.Messages = append(resp.Messages, messageFromDB(m))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerMessagesCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	cleaned, err := validateChirp(params.Body)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	conversation, ok := cfg.participantConversation(w, r, user)
+	if !ok {
+		return
+	}
+	other := conversation.UserLow
+	if other == user.ID {
+		other = conversation.UserHigh
+	}
+	if !cfg.canMessage(w, r, user.ID, other) {
+		return
+	}
+
+	var message database.Message
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		var err error
+		message, err = tx.CreateMessage(r.Context(), database.CreateMessageParams{
+			ConversationID: conversation.ID,
+			SenderID:       user.ID,
+			Body:           cleaned,
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.SetConversationUpdatedAt(r.Context(), database.SetConversationUpdatedAtParams{
+			ID:        conversation.ID,
+			UpdatedAt: message.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		// Senders have read everything up to their own message.
+		_, err = tx.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+			ReadAt:         message.CreatedAt,
+			ConversationID: conversation.ID,
+			UserID:         user.ID,
+		})
+		return err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send message", err)
+		return
+	}
+
+	resp := messageFromDB(message)
+	cfg.publishConversationEvent(r.Context(), events.TypeMessageCreated, conversation, user.ID, resp)
+
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+// handlerConversationRead marks the messages up to and including
+// message_id as read by the requester.
+func (cfg *apiConfig) handlerConversationRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		MessageID uuid.UUID `json:"message_id"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	if params.MessageID == uuid.Nil {
+		v.add("message_id", "is required")
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	conversation, ok := cfg.participantConversation(w, r, user)
+	if !ok {
+		return
+	}
+	message, err := cfg.db.GetMessage(r.Context(), database.GetMessageParams{
+		ID:             params.MessageID,
+		ConversationID: conversation.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find message", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get message", err)
+		return
+	}
+
+	participant, err := cfg.db.MarkConversationRead(r.Context(), database.MarkConversationReadParams{
+		ReadAt:         message.CreatedAt,
+		ConversationID: conversation.ID,
+		UserID:         user.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark conversation read", err)
+		return
+	}
+
+	cfg.publishConversationEvent(r.Context(), events.TypeConversationRead, conversation, user.ID, conversationRead{
+		ConversationID: conversation.ID,
+		UserID:         user.ID,
+		LastReadAt:     participant.LastReadAt.Time,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// participantConversation returns the conversation in the request path,
+// or responds with a 404 if it doesn't exist or user isn't in it.
+func (cfg *apiConfig) participantConversation(w http.ResponseWriter, r *http.Request, user database.User) (database.Conversation, bool) {
+	conversationID, err := uuid.Parse(r.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid conversation ID", err)
+		return database.Conversation{}, false
+	}
+
+	conversation, err := cfg.db.GetConversationForParticipant(r.Context(), database.GetConversationForParticipantParams{
+		ID:     conversationID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find conversation", err)
+			return database.Conversation{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get conversation", err)
+		return database.Conversation{}, false
+	}
+	return conversation, true
+}
+
+// canMessage responds with a 403 and returns false if either user has
+// blocked the other.
+func (cfg *apiConfig) canMessage(w http.ResponseWriter, r *http.Request, senderID, recipientID uuid.UUID) bool {
+	blocked, err := cfg.db.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{
+		UserA: senderID,
+		UserB: recipientID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+		return false
+	}
+	if blocked {
+		respondWithError(w, http.StatusForbidden, "You can't message this user", nil)
+		return false
+	}
+	return true
+}
+
+// conversationsFromDB builds the responses for conversations, given how
+// many messages each has that the requester hasn't read.
+func (cfg *apiConfig) conversationsFromDB(ctx context.Context, conversations []database.Conversation, unread []int64) ([]Conversation, error) {
+	ids := []uuid.UUID{}
+	for _, c := range conversations {
+		ids = append(ids, c.ID)
+	}
+	participants, err := cfg.db.GetConversationParticipants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byConversation := map[uuid.UUID][]Participant{}
+	for _, p := range participants {
+		participant := Participant{UserID: p.UserID}
+		if p.LastReadAt.Valid {
+			participant.LastReadAt = &p.LastReadAt.Time
+		}
+		byConversation[p.ConversationID] = append(byConversation[p.ConversationID], participant)
+	}
+
+	resp := []Conversation{}
+	for i, c := range conversations {
+		conversation := Conversation{
+			ID:           c.ID,
+			CreatedAt:    c.CreatedAt,
+			UpdatedAt:    c.UpdatedAt,
+			Participants: byConversation[c.ID],
+			UnreadCount:  int(unread[i]),
+		}
+		if conversation.Participants == nil {
+			conversation.Participants = []Participant{}
+		}
+		resp = append(resp, conversation)
+	}
+	return resp, nil
+}
+
+func messageFromDB(m database.Message) Message {
+	return Message{
+		ID:             m.ID,
+		CreatedAt:      m.CreatedAt,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+	}
+}
+
+// publishConversationEvent sends data to the stream of both participants
+// of conversation, and no one else.
+func (cfg *apiConfig) publishConversationEvent(ctx context.Context, eventType string, conversation database.Conversation, actorID uuid.UUID, data any) {
+	dat, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Couldn't encode %s event: %s", eventType, err)
+		return
+	}
+	cfg.publishEvent(ctx, events.Event{
+		Type:       eventType,
+		AuthorID:   actorID,
+		Recipients: []uuid.UUID{conversation.UserLow, conversation.UserHigh},
+		Data:       dat,
+	})
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+)
+
+func TestConversations(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+
+	conversation, err := alice.CreateConversation(ctx, client.CreateConversationRequest{UserID: bobLogin.ID})
+	if err != nil {
+		t.Fatalf("CreateConversation() error = %v", err)
+	}
+	if len(conversation.Participants) != 2 || conversation.UnreadCount != 0 {
+		t.Errorf("CreateConversation() = %+v, want two participants and nothing unread", conversation)
+	}
+	// Either user starting it again gets the same conversation.
+	again, err := bob.CreateConversation(ctx, client.CreateConversationRequest{UserID: aliceLogin.ID})
+	if err != nil || again.ID != conversation.ID {
+		t.Errorf("CreateConversation() again = %v, %v, want conversation %s", again.ID, err, conversation.ID)
+	}
+
+	_, err = alice.CreateConversation(ctx, client.CreateConversationRequest{UserID: aliceLogin.ID})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("CreateConversation() with yourself error = %v, want 400", err)
+	}
+	_, err = alice.CreateConversation(ctx, client.CreateConversationRequest{UserID: uuid.New()})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("CreateConversation() with an unknown user error = %v, want 404", err)
+	}
+
+	cleaned, err := bob.SendMessage(ctx, conversation.ID, client.CreateMessageRequest{Body: "what a kerfuffle"})
+	if err != nil || cleaned.Body != "what a ****" {
+		t.Errorf("SendMessage() = %q, %v, want the body cleaned like a chirp", cleaned.Body, err)
+	}
+	_, err = bob.SendMessage(ctx, conversation.ID, client.CreateMessageRequest{Body: ""})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.Code != "validation_failed" {
+		t.Errorf("SendMessage() with an empty body error = %v, want validation_failed", err)
+	}
+
+	sent := []client.Message{}
+	for i := 0; i < 5; i++ {
+		m, err := alice.SendMessage(ctx, conversation.ID, client.CreateMessageRequest{Body: fmt.Sprintf("message %d", i)})
+		if err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+		sent = append(sent, m)
+	}
+
+	// Bob hasn't read alice's five messages; his own don't count.
+	listed, err := bob.ListConversations(ctx, nil)
+	if err != nil || len(listed) != 1 || listed[0].UnreadCount != 5 {
+		t.Fatalf("ListConversations() = %+v, %v, want one conversation with 5 unread", listed, err)
+	}
+
+	if err := bob.MarkConversationRead(ctx, conversation.ID, client.MarkConversationReadRequest{MessageID: sent[2].ID}); err != nil {
+		t.Fatalf("MarkConversationRead() error = %v", err)
+	}
+	got, err := bob.GetConversation(ctx, conversation.ID)
+	if err != nil || got.UnreadCount != 2 {
+		t.Errorf("GetConversation() unread = %d, %v, want 2", got.UnreadCount, err)
+	}
+	// Alice sees how far bob has read.
+	got, err = alice.GetConversation(ctx, conversation.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range got.Participants {
+		if p.UserID == bobLogin.ID && (p.LastReadAt == nil || !p.LastReadAt.Equal(sent[2].CreatedAt)) {
+			t.Errorf("bob's last_read_at = %v, want %v", p.LastReadAt, sent[2].CreatedAt)
+		}
+	}
+	// Marking an older message read doesn't move the receipt back.
+	if err := bob.MarkConversationRead(ctx, conversation.ID, client.MarkConversationReadRequest{MessageID: sent[0].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := bob.GetConversation(ctx, conversation.ID); got.UnreadCount != 2 {
+		t.Errorf("unread after marking an older message read = %d, want 2", got.UnreadCount)
+	}
+}
+
+func TestMessagesPagination(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+	_, bobLogin := newTestUser(t, srv, "bob@example.com")
+
+	conversation, err := alice.CreateConversation(ctx, client.CreateConversationRequest{UserID: bobLogin.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uuid.UUID{}
+	for i := 0; i < 5; i++ {
+		m, err := alice.SendMessage(ctx, conversation.ID, client.CreateMessageRequest{Body: fmt.Sprintf("message %d", i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append([]uuid.UUID{m.ID}, want...)
+	}
+
+	got := []uuid.UUID{}
+	limit := 2
+	params := &client.ListMessagesParams{Limit: &limit}
+	for pages := 1; ; pages++ {
+		page, err := alice.ListMessages(ctx, conversation.ID, params)
+		if err != nil {
+			t.Fatalf("ListMessages() error = %v", err)
+		}
+		for _, m := range page.Messages {
+			got = append(got, m.ID)
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("got %d pages of 2 for 5 messages, want 3", pages)
+			}
+			break
+		}
+		if pages > 3 {
+			t.Fatal("ListMessages() never ran out of pages")
+		}
+		params.Cursor = &page.NextCursor
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("paged through %v, want newest first %v", got, want)
+	}
+
+	bad := "not-a-cursor"
+	_, err = alice.ListMessages(ctx, conversation.ID, &client.ListMessagesParams{Cursor: &bad})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("ListMessages() with a bad cursor error = %v, want 400", err)
+	}
+}
+
+func TestConversationsParticipantsOnly(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+	_, bobLogin := newTestUser(t, srv, "bob@example.com")
+	carol, _ := newTestUser(t, srv, "carol@example.com")
+
+	conversation, err := alice.CreateConversation(ctx, client.CreateConversationRequest{UserID: bobLogin.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := alice.SendMessage(ctx, conversation.ID, client.CreateMessageRequest{Body: "just between us"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anon := client.New(srv.URL)
+	for _, c := range []struct {
+		name   string
+		client *client.Client
+		status int
+	}{
+		{"another user", carol, http.StatusNotFound},
+		{"anonymous", anon, http.StatusUnauthorized},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			calls := map[string]func() error{
+				"GetConversation": func() error {
+					_, err := c.client.GetConversation(ctx, conversation.ID)
+					return err
+				},
+				"ListMessages": func() error {
+					_, err := c.client.ListMessages(ctx, conversation.ID, nil)
+					return err
+				},
+				"SendMessage": func() error {
+					_, err := c.client.SendMessage(ctx, conversation.ID, client.CreateMessageRequest{Body: "hi"})
+					return err
+				},
+				"MarkConversationRead": func() error {
+					return c.client.MarkConversationRead(ctx, conversation.ID, client.MarkConversationReadRequest{MessageID: message.ID})
+				},
+			}
+			for name, call := range calls {
+				err := call()
+				if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != c.status {
+					t.Errorf("%s() error = %v, want %d", name, err, c.status)
+				}
+			}
+		})
+	}
+
+	listed, err := carol.ListConversations(ctx, nil)
+	if err != nil || len(listed) != 0 {
+		t.Errorf("ListConversations() for another user = %+v, %v, want none", listed, err)
+	}
+}
+
+func TestConversationsRespectBlocks(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+	_, carolLogin := newTestUser(t, srv, "carol@example.com")
+
+	conversation, err := alice.CreateConversation(ctx, client.CreateConversationRequest{UserID: bobLogin.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, block := range []database.BlockUserParams{
+		{BlockerID: bobLogin.ID, BlockedID: aliceLogin.ID},
+		{BlockerID: carolLogin.ID, BlockedID: aliceLogin.ID},
+	} {
+		if _, err := cfg.db.BlockUser(ctx, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = alice.CreateConversation(ctx, client.CreateConversationRequest{UserID: carolLogin.ID})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("CreateConversation() with a user who blocked you error = %v, want 403", err)
+	}
+	// The block stops messages both ways.
+	for name, c := range map[string]*client.Client{"blocked": alice, "blocker": bob} {
+		_, err := c.SendMessage(ctx, conversation.ID, client.CreateMessageRequest{Body: "hello?"})
+		if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+			t.Errorf("SendMessage() by the %s error = %v, want 403", name, err)
+		}
+	}
+	// The history can still be read.
+	if _, err := alice.ListMessages(ctx, conversation.ID, nil); err != nil {
+		t.Errorf("ListMessages() after a block error = %v", err)
+	}
+}
+
+func TestMessagesStream(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+	carol, _ := newTestUser(t, srv, "carol@example.com")
+	conversation, err := alice.CreateConversation(ctx, client.CreateConversationRequest{UserID: bobLogin.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	streams := map[string]*bufio.Reader{}
+	for name, c := range map[string]*client.Client{"bob": bob, "carol": carol, "anonymous": client.New(srv.URL)} {
+		stream, err := c.StreamChirps(ctx, nil)
+		if err != nil {
+			t.Fatalf("StreamChirps() for %s error = %v", name, err)
+		}
+		defer stream.Close()
+		streams[name] = bufio.NewReader(stream)
+	}
+
+	message, err := alice.SendMessage(ctx, conversation.ID, client.CreateMessageRequest{Body: "psst"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.MarkConversationRead(ctx, conversation.ID, client.MarkConversationReadRequest{MessageID: message.ID}); err != nil {
+		t.Fatal(err)
+	}
+	// A public chirp shows where the private events would have been.
+	chirp, err := carol.CreateChirp(ctx, client.CreateChirpRequest{Body: "public"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := readStreamEvent(t, streams["bob"])
+	got := client.Message{}
+	if e.eventType != events.TypeMessageCreated || json.Unmarshal([]byte(e.data), &got) != nil || got.ID != message.ID {
+		t.Errorf("bob's first event = %+v, want message %s", e, message.ID)
+	}
+	if e := readStreamEvent(t, streams["bob"]); e.eventType != events.TypeConversationRead {
+		t.Errorf("bob's second event = %+v, want %s", e, events.TypeConversationRead)
+	}
+	for _, name := range []string{"carol", "anonymous"} {
+		e := readStreamEvent(t, streams[name])
+		gotChirp := client.Chirp{}
+		if e.eventType != events.TypeChirpCreated || json.Unmarshal([]byte(e.data), &gotChirp) != nil || gotChirp.ID != chirp.ID {
+			t.Errorf("%s's first event = %+v, want only the public chirp", name, e)
+		}
+	}
+}
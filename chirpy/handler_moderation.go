@@ -0,0 +1,258 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+	"github.com/lsherman98/boot.dev/chirpy/internal/webhooks"
+)
+
+// reportReasons are the reasons a chirp can be reported for. Keep in sync
+// with the chirp_reports reason check in sql/schema/016_moderation.sql.
+var reportReasons = []string{"spam", "harassment", "hate", "violence", "misinformation", "other"}
+
+// Moderation actions on a reported chirp. Each resolves the chirp's open
+// reports.
+const (
+	moderationDismiss = "dismiss"
+	moderationHide    = "hide"
+	moderationDelete  = "delete"
+)
+
+// Moderation actions on a reported chirp's author.
+const (
+	moderationStrike  = "strike"
+	moderationSuspend = "suspend"
+)
+
+// ModerationItem is a chirp in the moderation queue.
+type ModerationItem struct {
+	Chirp           Chirp     `json:"chirp"`
+	ReportCount     int       `json:"report_count"`
+	Reasons         []string  `json:"reasons"`
+	FirstReportedAt time.Time `json:"first_reported_at"`
+	AuthorStrikes   int       `json:"author_strikes"`
+}
+
+func (cfg *apiConfig) handlerChirpsReport(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	const maxDetailsLength = 500
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	v.require("reason", params.Reason)
+	if params.Reason != "" && !slices.Contains(reportReasons, params.Reason) {
+		v.add("reason", fmt.Sprintf("must be one of %v", reportReasons))
+	}
+	if utf8.RuneCountInString(params.Details) > maxDetailsLength {
+		v.add("details", fmt.Sprintf("must be at most %d characters", maxDetailsLength))
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil || !chirpVisibleTo(chirp, user) {
+		respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+		return
+	}
+	if chirp.UserID == user.ID {
+		respondWithError(w, http.StatusBadRequest, "You can't report your own chirp", nil)
+		return
+	}
+
+	_, err = cfg.db.CreateChirpReport(r.Context(), database.CreateChirpReportParams{
+		ChirpID:    chirpID,
+		ReporterID: user.ID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't report chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerModerationQueue(w http.ResponseWriter, r *http.Request, user database.User) {
+	const defaultLimit, maxLimit = 50, 100
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	rows, err := cfg.db.GetModerationQueue(r.Context(), int32(limit))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve moderation queue", err)
+		return
+	}
+
+	queue := []ModerationItem{}
+	for _, row := range rows {
+		chirp, err := cfg.db.GetChirp(r.Context(), row.ChirpID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get reported chirp", err)
+			return
+		}
+		author, err := cfg.db.GetUserByID(r.Context(), chirp.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp author", err)
+			return
+		}
+		queue = append(queue, ModerationItem{
+			Chirp:           chirpFromDB(chirp),
+			ReportCount:     int(row.ReportCount),
+			Reasons:         row.Reasons,
+			FirstReportedAt: row.FirstReportedAt,
+			AuthorStrikes:   int(author.Strikes),
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, queue)
+}
+
+func (cfg *apiConfig) handlerModerationAction(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Action       string `json:"action"`
+		AuthorAction string `json:"author_action"`
+		// SuspendDays is how long a suspension lasts.
+		SuspendDays int `json:"suspend_days"`
+	}
+	const defaultSuspendDays, maxSuspendDays = 7, 365
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return
+	}
+
+	params := parameters{}
+	err = decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	switch params.Action {
+	case "":
+		v.add("action", "is required")
+	case moderationDismiss, moderationHide, moderationDelete:
+	default:
+		v.add("action", "must be dismiss, hide or delete")
+	}
+	switch params.AuthorAction {
+	case "", moderationStrike, moderationSuspend:
+	default:
+		v.add("author_action", "must be strike or suspend")
+	}
+	if params.SuspendDays == 0 {
+		params.SuspendDays = defaultSuspendDays
+	}
+	if params.SuspendDays < 1 || params.SuspendDays > maxSuspendDays {
+		v.add("suspend_days", fmt.Sprintf("must be between 1 and %d", maxSuspendDays))
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	dbChirp, err := cfg.db.GetChirp(r.Context(), chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find chirp", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+	chirp := chirpFromDB(dbChirp)
+
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		switch params.Action {
+		case moderationHide:
+			_, err := tx.SetChirpHidden(r.Context(), database.SetChirpHiddenParams{
+				ID:       chirpID,
+				HiddenAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		case moderationDelete:
+			if err := tx.DeleteChirp(r.Context(), chirpID); err != nil {
+				return err
+			}
+			if err := webhooks.Enqueue(r.Context(), tx, webhooks.EventChirpDeleted, chirp.UserID, chirp); err != nil {
+				return err
+			}
+			if err := writeAudit(r, tx, chirpDeletedRecord(user.ID, dbChirp)); err != nil {
+				return err
+			}
+		}
+		if params.Action != moderationDelete {
+			_, err := tx.ResolveChirpReports(r.Context(), database.ResolveChirpReportsParams{
+				ChirpID:    chirpID,
+				Resolution: sql.NullString{String: params.Action, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		switch params.AuthorAction {
+		case moderationStrike:
+			_, err := tx.AddUserStrike(r.Context(), chirp.UserID)
+			return err
+		case moderationSuspend:
+			_, err := tx.SuspendUser(r.Context(), database.SuspendUserParams{
+				ID:             chirp.UserID,
+				SuspendedUntil: sql.NullTime{Time: time.Now().UTC().AddDate(0, 0, params.SuspendDays), Valid: true},
+			})
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't moderate chirp", err)
+		return
+	}
+
+	// Hidden chirps leave open streams the same way deleted ones do.
+	if params.Action != moderationDismiss {
+		cfg.publishChirpEvent(r.Context(), events.TypeChirpDeleted, chirp)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+func TestModeration(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+	carol, _ := newTestUser(t, srv, "carol@example.com")
+	admin, adminLogin := newTestUser(t, srv, "admin@example.com")
+	anon := client.New(srv.URL)
+
+	_, err := cfg.db.SetUserAdmin(ctx, database.SetUserAdminParams{ID: adminLogin.ID, IsAdmin: true})
+	if err != nil {
+		t.Fatalf("SetUserAdmin() error = %v", err)
+	}
+
+	spam, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "buy now"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	rude, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "you are all wrong"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+
+	wantStatus := func(name string, err error, status int) {
+		t.Helper()
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+			t.Errorf("%s error = %v, want status %d", name, err, status)
+		}
+	}
+
+	wantStatus("ReportChirp(own chirp)", bob.ReportChirp(ctx, spam.ID, client.CreateReportRequest{Reason: "spam"}), http.StatusBadRequest)
+	wantStatus("ReportChirp(bad reason)", alice.ReportChirp(ctx, spam.ID, client.CreateReportRequest{Reason: "boring"}), http.StatusBadRequest)
+	for _, report := range []struct {
+		c      *client.Client
+		chirp  client.Chirp
+		reason string
+	}{
+		{alice, spam, "spam"},
+		{alice, spam, "spam"},
+		{carol, spam, "other"},
+		{alice, rude, "harassment"},
+	} {
+		if err := report.c.ReportChirp(ctx, report.chirp.ID, client.CreateReportRequest{Reason: report.reason}); err != nil {
+			t.Fatalf("ReportChirp() error = %v", err)
+		}
+	}
+
+	_, err = alice.GetModerationQueue(ctx, nil)
+	wantStatus("GetModerationQueue(non-admin)", err, http.StatusForbidden)
+
+	queue, err := admin.GetModerationQueue(ctx, nil)
+	if err != nil {
+		t.Fatalf("GetModerationQueue() error = %v", err)
+	}
+	if len(queue) != 2 || queue[0].Chirp.ID != spam.ID || queue[0].ReportCount != 2 || len(queue[0].Reasons) != 2 {
+		t.Fatalf("GetModerationQueue() = %+v, want spam with 2 reports first", queue)
+	}
+
+	err = admin.ModerateChirp(ctx, spam.ID, client.ModerationActionRequest{Action: "hide", AuthorAction: "strike"})
+	if err != nil {
+		t.Fatalf("ModerateChirp(hide) error = %v", err)
+	}
+	_, err = anon.GetChirp(ctx, spam.ID, nil)
+	wantStatus("GetChirp(hidden) as anonymous", err, http.StatusNotFound)
+	if chirps, _ := alice.ListChirps(ctx, nil); len(chirps) != 1 || chirps[0].ID != rude.ID {
+		t.Errorf("ListChirps() as alice = %+v, want only the visible chirp", chirps)
+	}
+	for name, c := range map[string]*client.Client{"author": bob, "admin": admin} {
+		if chirp, err := c.GetChirp(ctx, spam.ID, nil); err != nil || !chirp.Hidden {
+			t.Errorf("GetChirp(hidden) as %s = %+v, %v, want the hidden chirp", name, chirp, err)
+		}
+		if chirps, _ := c.ListChirps(ctx, nil); len(chirps) != 2 {
+			t.Errorf("ListChirps() as %s = %+v, want both chirps", name, chirps)
+		}
+	}
+	wantStatus("ReportChirp(hidden)", carol.ReportChirp(ctx, spam.ID, client.CreateReportRequest{Reason: "spam"}), http.StatusNotFound)
+
+	queue, _ = admin.GetModerationQueue(ctx, nil)
+	if len(queue) != 1 || queue[0].Chirp.ID != rude.ID || queue[0].AuthorStrikes != 1 {
+		t.Fatalf("GetModerationQueue() after hiding = %+v, want rude chirp by an author with 1 strike", queue)
+	}
+
+	err = admin.ModerateChirp(ctx, rude.ID, client.ModerationActionRequest{Action: "delete", AuthorAction: "suspend", SuspendDays: 3})
+	if err != nil {
+		t.Fatalf("ModerateChirp(delete) error = %v", err)
+	}
+	_, err = admin.GetChirp(ctx, rude.ID, nil)
+	wantStatus("GetChirp(deleted)", err, http.StatusNotFound)
+	if queue, _ := admin.GetModerationQueue(ctx, nil); len(queue) != 0 {
+		t.Errorf("GetModerationQueue() after deleting = %+v, want empty", queue)
+	}
+
+	_, err = bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "let me back in"})
+	wantStatus("CreateChirp(suspended)", err, http.StatusForbidden)
+	if _, err := bob.ListChirps(ctx, nil); err != nil {
+		t.Errorf("ListChirps() while suspended error = %v", err)
+	}
+	user, _ := cfg.db.GetUserByID(ctx, bobLogin.ID)
+	if user.Strikes != 1 || !isSuspended(user) {
+		t.Errorf("bob = %+v, want 1 strike and suspended", user)
+	}
+
+	carolChirp, _ := carol.CreateChirp(ctx, client.CreateChirpRequest{Body: "fine chirp"})
+	if err := alice.ReportChirp(ctx, carolChirp.ID, client.CreateReportRequest{Reason: "other"}); err != nil {
+		t.Fatalf("ReportChirp() error = %v", err)
+	}
+	if err := admin.ModerateChirp(ctx, carolChirp.ID, client.ModerationActionRequest{Action: "dismiss"}); err != nil {
+		t.Fatalf("ModerateChirp(dismiss) error = %v", err)
+	}
+	if queue, _ := admin.GetModerationQueue(ctx, nil); len(queue) != 0 {
+		t.Errorf("GetModerationQueue() after dismissing = %+v, want empty", queue)
+	}
+	if _, err := anon.GetChirp(ctx, carolChirp.ID, nil); err != nil {
+		t.Errorf("GetChirp(dismissed) error = %v", err)
+	}
+}
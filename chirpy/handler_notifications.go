@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// Notification kinds.
+const (
+	notificationMention = "mention"
+	notificationLike    = "like"
+	notificationFollow  = "follow"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Kind      string     `json:"kind"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	ChirpID   *uuid.UUID `json:"chirp_id,omitempty"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+}
+
+func (cfg *apiConfig) handlerNotificationsList(w http.ResponseWriter, r *http.Request, user database.User) {
+	const defaultLimit, maxLimit = 50, 100
+	type response struct {
+		UnreadCount   int            `json:"unread_count"`
+		Notifications []Notification `json:"notifications"`
+	}
+
+	unreadOnly := false
+	if unreadString := r.URL.Query().Get("unread"); unreadString != "" {
+		var err error
+		unreadOnly, err = strconv.ParseBool(unreadString)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "unread must be true or false", err)
+			return
+		}
+	}
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	dbNotifications, err := cfg.db.GetNotifications(r.Context(), database.GetNotificationsParams{
+		UserID:           user.ID,
+		UnreadOnly:       unreadOnly,
+		MaxNotifications: int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve notifications", err)
+		return
+	}
+	unread, err := cfg.db.CountUnreadNotifications(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count notifications", err)
+		return
+	}
+
+	resp := response{
+		UnreadCount:   int(unread),
+		Notifications: []Notification{},
+	}
+	for _, n := range dbNotifications {
+		notification := Notification{
+			ID:        n.ID,
+			CreatedAt: n.CreatedAt,
+			Kind:      n.Kind,
+			ActorID:   n.ActorID,
+			Read:      n.ReadAt.Valid,
+		}
+		if n.ChirpID.Valid {
+			notification.ChirpID = &n.ChirpID.UUID
+		}
+		if n.ReadAt.Valid {
+			notification.ReadAt = &n.ReadAt.Time
+		}
+		resp.Notifications = append(resp.Notifications, notification)
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerNotificationsRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+		All bool        `json:"all"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	if len(params.IDs) == 0 && !params.All {
+		v.add("ids", "is required unless all is true")
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	if params.All {
+		err = cfg.db.MarkAllNotificationsRead(r.Context(), user.ID)
+	} else {
+		err = cfg.db.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			UserID: user.ID,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark notifications read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+func TestHashtagsMentionsAndNotifications(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+
+	mention, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "hey @alice@example.com, try #Go and #東京"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	aliceChirp, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "#go all day"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+
+	page, err := alice.GetHashtagChirps(ctx, "#GO", nil)
+	if err != nil || len(page.Chirps) != 2 || page.Chirps[0].ID != mention.ID {
+		t.Fatalf("GetHashtagChirps(#GO) = %+v, %v, want both #go chirps, oldest first", page, err)
+	}
+	if page, _ := alice.GetHashtagChirps(ctx, "東京", nil); len(page.Chirps) != 1 {
+		t.Errorf("GetHashtagChirps(東京) = %+v, want bob's chirp", page)
+	}
+
+	trending, err := alice.GetTrendingHashtags(ctx, nil)
+	if err != nil || len(trending) != 2 || trending[0].Tag != "go" || trending[0].Uses != 2 {
+		t.Fatalf("GetTrendingHashtags() = %+v, %v, want go used twice first", trending, err)
+	}
+
+	if err := bob.LikeChirp(ctx, aliceChirp.ID); err != nil {
+		t.Fatalf("LikeChirp() error = %v", err)
+	}
+	if err := bob.LikeChirp(ctx, aliceChirp.ID); err != nil {
+		t.Fatalf("LikeChirp() again error = %v", err)
+	}
+	if err := alice.LikeChirp(ctx, aliceChirp.ID); err != nil {
+		t.Fatalf("LikeChirp() own chirp error = %v", err)
+	}
+	if err := bob.FollowUser(ctx, aliceLogin.ID); err != nil {
+		t.Fatalf("FollowUser() error = %v", err)
+	}
+
+	inbox, err := alice.ListNotifications(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListNotifications() error = %v", err)
+	}
+	if inbox.UnreadCount != 3 || len(inbox.Notifications) != 3 {
+		t.Fatalf("ListNotifications() = %+v, want 3 unread", inbox)
+	}
+	kinds := []string{}
+	for _, n := range inbox.Notifications {
+		if n.ActorID != bobLogin.ID || n.Read {
+			t.Errorf("notification = %+v, want unread from bob", n)
+		}
+		kinds = append(kinds, n.Kind)
+	}
+	if kinds[0] != "follow" || kinds[1] != "like" || kinds[2] != "mention" {
+		t.Errorf("notification kinds = %v, want follow, like, mention", kinds)
+	}
+	if inbox.Notifications[2].ChirpID != mention.ID {
+		t.Errorf("mention notification chirp = %v, want %v", inbox.Notifications[2].ChirpID, mention.ID)
+	}
+	if bobInbox, _ := bob.ListNotifications(ctx, nil); len(bobInbox.Notifications) != 0 {
+		t.Errorf("bob's notifications = %+v, want none", bobInbox)
+	}
+
+	err = alice.MarkNotificationsRead(ctx, client.MarkNotificationsReadRequest{IDs: []uuid.UUID{inbox.Notifications[0].ID}})
+	if err != nil {
+		t.Fatalf("MarkNotificationsRead() error = %v", err)
+	}
+	unread := true
+	unreadInbox, err := alice.ListNotifications(ctx, &client.ListNotificationsParams{Unread: &unread})
+	if err != nil || unreadInbox.UnreadCount != 2 || len(unreadInbox.Notifications) != 2 {
+		t.Fatalf("ListNotifications(unread) = %+v, %v, want 2 unread", unreadInbox, err)
+	}
+
+	if err := bob.DeleteChirp(ctx, mention.ID, nil); err != nil {
+		t.Fatalf("DeleteChirp() error = %v", err)
+	}
+	if err := alice.MarkNotificationsRead(ctx, client.MarkNotificationsReadRequest{All: true}); err != nil {
+		t.Fatalf("MarkNotificationsRead(all) error = %v", err)
+	}
+	inbox, _ = alice.ListNotifications(ctx, nil)
+	if inbox.UnreadCount != 0 || len(inbox.Notifications) != 2 {
+		t.Errorf("ListNotifications() after deleting the mention = %+v, want 2 read", inbox)
+	}
+	if page, _ := alice.GetHashtagChirps(ctx, "go", nil); len(page.Chirps) != 1 {
+		t.Errorf("GetHashtagChirps(go) after delete = %+v, want only alice's chirp", page)
+	}
+}
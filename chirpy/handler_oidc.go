@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/oidc"
+)
+
+// oidcLoginCookie holds the state, nonce and PKCE code verifier of a
+// login in progress, between sending the user to the provider and their
+// return to the callback. It is signed so none of them can be swapped.
+const oidcLoginCookie = "chirpy_oidc_login"
+
+// oidcLoginTTL is how long a user has to sign in with the provider.
+const oidcLoginTTL = 10 * time.Minute
+
+var (
+	errOIDCNoVerifiedEmail = errors.New("identity provider didn't share a verified email")
+	errAccountDeleted      = errors.New("account has been deleted")
+)
+
+// oidcLogin is the content of the oidcLoginCookie.
+type oidcLogin struct {
+	Provider  string    `json:"provider"`
+	State     string    `json:"state"`
+	Nonce     string    `json:"nonce"`
+	Verifier  string    `json:"verifier"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handlerOIDCLogin starts a login with an identity provider by
+// redirecting the user to it.
+func (cfg *apiConfig) handlerOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("provider")
+	provider, ok := cfg.oidcProviders[name]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown identity provider", nil)
+		return
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start login", err)
+		return
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start login", err)
+		return
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start login", err)
+		return
+	}
+	login := oidcLogin{
+		Provider:  name,
+		State:     state,
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: time.Now().UTC().Add(oidcLoginTTL),
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state, nonce, challenge)
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Couldn't reach identity provider", err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcLoginCookie,
+		Value:    cfg.signOIDCLogin(login),
+		Path:     "/api/auth/" + name + "/",
+		MaxAge:   int(oidcLoginTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(provider.RedirectURL(), "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handlerOIDCCallback finishes a login with an identity provider and
+// responds like handlerLogin. A user is found by the identity; failing
+// that, the identity is linked to the user with the provider's verified
+// email, who is created if there is none. Linking an existing user
+// clears their password and revokes their refresh tokens, so nobody who
+// signed up with an email they don't own keeps access.
+func (cfg *apiConfig) handlerOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("provider")
+	provider, ok := cfg.oidcProviders[name]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown identity provider", nil)
+		return
+	}
+
+	// The login cookie is only good once.
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcLoginCookie,
+		Path:     "/api/auth/" + name + "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(provider.RedirectURL(), "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	q := r.URL.Query()
+	if providerErr := q.Get("error"); providerErr != "" {
+		respondWithError(w, http.StatusUnauthorized, "Identity provider refused the login: "+providerErr, nil)
+		return
+	}
+	cookie, err := r.Cookie(oidcLoginCookie)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "No login in progress", err)
+		return
+	}
+	login, err := cfg.verifyOIDCLogin(cookie.Value)
+	if err != nil || login.Provider != name || !hmac.Equal([]byte(login.State), []byte(q.Get("state"))) {
+		respondWithError(w, http.StatusBadRequest, "Login state doesn't match", err)
+		return
+	}
+	code := q.Get("code")
+	if code == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing authorization code", nil)
+		return
+	}
+
+	claims, err := provider.Exchange(r.Context(), code, login.Verifier, login.Nonce)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't verify the identity provider's login", err)
+		return
+	}
+
+	var user database.User
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		var err error
+		user, err = cfg.userForIdentity(r, tx, provider.Issuer(), claims)
+		return err
+	})
+	switch {
+	case errors.Is(err, errOIDCNoVerifiedEmail), errors.Is(err, errAccountDeleted):
+		cfg.audit(r, auditRecord{Action: auditLoginFailed, TargetType: auditTargetEmail, TargetID: claims.Email})
+		respondWithError(w, http.StatusForbidden, "Couldn't sign in: "+err.Error(), err)
+		return
+	case err != nil:
+		respondWithError(w, http.StatusInternalServerError, "Couldn't sign in", err)
+		return
+	}
+
+	cfg.audit(r, auditRecord{Action: auditLoginSucceeded, ActorID: user.ID, TargetType: auditTargetUser, TargetID: user.ID.String()})
+	cfg.respondWithTokens(w, r, user)
+}
+
+// userForIdentity returns the user an identity belongs to, linking it to
+// a new or existing user on first use.
+func (cfg *apiConfig) userForIdentity(r *http.Request, tx database.Store, issuer string, claims *oidc.Claims) (database.User, error) {
+	user, err := tx.GetUserByIdentity(r.Context(), database.GetUserByIdentityParams{
+		Issuer:  issuer,
+		Subject: claims.Subject,
+	})
+	if err == nil {
+		if user.DeletedAt.Valid {
+			return database.User{}, errAccountDeleted
+		}
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, err
+	}
+
+	// Only a verified email proves the identity's owner also owns the
+	// chirpy account with that email.
+	if claims.Email == "" || !claims.EmailVerified {
+		return database.User{}, errOIDCNoVerifiedEmail
+	}
+	user, err = tx.GetUserByEmail(r.Context(), claims.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The user can set a password later with a password reset.
+		user, err = tx.CreateUser(r.Context(), database.CreateUserParams{
+			Email:          claims.Email,
+			HashedPassword: auth.UnsetPassword,
+		})
+	} else if err == nil && !user.DeletedAt.Valid {
+		// Signing up doesn't prove the email is the user's, so whoever
+		// made the account may not own the identity. Its password and
+		// sessions go, leaving the identity the only way in.
+		user, err = tx.SetUserPassword(r.Context(), database.SetUserPasswordParams{
+			ID:             user.ID,
+			HashedPassword: auth.UnsetPassword,
+		})
+		if err == nil {
+			err = tx.RevokeUserRefreshTokens(r.Context(), user.ID)
+		}
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+	if user.DeletedAt.Valid {
+		return database.User{}, errAccountDeleted
+	}
+
+	_, err = tx.CreateUserIdentity(r.Context(), database.CreateUserIdentityParams{
+		UserID:  user.ID,
+		Issuer:  issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return user, nil
+}
+
+// signOIDCLogin encodes login as base64 JSON followed by an HMAC of it
+// keyed with the JWT secret.
+func (cfg *apiConfig) signOIDCLogin(login oidcLogin) string {
+	dat, _ := json.Marshal(login)
+	payload := base64.RawURLEncoding.EncodeToString(dat)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(cfg.oidcLoginMAC(payload))
+}
+
+// verifyOIDCLogin checks and decodes a value from signOIDCLogin.
+func (cfg *apiConfig) verifyOIDCLogin(value string) (oidcLogin, error) {
+	payload, sig, ok := strings.Cut(value, ".")
+	if !ok {
+		return oidcLogin{}, errors.New("malformed login cookie")
+	}
+	gotMAC, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotMAC, cfg.oidcLoginMAC(payload)) {
+		return oidcLogin{}, errors.New("login cookie signature doesn't match")
+	}
+	dat, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return oidcLogin{}, err
+	}
+	login := oidcLogin{}
+	if err := json.Unmarshal(dat, &login); err != nil {
+		return oidcLogin{}, err
+	}
+	if time.Now().After(login.ExpiresAt) {
+		return oidcLogin{}, errors.New("login has expired")
+	}
+	return login, nil
+}
+
+func (cfg *apiConfig) oidcLoginMAC(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(cfg.jwtSecret))
+	// Keep these MACs apart from anything else signed with the secret.
+	mac.Write([]byte("chirpy-oidc-login:"))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/oidc"
+	"github.com/lsherman98/boot.dev/chirpy/internal/oidc/oidctest"
+)
+
+// newTestOIDCProvider registers a mock identity provider named "mock".
+func newTestOIDCProvider(t *testing.T, srv *httptest.Server, cfg *apiConfig) *oidctest.Provider {
+	t.Helper()
+	mock := oidctest.NewProvider(t, "chirpy", "client-secret")
+	cfg.oidcProviders = map[string]*oidc.Provider{
+		"mock": oidc.NewProvider(oidc.Config{
+			Issuer:       mock.URL,
+			ClientID:     "chirpy",
+			ClientSecret: "client-secret",
+			RedirectURL:  srv.URL + "/api/auth/mock/callback",
+		}),
+	}
+	return mock
+}
+
+// oidcSignIn goes through the whole login as a browser would, following
+// the redirects to the provider and back, and returns the final response.
+func oidcSignIn(t *testing.T, srv *httptest.Server) (int, client.LoginResponse) {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := &http.Client{Jar: jar}
+	resp, err := browser.Get(srv.URL + "/api/auth/mock/login")
+	if err != nil {
+		t.Fatalf("signing in: %v", err)
+	}
+	defer resp.Body.Close()
+	login := client.LoginResponse{}
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&login); err != nil {
+			t.Fatalf("decoding login response: %v", err)
+		}
+	}
+	return resp.StatusCode, login
+}
+
+func TestOIDCLogin(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	mock := newTestOIDCProvider(t, srv, cfg)
+
+	mock.SetUser(oidctest.User{Subject: "new-user", Email: "new@example.com", EmailVerified: true})
+	status, first := oidcSignIn(t, srv)
+	if status != http.StatusOK {
+		t.Fatalf("signing in a new user status = %d, want 200", status)
+	}
+	if first.Email != "new@example.com" || first.Token == "" || first.RefreshToken == "" {
+		t.Errorf("login = %+v, want new@example.com with a token pair", first)
+	}
+	c := client.New(srv.URL)
+	c.RefreshToken = first.RefreshToken
+	if _, err := c.Refresh(ctx); err != nil {
+		t.Errorf("Refresh() with the OIDC refresh token error = %v", err)
+	}
+
+	// The identity is linked now, so a changed email doesn't matter.
+	mock.SetUser(oidctest.User{Subject: "new-user", Email: "renamed@example.com"})
+	status, again := oidcSignIn(t, srv)
+	if status != http.StatusOK || again.ID != first.ID {
+		t.Errorf("signing in again = %d, %s, want 200 and user %s", status, again.ID, first.ID)
+	}
+
+	// A new identity with the verified email of an existing user is
+	// linked to that user, who loses their password and sessions.
+	squatter, existing := newTestUser(t, srv, "existing@example.com")
+	mock.SetUser(oidctest.User{Subject: "existing-user", Email: "existing@example.com", EmailVerified: true})
+	status, linked := oidcSignIn(t, srv)
+	if status != http.StatusOK || linked.ID != existing.ID {
+		t.Errorf("signing in with an existing email = %d, %s, want 200 and user %s", status, linked.ID, existing.ID)
+	}
+	if _, err := squatter.Refresh(ctx); err == nil {
+		t.Error("Refresh() with a token from before the link error = nil, want it revoked")
+	}
+	if _, err := c.Login(ctx, client.Credentials{Email: "existing@example.com", Password: "password"}); err == nil {
+		t.Error("Login() with the password from before the link error = nil, want it cleared")
+	}
+	c.RefreshToken = linked.RefreshToken
+	if _, err := c.Refresh(ctx); err != nil {
+		t.Errorf("Refresh() with the linked identity's token error = %v", err)
+	}
+
+	mock.SetUser(oidctest.User{Subject: "unverified", Email: "existing@example.com", EmailVerified: false})
+	if status, _ := oidcSignIn(t, srv); status != http.StatusForbidden {
+		t.Errorf("signing in with an unverified email status = %d, want 403", status)
+	}
+}
+
+func TestDeleteOIDCUser(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	mock := newTestOIDCProvider(t, srv, cfg)
+
+	mock.SetUser(oidctest.User{Subject: "new-user", Email: "new@example.com", EmailVerified: true})
+	status, login := oidcSignIn(t, srv)
+	if status != http.StatusOK {
+		t.Fatalf("sign in status = %d, want 200", status)
+	}
+	c := client.New(srv.URL)
+	c.RefreshToken = login.RefreshToken
+
+	// A refreshed token doesn't show the user just signed in.
+	resp, err := c.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	c.AccessToken = resp.Token
+	err = c.DeleteCurrentUser(ctx, client.DeleteUserRequest{})
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("DeleteCurrentUser(refreshed token) error = %v, want 403", err)
+	}
+
+	c.AccessToken = login.Token
+	if err := c.DeleteCurrentUser(ctx, client.DeleteUserRequest{}); err != nil {
+		t.Fatalf("DeleteCurrentUser(fresh sign-in) error = %v", err)
+	}
+	if _, err := cfg.db.GetUserByID(ctx, login.ID); err == nil {
+		t.Error("GetUserByID() after deletion error = nil, want the user gone")
+	}
+}
+
+func TestOIDCLoginRejectsBadTokens(t *testing.T) {
+	srv, cfg := newTestServer(t)
+	mock := newTestOIDCProvider(t, srv, cfg)
+
+	mock.ModifyClaims(func(c jwt.MapClaims) { c["nonce"] = "replayed" })
+	if status, _ := oidcSignIn(t, srv); status != http.StatusUnauthorized {
+		t.Errorf("signing in with the wrong nonce status = %d, want 401", status)
+	}
+	mock.ModifyClaims(func(c jwt.MapClaims) { c["aud"] = "another-client" })
+	if status, _ := oidcSignIn(t, srv); status != http.StatusUnauthorized {
+		t.Errorf("signing in with another client's token status = %d, want 401", status)
+	}
+	mock.ModifyClaims(nil)
+	if status, _ := oidcSignIn(t, srv); status != http.StatusOK {
+		t.Errorf("signing in status = %d, want 200", status)
+	}
+}
+
+func TestOIDCCallbackChecksState(t *testing.T) {
+	srv, cfg := newTestServer(t)
+	newTestOIDCProvider(t, srv, cfg)
+
+	// Without the login cookie, as when an attacker sends the victim a
+	// callback link with their own code.
+	resp, err := http.Get(srv.URL + "/api/auth/mock/callback?code=abc&state=xyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback without a login status = %d, want 400", resp.StatusCode)
+	}
+
+	// With the cookie of a login that was for another state.
+	cookie := cfg.signOIDCLogin(oidcLogin{Provider: "mock", State: "expected", ExpiresAt: time.Now().Add(time.Minute)})
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/auth/mock/callback?code=abc&state=other", nil)
+	req.AddCookie(&http.Cookie{Name: oidcLoginCookie, Value: cookie})
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback with another state status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/api/auth/unknown/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown provider status = %d, want 404", resp.StatusCode)
+	}
+}
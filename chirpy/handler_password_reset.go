@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// passwordResetTTL is how long a password reset token can be used for.
+const passwordResetTTL = time.Hour
+
+// passwordResetMailer delivers password reset tokens to users.
+type passwordResetMailer interface {
+	SendPasswordReset(ctx context.Context, email, token string, expiresAt time.Time) error
+}
+
+// logPasswordResetMailer logs reset tokens instead of emailing them. Anyone
+// who can read the logs could take over accounts with them, so it is only
+// used with PLATFORM=dev.
+type logPasswordResetMailer struct{}
+
+func (logPasswordResetMailer) SendPasswordReset(ctx context.Context, email, token string, expiresAt time.Time) error {
+	log.Printf("Password reset token for %s, valid until %s: %s", email, expiresAt.Format(time.RFC3339), token)
+	return nil
+}
+
+// hashResetToken returns what is stored for a reset token. Reset tokens
+// are long and random, so a fast hash is enough.
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// handlerPasswordResetRequest sends a reset token to the account with the
+// given email. It answers the same whether or not the account exists, so
+// it can't be used to find out who has one. Without a mailer it answers
+// 501.
+func (cfg *apiConfig) handlerPasswordResetRequest(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+
+	if cfg.passwordResetMailer == nil {
+		respondWithError(w, http.StatusNotImplemented, "Password reset isn't available on this server", nil)
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	v.requireEmail("email", params.Email)
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	user, err := cfg.db.GetUserByEmail(r.Context(), params.Email)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && user.DeletedAt.Valid) {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create reset token", err)
+		return
+	}
+	resetToken, err := cfg.db.CreatePasswordResetToken(r.Context(), database.CreatePasswordResetTokenParams{
+		TokenHash: hashResetToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().UTC().Add(passwordResetTTL),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save reset token", err)
+		return
+	}
+
+	err = cfg.passwordResetMailer.SendPasswordReset(r.Context(), user.Email, token, resetToken.ExpiresAt)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't send reset token", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handlerPasswordResetConfirm sets a new password with a reset token. The
+// user's other reset tokens and all their refresh tokens stop working.
+func (cfg *apiConfig) handlerPasswordResetConfirm(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	v.require("token", params.Token)
+	v.require("password", params.Password)
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	hashedPassword, err := cfg.passwordHashing.Hash(params.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+		return
+	}
+
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		resetToken, err := tx.UsePasswordResetToken(r.Context(), hashResetToken(params.Token))
+		if err != nil {
+			return err
+		}
+		_, err = tx.SetUserPassword(r.Context(), database.SetUserPasswordParams{
+			ID:             resetToken.UserID,
+			HashedPassword: hashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.ExpireUserPasswordResetTokens(r.Context(), resetToken.UserID); err != nil {
+			return err
+		}
+		return tx.RevokeUserRefreshTokens(r.Context(), resetToken.UserID)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired reset token", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reset password", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recordingMailer keeps the reset tokens it is asked to send.
+type recordingMailer struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func (m *recordingMailer) SendPasswordReset(ctx context.Context, email, token string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.tokens == nil {
+		m.tokens = map[string]string{}
+	}
+	m.tokens[email] = token
+	return nil
+}
+
+func (m *recordingMailer) token(email string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tokens[email]
+}
+
+func TestLoginRehashesBcryptPasswords(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := cfg.db.CreateUser(ctx, database.CreateUserParams{Email: "old@example.com", HashedPassword: string(legacy)})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	c := client.New(srv.URL)
+	creds := client.Credentials{Email: "old@example.com", Password: "password"}
+	if _, err := c.Login(ctx, creds); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	user, err = cfg.db.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if !strings.HasPrefix(user.HashedPassword, "$argon2id$") || cfg.passwordHashing.NeedsRehash(user.HashedPassword) {
+		t.Errorf("HashedPassword = %q, want an argon2id hash with the current parameters", user.HashedPassword)
+	}
+
+	if _, err := c.Login(ctx, creds); err != nil {
+		t.Errorf("Login() after rehashing error = %v", err)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	mailer := &recordingMailer{}
+	cfg.passwordResetMailer = mailer
+
+	user, err := cfg.db.CreateUser(ctx, database.CreateUserParams{Email: "early@example.com", HashedPassword: auth.UnsetPassword})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	c := client.New(srv.URL)
+	_, err = c.Login(ctx, client.Credentials{Email: "early@example.com", Password: "unset"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message != "Incorrect email or password" {
+		t.Fatalf("Login() with an unset password error = %v, want the 401 of a wrong password", err)
+	}
+
+	if err := c.RequestPasswordReset(ctx, client.PasswordResetRequest{Email: "nobody@example.com"}); err != nil {
+		t.Errorf("RequestPasswordReset() for an unknown email error = %v, want nil", err)
+	}
+	if token := mailer.token("nobody@example.com"); token != "" {
+		t.Errorf("a reset token was sent to an unknown email")
+	}
+
+	if err := c.RequestPasswordReset(ctx, client.PasswordResetRequest{Email: "early@example.com"}); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	token := mailer.token("early@example.com")
+	if token == "" {
+		t.Fatal("no reset token was sent")
+	}
+
+	err = c.ConfirmPasswordReset(ctx, client.PasswordResetConfirmRequest{Token: "not-a-token", Password: "new password"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("ConfirmPasswordReset() with a bad token error = %v, want a 401", err)
+	}
+
+	if err := c.ConfirmPasswordReset(ctx, client.PasswordResetConfirmRequest{Token: token, Password: "new password"}); err != nil {
+		t.Fatalf("ConfirmPasswordReset() error = %v", err)
+	}
+	login, err := c.Login(ctx, client.Credentials{Email: "early@example.com", Password: "new password"})
+	if err != nil {
+		t.Fatalf("Login() with the new password error = %v", err)
+	}
+	if login.ID != user.ID {
+		t.Errorf("Login() ID = %s, want %s", login.ID, user.ID)
+	}
+
+	err = c.ConfirmPasswordReset(ctx, client.PasswordResetConfirmRequest{Token: token, Password: "another password"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("ConfirmPasswordReset() reusing a token error = %v, want a 401", err)
+	}
+}
+
+func TestPasswordResetWithoutMailer(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	err := client.New(srv.URL).RequestPasswordReset(context.Background(), client.PasswordResetRequest{Email: "alice@example.com"})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotImplemented {
+		t.Errorf("RequestPasswordReset() without a mailer error = %v, want a 501", err)
+	}
+}
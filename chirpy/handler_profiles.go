@@ -0,0 +1,95 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// Profile is the public view of a user. It leaves out private fields
+// such as the email address.
+type Profile struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Handle      string    `json:"handle"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+func (cfg *apiConfig) handlerProfileGet(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.getUserByHandle(w, r)
+	if !ok {
+		return
+	}
+	if checkNotModified(w, r, resourceETag(user.ID, user.UpdatedAt), user.UpdatedAt) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, Profile{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarURL:   user.AvatarUrl,
+		IsChirpyRed: user.IsChirpyRed,
+	})
+}
+
+func (cfg *apiConfig) handlerProfileChirps(w http.ResponseWriter, r *http.Request) {
+	user, ok := cfg.getUserByHandle(w, r)
+	if !ok {
+		return
+	}
+
+	dbChirps, err := cfg.db.GetChirpsByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve chirps", err)
+		return
+	}
+
+	viewer := cfg.optionalUser(r)
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		if chirpVisibleTo(dbChirp, viewer) {
+			chirps = append(chirps, chirpFromDB(dbChirp))
+		}
+	}
+	sortChirps(chirps, r.URL.Query().Get("sort"))
+	if err := cfg.withLinkPreviews(r.Context(), chirps); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get link previews", err)
+		return
+	}
+	if checkNotModified(w, r, chirpsETag(chirps), time.Time{}) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// getUserByHandle looks up the user named by the request's handle path
+// value, responding with an error and returning false if there isn't one.
+func (cfg *apiConfig) getUserByHandle(w http.ResponseWriter, r *http.Request) (database.User, bool) {
+	handle := r.PathValue("handle")
+	if database.CheckHandle(handle) != "" {
+		respondWithError(w, http.StatusNotFound, "Couldn't find user", nil)
+		return database.User{}, false
+	}
+
+	user, err := cfg.db.GetUserByHandle(r.Context(), handle)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+			return database.User{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return database.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+func TestProfiles(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, _ := newTestUser(t, srv, "bob@example.com")
+
+	user, err := alice.UpdateUser(ctx, nil, client.UpdateUserRequest{
+		Handle:      "Alice_1",
+		DisplayName: "Alice",
+		Bio:         "Chirping since 2024",
+		AvatarURL:   "https://example.com/alice.png",
+	})
+	if err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if user.Handle != "Alice_1" || user.Email != "alice@example.com" || user.DisplayName != "Alice" {
+		t.Errorf("UpdateUser() = %+v, want the new profile with the email unchanged", user)
+	}
+	if _, err := alice.Login(ctx, client.Credentials{Email: "alice@example.com", Password: "password"}); err != nil {
+		t.Errorf("Login() after a profile update error = %v, want the password unchanged", err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		req    client.UpdateUserRequest
+		status int
+	}{
+		{"taken in another case", client.UpdateUserRequest{Handle: "alice_1"}, http.StatusConflict},
+		{"reserved", client.UpdateUserRequest{Handle: "Admin"}, http.StatusBadRequest},
+		{"too short", client.UpdateUserRequest{Handle: "ab"}, http.StatusBadRequest},
+		{"bad characters", client.UpdateUserRequest{Handle: "bob.smith"}, http.StatusBadRequest},
+		{"bad avatar", client.UpdateUserRequest{AvatarURL: "javascript:alert(1)"}, http.StatusBadRequest},
+		{"empty", client.UpdateUserRequest{}, http.StatusBadRequest},
+	} {
+		_, err := bob.UpdateUser(ctx, nil, tc.req)
+		apiErr := &client.APIError{}
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status {
+			t.Errorf("UpdateUser(%s) error = %v, want status %d", tc.name, err, tc.status)
+		}
+	}
+
+	profile, err := bob.GetProfile(ctx, "ALICE_1", nil)
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if profile.ID != aliceLogin.ID || profile.Handle != "Alice_1" || profile.Bio != "Chirping since 2024" {
+		t.Errorf("GetProfile() = %+v, want alice's profile", profile)
+	}
+	resp, err := http.Get(srv.URL + "/api/users/alice_1")
+	if err != nil {
+		t.Fatalf("GET profile error = %v", err)
+	}
+	defer resp.Body.Close()
+	dat, _ := io.ReadAll(resp.Body)
+	fields := map[string]any{}
+	if err := json.Unmarshal(dat, &fields); err != nil {
+		t.Fatalf("decoding profile: %v", err)
+	}
+	if _, ok := fields["email"]; ok {
+		t.Errorf("profile = %s, want no email", dat)
+	}
+
+	if _, err := bob.GetProfile(ctx, "nobody", nil); err == nil {
+		t.Error("GetProfile(nobody) error = nil, want 404")
+	}
+
+	first, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "first"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if _, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "second"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if _, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "hi @alice_1"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	desc := "desc"
+	chirps, err := bob.GetProfileChirps(ctx, "alice_1", &client.GetProfileChirpsParams{Sort: &desc})
+	if err != nil || len(chirps) != 2 || chirps[1].ID != first.ID {
+		t.Errorf("GetProfileChirps() = %+v, %v, want alice's two chirps, newest first", chirps, err)
+	}
+
+	inbox, err := alice.ListNotifications(ctx, nil)
+	if err != nil || len(inbox.Notifications) != 1 || inbox.Notifications[0].Kind != "mention" {
+		t.Errorf("ListNotifications() = %+v, %v, want a mention by handle", inbox, err)
+	}
+}
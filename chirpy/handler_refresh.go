@@ -34,6 +34,7 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	cfg.audit(r, auditRecord{Action: auditTokenRefreshed, ActorID: user.ID, TargetType: auditTargetUser, TargetID: user.ID.String()})
 	respondWithJSON(w, http.StatusOK, response{
 		Token: accessToken,
 	})
@@ -46,11 +47,12 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
+	revoked, err := cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't revoke session", err)
 		return
 	}
+	cfg.audit(r, auditRecord{Action: auditTokenRevoked, ActorID: revoked.UserID, TargetType: auditTargetUser, TargetID: revoked.UserID.String()})
 
 	w.WriteHeader(http.StatusNoContent)
 }
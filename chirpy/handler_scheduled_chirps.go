@@ -0,0 +1,210 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// maxScheduleAhead is how far in the future a chirp can be scheduled: a
+// year.
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+// scheduleChirp stores a validated chirp body to be published at
+// publishAt. Scheduling is a Chirpy Red feature.
+func (cfg *apiConfig) scheduleChirp(w http.ResponseWriter, r *http.Request, user database.User, body string, publishAt time.Time) {
+	if !user.IsChirpyRed {
+		respondWithError(w, http.StatusForbidden, "Scheduling chirps requires Chirpy Red", nil)
+		return
+	}
+
+	v := validation{}
+	v.requirePublishAt("publish_at", publishAt)
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	chirp, err := cfg.db.CreateScheduledChirp(r.Context(), database.CreateScheduledChirpParams{
+		UserID:    user.ID,
+		Body:      body,
+		PublishAt: publishAt.UTC(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't schedule chirp", err)
+		return
+	}
+
+	w.Header().Set("ETag", resourceETag(chirp.ID, chirp.UpdatedAt))
+	respondWithJSON(w, http.StatusCreated, scheduledChirpFromDB(chirp))
+}
+
+func (cfg *apiConfig) handlerScheduledChirpsList(w http.ResponseWriter, r *http.Request, user database.User) {
+	dbChirps, err := cfg.db.GetScheduledChirpsByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve scheduled chirps", err)
+		return
+	}
+
+	chirps := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirps = append(chirps, scheduledChirpFromDB(dbChirp))
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+func (cfg *apiConfig) handlerScheduledChirpsUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	// Omitted fields are left unchanged.
+	type parameters struct {
+		Body      *string    `json:"body"`
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	chirp, ok := cfg.getOwnedScheduledChirp(w, r, user)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	if params.Body == nil && params.PublishAt == nil {
+		v.add("body", "must set body or publish_at")
+	}
+	if params.PublishAt != nil {
+		v.requirePublishAt("publish_at", *params.PublishAt)
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+	if params.Body != nil {
+		cleaned, err := validateChirp(*params.Body)
+		if err != nil {
+			respondWithRequestError(w, err)
+			return
+		}
+		params.Body = &cleaned
+	}
+
+	var currentETag string
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		current, err := tx.GetScheduledChirpForUpdate(r.Context(), chirp.ID)
+		if err != nil {
+			return err
+		}
+		currentETag = resourceETag(current.ID, current.UpdatedAt)
+		if !ifMatch(r, currentETag) {
+			return errPreconditionFailed
+		}
+
+		arg := database.UpdateScheduledChirpParams{
+			ID:        current.ID,
+			Body:      current.Body,
+			PublishAt: current.PublishAt,
+		}
+		if params.Body != nil {
+			arg.Body = *params.Body
+		}
+		if params.PublishAt != nil {
+			arg.PublishAt = params.PublishAt.UTC()
+		}
+		chirp, err = tx.UpdateScheduledChirp(r.Context(), arg)
+		return err
+	})
+	if err != nil {
+		respondScheduledChirpTxError(w, err, currentETag, "Couldn't update scheduled chirp")
+		return
+	}
+
+	w.Header().Set("ETag", resourceETag(chirp.ID, chirp.UpdatedAt))
+	respondWithJSON(w, http.StatusOK, scheduledChirpFromDB(chirp))
+}
+
+func (cfg *apiConfig) handlerScheduledChirpsCancel(w http.ResponseWriter, r *http.Request, user database.User) {
+	chirp, ok := cfg.getOwnedScheduledChirp(w, r, user)
+	if !ok {
+		return
+	}
+
+	var currentETag string
+	err := cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		current, err := tx.GetScheduledChirpForUpdate(r.Context(), chirp.ID)
+		if err != nil {
+			return err
+		}
+		currentETag = resourceETag(current.ID, current.UpdatedAt)
+		if !ifMatch(r, currentETag) {
+			return errPreconditionFailed
+		}
+		_, err = tx.DeleteScheduledChirp(r.Context(), chirp.ID)
+		return err
+	})
+	if err != nil {
+		respondScheduledChirpTxError(w, err, currentETag, "Couldn't cancel scheduled chirp")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondScheduledChirpTxError responds to an error from changing a
+// scheduled chirp in a transaction.
+func respondScheduledChirpTxError(w http.ResponseWriter, err error, currentETag, msg string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// The publisher may have got to it first.
+		respondWithError(w, http.StatusNotFound, "Couldn't find scheduled chirp", err)
+	case errors.Is(err, errPreconditionFailed):
+		respondPreconditionFailed(w, currentETag)
+	default:
+		respondWithError(w, http.StatusInternalServerError, msg, err)
+	}
+}
+
+// getOwnedScheduledChirp looks up the pending chirp named by the request's
+// chirpID path value, responding with an error and returning false unless
+// it exists and belongs to user.
+func (cfg *apiConfig) getOwnedScheduledChirp(w http.ResponseWriter, r *http.Request, user database.User) (database.ScheduledChirp, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid chirp ID", err)
+		return database.ScheduledChirp{}, false
+	}
+
+	chirp, err := cfg.db.GetScheduledChirp(r.Context(), chirpID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find scheduled chirp", err)
+			return database.ScheduledChirp{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get scheduled chirp", err)
+		return database.ScheduledChirp{}, false
+	}
+	if chirp.UserID != user.ID {
+		respondWithError(w, http.StatusForbidden, "You can't change this chirp", nil)
+		return database.ScheduledChirp{}, false
+	}
+	return chirp, true
+}
+
+func scheduledChirpFromDB(chirp database.ScheduledChirp) Chirp {
+	return Chirp{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		UserID:    chirp.UserID,
+		Body:      chirp.Body,
+		PublishAt: &chirp.PublishAt,
+	}
+}
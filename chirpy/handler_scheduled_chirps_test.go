@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+func TestScheduledChirps(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, login := newTestUser(t, srv, "alice@example.com")
+	bob, _ := newTestUser(t, srv, "bob@example.com")
+
+	now := time.Now()
+	inAnHour := now.Add(time.Hour)
+	_, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "later", PublishAt: &inAnHour})
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("CreateChirp(publish_at) without Chirpy Red error = %v, want 403", err)
+	}
+
+	if _, err := cfg.db.UpgradeToChirpyRed(ctx, login.ID); err != nil {
+		t.Fatalf("UpgradeToChirpyRed() error = %v", err)
+	}
+	anHourAgo := now.Add(-time.Hour)
+	if _, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "too late", PublishAt: &anHourAgo}); err == nil {
+		t.Error("CreateChirp(publish_at in the past) error = nil, want 400")
+	}
+
+	scheduled, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "see you #later", PublishAt: &inAnHour})
+	if err != nil {
+		t.Fatalf("CreateChirp(publish_at) error = %v", err)
+	}
+	if scheduled.PublishAt == nil || !scheduled.PublishAt.Equal(inAnHour) {
+		t.Errorf("CreateChirp(publish_at) = %+v, want publish_at %s", scheduled, inAnHour)
+	}
+	inTwoHours := now.Add(2 * time.Hour)
+	cancelled, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "never mind", PublishAt: &inTwoHours})
+	if err != nil {
+		t.Fatalf("CreateChirp(publish_at) error = %v", err)
+	}
+
+	if chirps, _ := bob.ListChirps(ctx, nil); len(chirps) != 0 {
+		t.Errorf("GetChirps() = %+v, want scheduled chirps hidden", chirps)
+	}
+	if _, err := bob.GetChirp(ctx, scheduled.ID, nil); err == nil {
+		t.Error("GetChirp(scheduled) error = nil, want 404")
+	}
+
+	edited := "see you #later, everyone"
+	updated, err := alice.UpdateScheduledChirp(ctx, scheduled.ID, nil, client.UpdateScheduledChirpRequest{Body: edited})
+	if err != nil || updated.Body != edited {
+		t.Fatalf("UpdateScheduledChirp() = %+v, %v, want the new body", updated, err)
+	}
+	if _, err := bob.UpdateScheduledChirp(ctx, scheduled.ID, nil, client.UpdateScheduledChirpRequest{Body: "hijacked"}); err == nil {
+		t.Error("UpdateScheduledChirp() by another user error = nil, want 403")
+	}
+	if err := bob.CancelScheduledChirp(ctx, cancelled.ID, nil); err == nil {
+		t.Error("CancelScheduledChirp() by another user error = nil, want 403")
+	}
+	if err := alice.CancelScheduledChirp(ctx, cancelled.ID, nil); err != nil {
+		t.Fatalf("CancelScheduledChirp() error = %v", err)
+	}
+
+	pending, err := alice.ListScheduledChirps(ctx)
+	if err != nil || len(pending) != 1 || pending[0].ID != scheduled.ID {
+		t.Fatalf("ListScheduledChirps() = %+v, %v, want the remaining scheduled chirp", pending, err)
+	}
+
+	publisher := cfg.newChirpPublisher()
+	publisher.Now = func() time.Time { return now.Add(30 * time.Minute) }
+	if n, err := publisher.PublishDue(ctx); err != nil || n != 0 {
+		t.Fatalf("PublishDue() before publish_at = %d, %v, want nothing published", n, err)
+	}
+	publisher.Now = func() time.Time { return now.Add(3 * time.Hour) }
+	if n, err := publisher.PublishDue(ctx); err != nil || n != 1 {
+		t.Fatalf("PublishDue() after publish_at = %d, %v, want one chirp published", n, err)
+	}
+
+	chirps, err := bob.ListChirps(ctx, nil)
+	if err != nil || len(chirps) != 1 || chirps[0].ID != scheduled.ID || chirps[0].Body != edited || chirps[0].PublishAt != nil {
+		t.Fatalf("GetChirps() = %+v, %v, want the published chirp under its scheduled ID", chirps, err)
+	}
+	if tagged, _ := bob.GetHashtagChirps(ctx, "later", nil); len(tagged.Chirps) != 1 {
+		t.Errorf("GetHashtagChirps(later) = %+v, want the published chirp indexed", tagged)
+	}
+	if pending, _ := alice.ListScheduledChirps(ctx); len(pending) != 0 {
+		t.Errorf("ListScheduledChirps() after publishing = %+v, want none", pending)
+	}
+	if n, err := publisher.PublishDue(ctx); err != nil || n != 0 {
+		t.Errorf("PublishDue() again = %d, %v, want nothing left to publish", n, err)
+	}
+}
+
+func TestChirpPublisherSkipsFailuresAndSuspendedAuthors(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, bobLogin := newTestUser(t, srv, "bob@example.com")
+	for _, id := range []uuid.UUID{aliceLogin.ID, bobLogin.ID} {
+		if _, err := cfg.db.UpgradeToChirpyRed(ctx, id); err != nil {
+			t.Fatalf("UpgradeToChirpyRed() error = %v", err)
+		}
+	}
+
+	now := time.Now()
+	schedule := func(c *client.Client, body string, at time.Time) client.Chirp {
+		t.Helper()
+		chirp, err := c.CreateChirp(ctx, client.CreateChirpRequest{Body: body, PublishAt: &at})
+		if err != nil {
+			t.Fatalf("CreateChirp(publish_at) error = %v", err)
+		}
+		return chirp
+	}
+	broken := schedule(alice, "broken", now.Add(time.Hour))
+	fine := schedule(alice, "fine", now.Add(2*time.Hour))
+	suspended := schedule(bob, "held back", now.Add(time.Hour))
+
+	// A chirp already published under the broken one's ID makes its
+	// publishing fail.
+	_, err := cfg.db.PublishScheduledChirp(ctx, database.PublishScheduledChirpParams{
+		ID:        broken.ID,
+		CreatedAt: now,
+		Body:      "squatter",
+		UserID:    aliceLogin.ID,
+	})
+	if err != nil {
+		t.Fatalf("PublishScheduledChirp() error = %v", err)
+	}
+	_, err = cfg.db.SuspendUser(ctx, database.SuspendUserParams{
+		ID:             bobLogin.ID,
+		SuspendedUntil: sql.NullTime{Time: now.Add(24 * time.Hour), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("SuspendUser() error = %v", err)
+	}
+
+	publisher := cfg.newChirpPublisher()
+	publisher.Now = func() time.Time { return now.Add(3 * time.Hour) }
+	if n, err := publisher.PublishDue(ctx); err != nil || n != 1 {
+		t.Fatalf("PublishDue() = %d, %v, want only the fine chirp published", n, err)
+	}
+	if _, err := cfg.db.GetChirp(ctx, fine.ID); err != nil {
+		t.Errorf("GetChirp(fine) error = %v, want it published", err)
+	}
+	if _, err := cfg.db.GetChirp(ctx, suspended.ID); err == nil {
+		t.Errorf("GetChirp(held back) error = nil, want it unpublished while bob is suspended")
+	}
+	failed, err := cfg.db.GetScheduledChirp(ctx, broken.ID)
+	if err != nil || !failed.FailedAt.Valid {
+		t.Fatalf("GetScheduledChirp(broken) = %+v, %v, want it marked failed", failed, err)
+	}
+	if n, err := publisher.PublishDue(ctx); err != nil || n != 0 {
+		t.Errorf("PublishDue() again = %d, %v, want the failure left alone", n, err)
+	}
+
+	// Once the suspension is over bob's chirp goes out, and the broken
+	// one is tried, and fails, again.
+	publisher.Now = func() time.Time { return now.Add(48 * time.Hour) }
+	if n, err := publisher.PublishDue(ctx); err != nil || n != 1 {
+		t.Fatalf("PublishDue() after the suspension = %d, %v, want bob's chirp published", n, err)
+	}
+	if _, err := cfg.db.GetChirp(ctx, suspended.ID); err != nil {
+		t.Errorf("GetChirp(held back) error = %v, want it published", err)
+	}
+	if retried, _ := cfg.db.GetScheduledChirp(ctx, broken.ID); !retried.FailedAt.Time.After(failed.FailedAt.Time) {
+		t.Errorf("broken chirp failed_at = %v, want it retried after %v", retried.FailedAt, failed.FailedAt)
+	}
+}
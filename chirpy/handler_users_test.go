@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+func TestUsersEmailTaken(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t)
+	alice := client.New(srv.URL)
+	aliceCreds := client.Credentials{Email: "alice@example.com", Password: "password"}
+	for _, creds := range []client.Credentials{aliceCreds, {Email: "bob@example.com", Password: "password"}} {
+		if _, err := alice.CreateUser(ctx, creds); err != nil {
+			t.Fatalf("CreateUser(%s) error = %v", creds.Email, err)
+		}
+	}
+	login, err := alice.Login(ctx, aliceCreds)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	alice.AccessToken = login.Token
+
+	_, err = alice.CreateUser(ctx, aliceCreds)
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("CreateUser() with a taken email error = %v, want a 409", err)
+	}
+
+	_, err = alice.UpdateUser(ctx, nil, client.UpdateUserRequest{Email: "bob@example.com"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("UpdateUser() with a taken email error = %v, want a 409", err)
+	}
+}
@@ -1,64 +1,159 @@
 package main
 
 import (
-	"encoding/json"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"unicode/utf8"
 
-	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
 	"github.com/lsherman98/boot.dev/chirpy/internal/database"
 )
 
-func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request) {
+func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, r *http.Request, user database.User) {
+	// Every field is optional; omitted fields are left unchanged. An
+	// empty handle removes the user's handle.
 	type parameters struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password    *string `json:"password"`
+		Email       *string `json:"email"`
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarURL   *string `json:"avatar_url"`
 	}
 	type response struct {
 		User
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
-		return
-	}
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		respondWithRequestError(w, err)
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	params := parameters{}
-	err = decoder.Decode(&params)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+	v := validation{}
+	if params.Email != nil {
+		v.requireEmail("email", *params.Email)
+	}
+	if params.Password != nil {
+		v.require("password", *params.Password)
+	}
+	if params.Handle != nil && *params.Handle != "" {
+		if problem := database.CheckHandle(*params.Handle); problem != "" {
+			v.add("handle", problem)
+		}
+	}
+	if params.DisplayName != nil && utf8.RuneCountInString(*params.DisplayName) > database.MaxDisplayNameLength {
+		v.add("display_name", fmt.Sprintf("must be at most %d characters", database.MaxDisplayNameLength))
+	}
+	if params.Bio != nil && utf8.RuneCountInString(*params.Bio) > database.MaxBioLength {
+		v.add("bio", fmt.Sprintf("must be at most %d characters", database.MaxBioLength))
+	}
+	if params.AvatarURL != nil && *params.AvatarURL != "" {
+		v.requireURL("avatar_url", *params.AvatarURL)
+	}
+	if params == (parameters{}) {
+		v.add("body", "must set at least one field")
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(params.Password)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
-		return
+	hashedPassword := ""
+	if params.Password != nil {
+		hashedPassword, err = cfg.passwordHashing.Hash(*params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't hash password", err)
+			return
+		}
 	}
 
-	user, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
-		ID:             userID,
-		Email:          params.Email,
-		HashedPassword: hashedPassword,
+	var currentETag string
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		user, err = tx.GetUserByIDForUpdate(r.Context(), user.ID)
+		if err != nil {
+			return err
+		}
+		currentETag = resourceETag(user.ID, user.UpdatedAt)
+		if !ifMatch(r, currentETag) {
+			return errPreconditionFailed
+		}
+		before := user
+
+		if params.Email != nil || params.Password != nil {
+			arg := database.UpdateUserParams{
+				ID:             user.ID,
+				Email:          user.Email,
+				HashedPassword: user.HashedPassword,
+			}
+			if params.Email != nil {
+				arg.Email = *params.Email
+			}
+			if params.Password != nil {
+				arg.HashedPassword = hashedPassword
+			}
+			user, err = tx.UpdateUser(r.Context(), arg)
+			if err != nil {
+				return err
+			}
+		}
+
+		if params.Handle != nil || params.DisplayName != nil || params.Bio != nil || params.AvatarURL != nil {
+			arg := database.UpdateUserProfileParams{
+				ID:          user.ID,
+				Handle:      user.Handle,
+				DisplayName: user.DisplayName,
+				Bio:         user.Bio,
+				AvatarUrl:   user.AvatarUrl,
+			}
+			if params.Handle != nil {
+				arg.Handle = sql.NullString{String: *params.Handle, Valid: *params.Handle != ""}
+			}
+			if params.DisplayName != nil {
+				arg.DisplayName = *params.DisplayName
+			}
+			if params.Bio != nil {
+				arg.Bio = *params.Bio
+			}
+			if params.AvatarURL != nil {
+				arg.AvatarUrl = *params.AvatarURL
+			}
+			user, err = tx.UpdateUserProfile(r.Context(), arg)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writeAudit(r, tx, auditRecord{
+			Action:     auditUserUpdated,
+			ActorID:    user.ID,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID.String(),
+			Diff:       userDiff(before, user),
+		})
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
+		case errors.Is(err, errPreconditionFailed):
+			respondPreconditionFailed(w, currentETag)
+		case errors.Is(err, database.ErrEmailTaken):
+			respondWithError(w, http.StatusConflict, "Email is already in use", err)
+		case errors.Is(err, database.ErrHandleTaken):
+			respondWithError(w, http.StatusConflict, "Handle is already in use", err)
+		case errors.Is(err, database.ErrInvalidProfile):
+			respondWithError(w, http.StatusBadRequest, "Invalid profile", err)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		}
 		return
 	}
 
+	w.Header().Set("ETag", resourceETag(user.ID, user.UpdatedAt))
 	respondWithJSON(w, http.StatusOK, response{
-		User: User{
-			ID:          user.ID,
-			CreatedAt:   user.CreatedAt,
-			UpdatedAt:   user.UpdatedAt,
-			Email:       user.Email,
-			IsChirpyRed: user.IsChirpyRed,
-		},
+		User: userFromDB(user),
 	})
 }
@@ -2,12 +2,13 @@ package main
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/webhooks"
 )
 
 func (cfg *apiConfig) handlerWebhook(w http.ResponseWriter, r *http.Request) {
@@ -15,7 +16,7 @@ func (cfg *apiConfig) handlerWebhook(w http.ResponseWriter, r *http.Request) {
 		Event string `json:"event"`
 		Data  struct {
 			UserID uuid.UUID `json:"user_id"`
-		}
+		} `json:"data"`
 	}
 
 	apiKey, err := auth.GetAPIKey(r.Header)
@@ -28,11 +29,19 @@ func (cfg *apiConfig) handlerWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
+	// Polka sends its payloads as it likes, so it isn't held to our own
+	// clients' rules.
 	params := parameters{}
-	err = decoder.Decode(&params)
+	err = decodeLenientJSON(w, r, &params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	v.require("event", params.Event)
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
 		return
 	}
 
@@ -41,7 +50,33 @@ func (cfg *apiConfig) handlerWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = cfg.db.UpgradeToChirpyRed(r.Context(), params.Data.UserID)
+	err = cfg.db.InTx(r.Context(), func(tx database.Store) error {
+		before, err := tx.GetUserByIDForUpdate(r.Context(), params.Data.UserID)
+		if err != nil {
+			return err
+		}
+		user, err := tx.UpgradeToChirpyRed(r.Context(), params.Data.UserID)
+		if err != nil {
+			return err
+		}
+		err = writeAudit(r, tx, auditRecord{
+			Action:     auditUserUpgraded,
+			TargetType: auditTargetUser,
+			TargetID:   user.ID.String(),
+			Diff:       userDiff(before, user),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.CreateSubscriptionEvent(r.Context(), database.CreateSubscriptionEventParams{
+			UserID: user.ID,
+			Event:  params.Event,
+		})
+		if err != nil {
+			return err
+		}
+		return webhooks.Enqueue(r.Context(), tx, webhooks.EventUserUpgraded, user.ID, userFromDB(user))
+	})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			respondWithError(w, http.StatusNotFound, "Couldn't find user", err)
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/netguard"
+	"github.com/lsherman98/boot.dev/chirpy/internal/webhooks"
+)
+
+type Webhook struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Global    bool      `json:"global"`
+	// Secret is only included when the endpoint is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID            uuid.UUID        `json:"id"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	EventID       uuid.UUID        `json:"event_id"`
+	EventType     string           `json:"event_type"`
+	Payload       json.RawMessage  `json:"payload"`
+	Status        string           `json:"status"`
+	NextAttemptAt *time.Time       `json:"next_attempt_at,omitempty"`
+	Attempts      []WebhookAttempt `json:"attempts"`
+}
+
+type WebhookAttempt struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	StatusCode *int      `json:"status_code,omitempty"`
+	// ResponseBody is only shown to admins, so endpoints can't be used
+	// to read responses from servers their owners can't reach.
+	ResponseBody string `json:"response_body,omitempty"`
+	Error        string `json:"error,omitempty"`
+	DurationMs   int    `json:"duration_ms"`
+}
+
+func (cfg *apiConfig) handlerWebhookEndpointsCreate(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Global bool     `json:"global"`
+	}
+
+	params := parameters{}
+	err := decodeJSON(w, r, &params)
+	if err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	v := validation{}
+	v.requireURL("url", params.URL)
+	if len(params.Events) == 0 {
+		v.add("events", "is required")
+	}
+	for i, event := range params.Events {
+		if !webhooks.IsEventType(event) {
+			v.add(fmt.Sprintf("events[%d]", i), "must be one of "+strings.Join(webhooks.EventTypes, ", "))
+		}
+	}
+	if err := v.err(); err != nil {
+		respondWithRequestError(w, err)
+		return
+	}
+
+	checkURL := cfg.checkWebhookURL
+	if checkURL == nil {
+		checkURL = webhooks.CheckURL
+	}
+	if err := checkURL(r.Context(), params.URL); err != nil {
+		if errors.Is(err, netguard.ErrBlockedAddress) {
+			v.add("url", "must not lead to a private or internal address")
+		} else {
+			v.add("url", "must have a host that resolves")
+		}
+		respondWithRequestError(w, v.err())
+		return
+	}
+
+	if params.Global && !user.IsAdmin {
+		respondWithError(w, http.StatusForbidden, "Only admins can create global webhooks", nil)
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create webhook secret", err)
+		return
+	}
+
+	endpoint, err := cfg.db.CreateWebhookEndpoint(r.Context(), database.CreateWebhookEndpointParams{
+		UserID:   user.ID,
+		Url:      params.URL,
+		Secret:   secret,
+		Events:   params.Events,
+		IsGlobal: params.Global,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create webhook", err)
+		return
+	}
+
+	resp := webhookEndpointFromDB(endpoint)
+	resp.Secret = endpoint.Secret
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (cfg *apiConfig) handlerWebhookEndpointsList(w http.ResponseWriter, r *http.Request, user database.User) {
+	dbEndpoints, err := cfg.db.GetWebhookEndpointsByUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhooks", err)
+		return
+	}
+
+	endpoints := []Webhook{}
+	for _, endpoint := range dbEndpoints {
+		endpoints = append(endpoints, webhookEndpointFromDB(endpoint))
+	}
+
+	respondWithJSON(w, http.StatusOK, endpoints)
+}
+
+func (cfg *apiConfig) handlerWebhookEndpointsDelete(w http.ResponseWriter, r *http.Request, user database.User) {
+	endpoint, ok := cfg.getOwnedWebhookEndpoint(w, r, user)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteWebhookEndpoint(r.Context(), endpoint.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete webhook", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerWebhookDeliveriesList(w http.ResponseWriter, r *http.Request, user database.User) {
+	const defaultLimit, maxLimit = 50, 100
+
+	limit := defaultLimit
+	if limitString := r.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxLimit {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxLimit), err)
+			return
+		}
+	}
+
+	endpoint, ok := cfg.getOwnedWebhookEndpoint(w, r, user)
+	if !ok {
+		return
+	}
+
+	dbDeliveries, err := cfg.db.GetWebhookDeliveries(r.Context(), database.GetWebhookDeliveriesParams{
+		EndpointID: endpoint.ID,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhook deliveries", err)
+		return
+	}
+
+	deliveryIDs := make([]uuid.UUID, 0, len(dbDeliveries))
+	for _, delivery := range dbDeliveries {
+		deliveryIDs = append(deliveryIDs, delivery.ID)
+	}
+	dbAttempts, err := cfg.db.GetWebhookAttempts(r.Context(), deliveryIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve webhook attempts", err)
+		return
+	}
+	attempts := map[uuid.UUID][]WebhookAttempt{}
+	for _, attempt := range dbAttempts {
+		resp := webhookAttemptFromDB(attempt)
+		if !user.IsAdmin {
+			resp.ResponseBody = ""
+		}
+		attempts[attempt.DeliveryID] = append(attempts[attempt.DeliveryID], resp)
+	}
+
+	deliveries := []WebhookDelivery{}
+	for _, delivery := range dbDeliveries {
+		resp := WebhookDelivery{
+			ID:        delivery.ID,
+			CreatedAt: delivery.CreatedAt,
+			UpdatedAt: delivery.UpdatedAt,
+			EventID:   delivery.EventID,
+			EventType: delivery.EventType,
+			Payload:   json.RawMessage(delivery.Payload),
+			Status:    delivery.Status,
+			Attempts:  attempts[delivery.ID],
+		}
+		if resp.Attempts == nil {
+			resp.Attempts = []WebhookAttempt{}
+		}
+		if delivery.Status == webhooks.StatusPending {
+			resp.NextAttemptAt = &delivery.NextAttemptAt
+		}
+		deliveries = append(deliveries, resp)
+	}
+
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// getOwnedWebhookEndpoint looks up the endpoint named by the webhookID
+// path value and checks that user owns it or is an admin. It responds
+// with an error and returns false if not.
+func (cfg *apiConfig) getOwnedWebhookEndpoint(w http.ResponseWriter, r *http.Request, user database.User) (database.WebhookEndpoint, bool) {
+	webhookID, err := uuid.Parse(r.PathValue("webhookID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook ID", err)
+		return database.WebhookEndpoint{}, false
+	}
+
+	endpoint, err := cfg.db.GetWebhookEndpoint(r.Context(), webhookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusNotFound, "Couldn't find webhook", err)
+			return database.WebhookEndpoint{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get webhook", err)
+		return database.WebhookEndpoint{}, false
+	}
+	if endpoint.UserID != user.ID && !user.IsAdmin {
+		respondWithError(w, http.StatusForbidden, "You can't access this webhook", nil)
+		return database.WebhookEndpoint{}, false
+	}
+	return endpoint, true
+}
+
+func webhookEndpointFromDB(endpoint database.WebhookEndpoint) Webhook {
+	return Webhook{
+		ID:        endpoint.ID,
+		CreatedAt: endpoint.CreatedAt,
+		UpdatedAt: endpoint.UpdatedAt,
+		UserID:    endpoint.UserID,
+		URL:       endpoint.Url,
+		Events:    endpoint.Events,
+		Global:    endpoint.IsGlobal,
+	}
+}
+
+func webhookAttemptFromDB(attempt database.WebhookAttempt) WebhookAttempt {
+	resp := WebhookAttempt{
+		ID:           attempt.ID,
+		CreatedAt:    attempt.CreatedAt,
+		ResponseBody: attempt.ResponseBody,
+		Error:        attempt.Error,
+		DurationMs:   int(attempt.DurationMs),
+	}
+	if attempt.StatusCode.Valid {
+		statusCode := int(attempt.StatusCode.Int32)
+		resp.StatusCode = &statusCode
+	}
+	return resp
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/webhooks"
+)
+
+func TestWebhookEndpoints(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, aliceLogin := newTestUser(t, srv, "alice@example.com")
+	bob, _ := newTestUser(t, srv, "bob@example.com")
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("queued"))
+	}))
+	defer receiver.Close()
+
+	_, err := alice.CreateWebhook(ctx, client.CreateWebhookRequest{
+		URL:    "http://169.254.169.254/latest/meta-data",
+		Events: []string{webhooks.EventChirpCreated},
+	})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || len(apiErr.Details) != 1 || apiErr.Details[0].Field != "url" {
+		t.Fatalf("CreateWebhook() with an internal address error = %v, want a url field error", err)
+	}
+	// The receiver is on loopback, which is refused everywhere else.
+	cfg.checkWebhookURL = func(context.Context, string) error { return nil }
+
+	_, err = alice.CreateWebhook(ctx, client.CreateWebhookRequest{
+		URL:    receiver.URL,
+		Events: []string{webhooks.EventChirpCreated},
+		Global: true,
+	})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("CreateWebhook() global as a non-admin error = %v, want a 403", err)
+	}
+
+	_, err = alice.CreateWebhook(ctx, client.CreateWebhookRequest{URL: "ftp://example.com", Events: []string{"chirp.liked"}})
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || len(apiErr.Details) != 2 {
+		t.Fatalf("CreateWebhook() with a bad url and event error = %v, want 2 field errors", err)
+	}
+
+	webhook, err := alice.CreateWebhook(ctx, client.CreateWebhookRequest{
+		URL:    receiver.URL,
+		Events: []string{webhooks.EventChirpCreated, webhooks.EventChirpDeleted},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook() error = %v", err)
+	}
+	if webhook.Secret == "" {
+		t.Errorf("CreateWebhook() returned no secret")
+	}
+	listed, err := alice.ListWebhooks(ctx)
+	if err != nil || len(listed) != 1 || listed[0].Secret != "" {
+		t.Fatalf("ListWebhooks() = %+v, %v, want the webhook without its secret", listed, err)
+	}
+
+	if _, err := bob.CreateChirp(ctx, client.CreateChirpRequest{Body: "not for alice"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if _, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "hello"}); err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	worker := webhooks.NewWorker(cfg.db)
+	worker.HTTPClient = receiver.Client()
+	if n, err := worker.DeliverDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverDue() = %d, %v, want only alice's chirp delivered", n, err)
+	}
+
+	deliveries, err := alice.ListWebhookDeliveries(ctx, webhook.ID, nil)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("ListWebhookDeliveries() = %+v, %v, want 1 delivery", deliveries, err)
+	}
+	delivery := deliveries[0]
+	if delivery.EventType != webhooks.EventChirpCreated || delivery.Status != webhooks.StatusDelivered {
+		t.Errorf("delivery = %+v, want a delivered %s", delivery, webhooks.EventChirpCreated)
+	}
+	if len(delivery.Attempts) != 1 || delivery.Attempts[0].StatusCode != http.StatusAccepted {
+		t.Fatalf("delivery attempts = %+v, want one 202", delivery.Attempts)
+	}
+	if body := delivery.Attempts[0].ResponseBody; body != "" {
+		t.Errorf("attempt response body shown to a non-admin = %q, want it hidden", body)
+	}
+
+	_, err = bob.ListWebhookDeliveries(ctx, webhook.ID, nil)
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("ListWebhookDeliveries() by another user error = %v, want a 403", err)
+	}
+
+	if _, err := cfg.db.SetUserAdmin(ctx, database.SetUserAdminParams{ID: aliceLogin.ID, IsAdmin: true}); err != nil {
+		t.Fatalf("SetUserAdmin() error = %v", err)
+	}
+	deliveries, err = alice.ListWebhookDeliveries(ctx, webhook.ID, nil)
+	if err != nil || len(deliveries) != 1 || len(deliveries[0].Attempts) != 1 || deliveries[0].Attempts[0].ResponseBody != "queued" {
+		t.Errorf("ListWebhookDeliveries() as an admin = %+v, %v, want the response body", deliveries, err)
+	}
+	global, err := alice.CreateWebhook(ctx, client.CreateWebhookRequest{
+		URL:    receiver.URL,
+		Events: []string{webhooks.EventUserUpgraded},
+		Global: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook() global as an admin error = %v", err)
+	}
+
+	polka := client.New(srv.URL)
+	polka.APIKey = cfg.polkaKey
+	bobUser, err := cfg.db.GetUserByEmail(ctx, "bob@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail() error = %v", err)
+	}
+	err = polka.PolkaWebhook(ctx, client.PolkaWebhookRequest{
+		Event: "user.upgraded",
+		Data:  client.PolkaWebhookData{UserID: bobUser.ID},
+	})
+	if err != nil {
+		t.Fatalf("PolkaWebhook() error = %v", err)
+	}
+	deliveries, err = alice.ListWebhookDeliveries(ctx, global.ID, nil)
+	if err != nil || len(deliveries) != 1 || deliveries[0].Status != webhooks.StatusPending {
+		t.Fatalf("ListWebhookDeliveries() = %+v, %v, want bob's upgrade queued", deliveries, err)
+	}
+
+	if err := alice.DeleteWebhook(ctx, webhook.ID); err != nil {
+		t.Fatalf("DeleteWebhook() error = %v", err)
+	}
+	_, err = alice.ListWebhookDeliveries(ctx, webhook.ID, nil)
+	if apiErr := (*client.APIError)(nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("ListWebhookDeliveries() after delete error = %v, want a 404", err)
+	}
+}
@@ -11,7 +11,6 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type TokenType string
@@ -24,18 +23,12 @@ const (
 // ErrNoAuthHeaderIncluded -
 var ErrNoAuthHeaderIncluded = errors.New("no auth header included in request")
 
-// HashPassword -
-func HashPassword(password string) (string, error) {
-	dat, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(dat), nil
-}
-
-// CheckPasswordHash -
-func CheckPasswordHash(password, hash string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+// accessClaims are the claims of an access token. AuthTime is when the
+// user signed in, and is only set on the token issued then: tokens from
+// a refresh leave it out.
+type accessClaims struct {
+	jwt.RegisteredClaims
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 }
 
 // MakeJWT -
@@ -44,46 +37,71 @@ func MakeJWT(
 	tokenSecret string,
 	expiresIn time.Duration,
 ) (string, error) {
+	return makeJWT(userID, tokenSecret, expiresIn, nil)
+}
+
+// MakeSignInJWT is MakeJWT for the access token issued when the user
+// signs in. It records the time in its auth_time claim, so handlers can
+// ask for a recent sign-in with SignedInSince.
+func MakeSignInJWT(
+	userID uuid.UUID,
+	tokenSecret string,
+	expiresIn time.Duration,
+) (string, error) {
+	return makeJWT(userID, tokenSecret, expiresIn, jwt.NewNumericDate(time.Now().UTC()))
+}
+
+func makeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration, authTime *jwt.NumericDate) (string, error) {
 	signingKey := []byte(tokenSecret)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    string(TokenTypeAccess),
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    string(TokenTypeAccess),
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject:   userID.String(),
+		},
+		AuthTime: authTime,
 	})
 	return token.SignedString(signingKey)
 }
 
 // ValidateJWT -
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claimsStruct := jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(
-		tokenString,
-		&claimsStruct,
-		func(token *jwt.Token) (interface{}, error) { return []byte(tokenSecret), nil },
-	)
+	claims, err := validateJWT(tokenString, tokenSecret)
 	if err != nil {
 		return uuid.Nil, err
 	}
-
-	userIDString, err := token.Claims.GetSubject()
+	id, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
 	}
+	return id, nil
+}
 
-	issuer, err := token.Claims.GetIssuer()
-	if err != nil {
-		return uuid.Nil, err
-	}
-	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+// SignedInSince reports whether a valid access token was issued by a
+// sign-in at or after t. Refreshed tokens never were.
+func SignedInSince(tokenString, tokenSecret string, t time.Time) bool {
+	claims, err := validateJWT(tokenString, tokenSecret)
+	if err != nil || claims.AuthTime == nil {
+		return false
 	}
+	return !claims.AuthTime.Time.Before(t.Truncate(time.Second))
+}
 
-	id, err := uuid.Parse(userIDString)
+func validateJWT(tokenString, tokenSecret string) (accessClaims, error) {
+	claims := accessClaims{}
+	_, err := jwt.ParseWithClaims(
+		tokenString,
+		&claims,
+		func(token *jwt.Token) (interface{}, error) { return []byte(tokenSecret), nil },
+	)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return accessClaims{}, err
 	}
-	return id, nil
+	if claims.Issuer != string(TokenTypeAccess) {
+		return accessClaims{}, errors.New("invalid issuer")
+	}
+	return claims, nil
 }
 
 // GetBearerToken -
@@ -111,6 +111,33 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+func TestSignedInSince(t *testing.T) {
+	userID := uuid.New()
+	signIn, _ := MakeSignInJWT(userID, "secret", time.Hour)
+	refreshed, _ := MakeJWT(userID, "secret", time.Hour)
+
+	tests := []struct {
+		name        string
+		tokenString string
+		tokenSecret string
+		since       time.Time
+		want        bool
+	}{
+		{"Fresh sign-in", signIn, "secret", time.Now().Add(-time.Minute), true},
+		{"Old sign-in", signIn, "secret", time.Now().Add(time.Minute), false},
+		{"Refreshed token", refreshed, "secret", time.Now().Add(-time.Minute), false},
+		{"Wrong secret", signIn, "wrong_secret", time.Now().Add(-time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignedInSince(tt.tokenString, tt.tokenSecret, tt.since); got != tt.want {
+				t.Errorf("SignedInSince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashes are stored in the PHC string format, which names the
+// algorithm and its version and parameters, so hashes made with older
+// algorithms or weaker parameters can be recognized and replaced:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+//
+// Hashes made before Argon2id was introduced are bcrypt hashes
+// ($2a$...). CheckPasswordHash still verifies them.
+
+// UnsetPassword is the hashed password of accounts created before users
+// had passwords. No password matches it; the user has to reset it.
+const UnsetPassword = "unset"
+
+var (
+	// ErrPasswordMismatch is returned when a password doesn't match its hash.
+	ErrPasswordMismatch = errors.New("password does not match")
+	// ErrPasswordNotSet is returned when checking a password against
+	// UnsetPassword.
+	ErrPasswordNotSet = errors.New("password has not been set")
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+	argon2SaltLen  = 16
+	argon2KeyLen   = 32
+)
+
+// Argon2idParams are the tunable costs of Argon2id.
+type Argon2idParams struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultArgon2idParams are the second recommended option of RFC 9106,
+// for environments that can't spare 2 GiB per hash.
+var DefaultArgon2idParams = Argon2idParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 4}
+
+// ParseArgon2idParams parses parameters written like the middle of a PHC
+// string, such as "m=65536,t=3,p=4".
+func ParseArgon2idParams(s string) (Argon2idParams, error) {
+	var p Argon2idParams
+	if _, err := fmt.Sscanf(s, "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return Argon2idParams{}, fmt.Errorf("argon2id parameters %q must look like m=65536,t=3,p=4", s)
+	}
+	if p.String() != s {
+		return Argon2idParams{}, fmt.Errorf("argon2id parameters %q must look like m=65536,t=3,p=4", s)
+	}
+	if err := p.validate(); err != nil {
+		return Argon2idParams{}, err
+	}
+	return p, nil
+}
+
+func (p Argon2idParams) String() string {
+	return fmt.Sprintf("m=%d,t=%d,p=%d", p.Memory, p.Iterations, p.Parallelism)
+}
+
+func (p Argon2idParams) validate() error {
+	if p.Iterations < 1 || p.Parallelism < 1 {
+		return errors.New("argon2id iterations and parallelism must be at least 1")
+	}
+	if p.Memory < 8*uint32(p.Parallelism) {
+		return errors.New("argon2id memory must be at least 8 KiB per lane of parallelism")
+	}
+	return nil
+}
+
+// orDefault returns DefaultArgon2idParams for the zero value.
+func (p Argon2idParams) orDefault() Argon2idParams {
+	if p == (Argon2idParams{}) {
+		return DefaultArgon2idParams
+	}
+	return p
+}
+
+// HashPassword hashes password with Argon2id and DefaultArgon2idParams.
+func HashPassword(password string) (string, error) {
+	return DefaultArgon2idParams.Hash(password)
+}
+
+// Hash hashes password with Argon2id using p, or DefaultArgon2idParams
+// if p is the zero value.
+func (p Argon2idParams) Hash(password string) (string, error) {
+	p = p.orDefault()
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$%s$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		p,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// NeedsRehash reports whether hash was made with another algorithm or
+// other parameters than Hash would use now. Call it after
+// CheckPasswordHash succeeds, while the password is at hand.
+func (p Argon2idParams) NeedsRehash(hash string) bool {
+	if hash == UnsetPassword {
+		return false
+	}
+	version, params, _, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return version != argon2.Version || params != p.orDefault() || len(key) != argon2KeyLen
+}
+
+// CheckPasswordHash returns nil if password matches hash, which may be an
+// Argon2id or a bcrypt hash.
+func CheckPasswordHash(password, hash string) error {
+	switch {
+	case hash == UnsetPassword:
+		return ErrPasswordNotSet
+	case strings.HasPrefix(hash, argon2idPrefix):
+		version, params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return err
+		}
+		if version != argon2.Version {
+			return fmt.Errorf("unsupported argon2 version %d", version)
+		}
+		got := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	case strings.HasPrefix(hash, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrPasswordMismatch
+		}
+		return err
+	default:
+		return errors.New("unrecognized password hash format")
+	}
+}
+
+func decodeArgon2id(hash string) (version int, params Argon2idParams, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return 0, Argon2idParams{}, nil, nil, errors.New("malformed argon2id hash")
+	}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return 0, Argon2idParams{}, nil, nil, errors.New("malformed argon2id hash version")
+	}
+	params, err = ParseArgon2idParams(parts[3])
+	if err != nil {
+		return 0, Argon2idParams{}, nil, nil, err
+	}
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return 0, Argon2idParams{}, nil, nil, errors.New("malformed argon2id salt")
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return 0, Argon2idParams{}, nil, nil, errors.New("malformed argon2id key")
+	}
+	return version, params, salt, key, nil
+}
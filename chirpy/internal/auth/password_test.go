@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// cheapParams keep the tests fast.
+var cheapParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func TestHashPasswordFormat(t *testing.T) {
+	hash, err := cheapParams.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash() = %q, want a PHC argon2id string with its parameters", hash)
+	}
+	if err := CheckPasswordHash("password", hash); err != nil {
+		t.Errorf("CheckPasswordHash() error = %v", err)
+	}
+	if err := CheckPasswordHash("Password", hash); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("CheckPasswordHash() with the wrong password error = %v, want ErrPasswordMismatch", err)
+	}
+}
+
+func TestCheckPasswordHashBcrypt(t *testing.T) {
+	dat, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPasswordHash("password", string(dat)); err != nil {
+		t.Errorf("CheckPasswordHash() error = %v", err)
+	}
+	if err := CheckPasswordHash("wrong", string(dat)); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("CheckPasswordHash() with the wrong password error = %v, want ErrPasswordMismatch", err)
+	}
+}
+
+func TestCheckPasswordHashUnset(t *testing.T) {
+	for _, password := range []string{"", "unset"} {
+		if err := CheckPasswordHash(password, UnsetPassword); !errors.Is(err, ErrPasswordNotSet) {
+			t.Errorf("CheckPasswordHash(%q, UnsetPassword) error = %v, want ErrPasswordNotSet", password, err)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current, _ := cheapParams.Hash("password")
+	weaker, _ := Argon2idParams{Memory: 32, Iterations: 1, Parallelism: 1}.Hash("password")
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"current parameters", current, false},
+		{"other parameters", weaker, true},
+		{"bcrypt", string(legacy), true},
+		{"unset", UnsetPassword, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cheapParams.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgon2idParams(t *testing.T) {
+	p, err := ParseArgon2idParams("m=65536,t=3,p=4")
+	if err != nil || p != DefaultArgon2idParams {
+		t.Errorf("ParseArgon2idParams() = %+v, %v, want DefaultArgon2idParams", p, err)
+	}
+	for _, s := range []string{"", "m=65536", "m=65536,t=0,p=4", "m=16,t=1,p=4", "t=3,m=65536,p=4", "m=65536,t=3,p=4,x=1"} {
+		if _, err := ParseArgon2idParams(s); err == nil {
+			t.Errorf("ParseArgon2idParams(%q) error = nil, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Package chirptext finds @mentions, #hashtags and links in chirp
+// bodies.
+//
+// Mentions and hashtags are recognized at the start of the body or after a character that
+// can't be part of a word, so email addresses and URL fragments in the
+// middle of a word are left alone. Letters and digits from any script
+// count as word characters.
+package chirptext
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxHashtagLength is the longest hashtag, in characters, that is
+// recognized. Longer runs are ignored rather than truncated.
+const MaxHashtagLength = 100
+
+// Hashtags returns the normalized hashtags in body, without their '#',
+// in the order they first appear.
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for i, r := range body {
+		if r != '#' || !atBoundary(body, i) {
+			continue
+		}
+		tag := takeWhile(body[i+1:], isHashtagRune)
+		if !validHashtag(tag) {
+			continue
+		}
+		tag = NormalizeHashtag(tag)
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// NormalizeHashtag returns the form a hashtag is stored and looked up
+// in: without a leading '#', NFC normalized and lowercased, so #Go and
+// #go match, as do accented letters typed precomposed or combining.
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(norm.NFC.String(strings.TrimPrefix(tag, "#")))
+}
+
+// ValidHashtag reports whether tag, with or without its '#', is a
+// hashtag that Hashtags would find.
+func ValidHashtag(tag string) bool {
+	tag = strings.TrimPrefix(tag, "#")
+	return takeWhile(tag, isHashtagRune) == tag && validHashtag(tag)
+}
+
+func validHashtag(tag string) bool {
+	if tag == "" || utf8.RuneCountInString(tag) > MaxHashtagLength {
+		return false
+	}
+	// All-digit tags like #1 are usually numbering, not topics.
+	return strings.IndexFunc(tag, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '_'
+	}) >= 0
+}
+
+// Mention is an @mention of a user by email or by handle.
+type Mention struct {
+	// Text is what followed the '@'.
+	Text string
+	// IsEmail is true if Text is an email address rather than a handle.
+	IsEmail bool
+}
+
+// Mentions returns the mentions in body in the order they first appear.
+func Mentions(body string) []Mention {
+	mentions := []Mention{}
+	seen := map[string]bool{}
+	for i, r := range body {
+		if r != '@' || !atBoundary(body, i) {
+			continue
+		}
+		mention, ok := parseMention(body[i+1:])
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(norm.NFC.String(mention.Text))
+		if !seen[key] {
+			seen[key] = true
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions
+}
+
+func parseMention(s string) (Mention, bool) {
+	text := takeWhile(s, func(r rune) bool {
+		return isWordRune(r) || strings.ContainsRune(".-+@", r)
+	})
+	// Sentence punctuation right after a mention isn't part of it.
+	text = strings.TrimRight(text, ".-+@")
+
+	local, domain, isEmail := strings.Cut(text, "@")
+	if isEmail {
+		if local == "" || strings.Contains(domain, "@") || !strings.Contains(domain, ".") ||
+			strings.HasPrefix(domain, ".") || strings.Contains(domain, "..") {
+			return Mention{}, false
+		}
+		return Mention{Text: text, IsEmail: true}, true
+	}
+
+	handle := takeWhile(text, isWordRune)
+	if handle == "" {
+		return Mention{}, false
+	}
+	return Mention{Text: handle}, true
+}
+
+// Links returns the http and https URLs in body, normalized and
+// without fragments, in the order they first appear. A URL runs to the
+// next space; punctuation ending a sentence after it isn't part of it.
+func Links(body string) []string {
+	links := []string{}
+	seen := map[string]bool{}
+	for i := 0; i < len(body); i++ {
+		rest := strings.ToLower(body[i:min(len(body), i+len("https://"))])
+		if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") {
+			continue
+		}
+		if !atBoundary(body, i) {
+			continue
+		}
+		raw := takeWhile(body[i:], func(r rune) bool {
+			return !unicode.IsSpace(r)
+		})
+		i += len(raw) - 1
+		link, ok := parseLink(raw)
+		if ok && !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+func parseLink(raw string) (string, bool) {
+	raw = strings.TrimRight(raw, ".,;:!?'\"")
+	// Keep the closing parenthesis of URLs like Wikipedia's, but not one
+	// closing a parenthesis the URL was written in.
+	for strings.HasSuffix(raw, ")") && strings.Count(raw, ")") > strings.Count(raw, "(") {
+		raw = strings.TrimRight(strings.TrimSuffix(raw, ")"), ".,;:!?'\"")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), true
+}
+
+// atBoundary reports whether the character at byte offset i in s starts a
+// new word.
+func atBoundary(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !isWordRune(prev) && prev != '@' && prev != '#' && prev != '&'
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Mc, r) || r == '_'
+}
+
+func isHashtagRune(r rune) bool {
+	// U+200D ZERO WIDTH JOINER holds together emoji sequences and
+	// conjuncts in some scripts, so it doesn't end a hashtag.
+	return isWordRune(r) || r == '\u200d'
+}
+
+// takeWhile returns the longest prefix of s whose runes all satisfy f.
+func takeWhile(s string, f func(rune) bool) string {
+	for i, r := range s {
+		if !f(r) {
+			return s[:i]
+		}
+	}
+	return s
+}
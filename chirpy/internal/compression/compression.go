@@ -0,0 +1,248 @@
+// Package compression negotiates a content coding from Accept-Encoding
+// and compresses HTTP responses with it.
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings, in the order the server prefers them when a client
+// accepts several equally.
+const (
+	Zstd = "zstd"
+	Gzip = "gzip"
+)
+
+// minSize is the smallest response worth compressing, when the handler
+// says how big its response is.
+const minSize = 512
+
+// Negotiate returns the coding from offered that acceptEncoding, an
+// Accept-Encoding header value, ranks highest, with ties going to
+// whichever comes first in offered. It returns "" if the client accepts
+// none of them.
+func Negotiate(acceptEncoding string, offered ...string) string {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range offered {
+		q, ok := qualities[coding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// Compressible reports whether responses of the given Content-Type are
+// worth compressing. Images other than SVG, archives and the like are
+// already compressed, and event streams must reach the client as soon as
+// they are flushed.
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson", "application/javascript",
+		"application/xml", "application/wasm":
+		return true
+	}
+	return false
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		return gzip.NewWriter(nil)
+	}}
+	zstdWriters = sync.Pool{New: func() any {
+		// One goroutine per response is plenty for API-sized bodies.
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithLowerEncoderMem(true))
+		return w
+	}}
+)
+
+// encoder is the part of *gzip.Writer and *zstd.Encoder the middleware
+// uses.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// Middleware compresses the responses of next with the coding the
+// request's Accept-Encoding header prefers, if any, unless the response
+// is already encoded, partial, empty or of a type Compressible rejects.
+//
+// A compressed response is a different representation from the
+// uncompressed one, so its ETag gets the coding appended, turning "abc"
+// into "abc-gzip". Handlers comparing validators should pass the ETags
+// clients send through TrimCoding first.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		coding := Negotiate(r.Header.Get("Accept-Encoding"), Zstd, Gzip)
+		if coding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &responseWriter{ResponseWriter: w, coding: coding, ifNoneMatch: r.Header.Get("If-None-Match")}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// responseWriter decides whether to compress when the header is written
+// and, if it does, sends the body through an encoder.
+type responseWriter struct {
+	http.ResponseWriter
+	coding      string
+	ifNoneMatch string
+	enc         encoder
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.shouldCompress(status) {
+		h := w.Header()
+		h.Set("Content-Encoding", w.coding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+		if etag := h.Get("ETag"); etag != "" {
+			h.Set("ETag", withCoding(etag, w.coding))
+		}
+		if w.coding == Zstd {
+			w.enc = zstdWriters.Get().(*zstd.Encoder)
+		} else {
+			w.enc = gzipWriters.Get().(*gzip.Writer)
+		}
+		w.enc.Reset(w.ResponseWriter)
+	}
+	if status == http.StatusNotModified {
+		// A 304 has no body to compress, so which representation the
+		// client has is only known from the ETag it sent.
+		h := w.Header()
+		if etag := withCoding(h.Get("ETag"), w.coding); etag != "" && strings.Contains(w.ifNoneMatch, etag) {
+			h.Set("ETag", etag)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// withCoding appends the coding to the opaque part of a strong or weak
+// ETag. Anything that isn't a quoted ETag is returned as it is.
+func withCoding(etag, coding string) string {
+	if len(etag) < 2 || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + coding + `"`
+}
+
+// TrimCoding removes the coding Middleware appends to the ETags of
+// compressed responses, so an ETag a client received in either
+// representation can be compared with the one a handler computed.
+func TrimCoding(etag string) string {
+	for _, coding := range []string{Zstd, Gzip} {
+		if trimmed, ok := strings.CutSuffix(etag, "-"+coding+`"`); ok {
+			return trimmed + `"`
+		}
+	}
+	return etag
+}
+
+func (w *responseWriter) shouldCompress(status int) bool {
+	h := w.Header()
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < minSize {
+		return false
+	}
+	return Compressible(h.Get("Content-Type"))
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.enc == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.enc.Write(b)
+}
+
+// Flush sends everything written so far to the client, so streaming
+// handlers work through the middleware.
+func (w *responseWriter) Flush() {
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) close() {
+	if w.enc == nil {
+		return
+	}
+	w.enc.Close()
+	w.enc.Reset(nil)
+	if w.coding == Zstd {
+		zstdWriters.Put(w.enc)
+	} else {
+		gzipWriters.Put(w.enc)
+	}
+	w.enc = nil
+}
@@ -0,0 +1,120 @@
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", Gzip},
+		{"gzip, zstd", Zstd},
+		{"gzip;q=1, zstd;q=0.5", Gzip},
+		{"zstd;q=0, gzip;q=0", ""},
+		{"*", Zstd},
+		{"br, *;q=0.1, zstd;q=0", Gzip},
+		{"identity", ""},
+	}
+	for _, tc := range tests {
+		if got := Negotiate(tc.header, Zstd, Gzip); got != tc.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	body := strings.Repeat(`{"body":"hello"}`, 100)
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") != "" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			io.WriteString(w, body)
+		case "/png":
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, body)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	get := func(path, acceptEncoding string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	for _, coding := range []string{Gzip, Zstd} {
+		resp := get("/json", coding)
+		if got := resp.Header.Get("Content-Encoding"); got != coding {
+			t.Fatalf("Content-Encoding = %q, want %q", got, coding)
+		}
+		var r io.Reader
+		if coding == Gzip {
+			zr, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r = zr
+		} else {
+			zr, err := zstd.NewReader(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zr.Close()
+			r = zr
+		}
+		got, err := io.ReadAll(r)
+		if err != nil || string(got) != body {
+			t.Errorf("%s body = %q, %v, want the original body", coding, got, err)
+		}
+		etag := resp.Header.Get("ETag")
+		if want := `"v1-` + coding + `"`; etag != want {
+			t.Errorf("%s ETag = %q, want %q", coding, etag, want)
+		}
+		if TrimCoding(etag) != `"v1"` {
+			t.Errorf("TrimCoding(%q) = %q, want %q", etag, TrimCoding(etag), `"v1"`)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/json", nil)
+		req.Header.Set("Accept-Encoding", coding)
+		req.Header.Set("If-None-Match", etag)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotModified || rec.Header().Get("ETag") != etag {
+			t.Errorf("revalidating %s = %d with ETag %q, want a 304 with %q", coding, rec.Code, rec.Header().Get("ETag"), etag)
+		}
+	}
+
+	if resp := get("/json", ""); resp.Header.Get("Content-Encoding") != "" || resp.Header.Get("ETag") != `"v1"` {
+		t.Errorf("without Accept-Encoding Content-Encoding = %q, ETag %q, want none and the ETag unchanged", resp.Header.Get("Content-Encoding"), resp.Header.Get("ETag"))
+	}
+	if resp := get("/png", "gzip"); resp.Header.Get("Content-Encoding") != "" {
+		t.Errorf("Content-Encoding for a PNG = %q, want none", resp.Header.Get("Content-Encoding"))
+	}
+	if resp := get("/empty", "gzip"); resp.Header.Get("Content-Encoding") != "" || resp.StatusCode != http.StatusNoContent {
+		t.Errorf("204 response = %d with Content-Encoding %q, want an unencoded 204", resp.StatusCode, resp.Header.Get("Content-Encoding"))
+	}
+	if resp := get("/json", "gzip"); resp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", resp.Header.Get("Vary"))
+	}
+}
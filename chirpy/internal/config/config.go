@@ -0,0 +1,455 @@
+// Package config loads chirpy's settings from the environment, a .env
+// file, an optional YAML or TOML file and command-line flags, and checks
+// them all at once.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is chirpy's configuration. Each field is set by the environment
+// variable in its env tag. In config files the key is the variable's
+// name in lower case, and the flag is that with dashes, so DB_URL is
+// db_url and -db-url.
+//
+// Fields tagged secret can instead be read from the file named by the
+// variable with _FILE appended, such as JWT_SECRET_FILE, which suits
+// secrets Kubernetes mounts as files.
+type Config struct {
+	Port         string `env:"PORT" default:"8080" usage:"port to listen on"`
+	FilepathRoot string `env:"FILEPATH_ROOT" default:"." usage:"directory the web app under /app/ is served from"`
+	StaticEmbed  bool   `env:"STATIC_EMBED" usage:"serve the web app built into the binary instead of FILEPATH_ROOT"`
+
+	Platform  string `env:"PLATFORM" required:"true" usage:"where chirpy runs; \"dev\" enables POST /admin/reset and logs password reset tokens"`
+	JWTSecret string `env:"JWT_SECRET" required:"true" secret:"true" usage:"key access tokens are signed with"`
+	PolkaKey  string `env:"POLKA_KEY" required:"true" secret:"true" usage:"API key Polka's webhooks authenticate with"`
+
+	DBDriver string `env:"DB_DRIVER" default:"postgres" oneof:"postgres json" usage:"storage backend"`
+	DBURL    string `env:"DB_URL" secret:"true" usage:"Postgres connection string, for DB_DRIVER=postgres"`
+	DBPath   string `env:"DB_PATH" default:"database.json" usage:"database file, for DB_DRIVER=json"`
+
+	DBReplicaURL      string        `env:"DB_REPLICA_URL" secret:"true" usage:"Postgres connection string of a read replica GET requests read from"`
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"25" usage:"most connections open to each database; 0 is unlimited"`
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"25" usage:"most idle connections kept open to each database"`
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"30m" usage:"how long a connection is reused before it is replaced; 0 reuses it forever"`
+	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"5m" usage:"how long an idle connection is kept; 0 keeps it forever"`
+	DBConnectTimeout  time.Duration `env:"DB_CONNECT_TIMEOUT" default:"30s" usage:"how long startup keeps retrying an unreachable database"`
+
+	PasswordHashing auth.Argon2idParams `env:"PASSWORD_HASHING" default:"m=65536,t=3,p=4" usage:"Argon2id memory in KiB, iterations and parallelism for password hashes"`
+
+	OIDCProvider     string   `env:"OIDC_PROVIDER" default:"oidc" usage:"name of the identity provider in its /api/auth/{provider}/ routes"`
+	OIDCIssuer       string   `env:"OIDC_ISSUER" usage:"issuer URL of an OpenID Connect provider users can sign in with"`
+	OIDCClientID     string   `env:"OIDC_CLIENT_ID" usage:"client ID registered with the OpenID Connect provider"`
+	OIDCClientSecret string   `env:"OIDC_CLIENT_SECRET" secret:"true" usage:"client secret registered with the OpenID Connect provider, if it issued one"`
+	OIDCRedirectURL  string   `env:"OIDC_REDIRECT_URL" usage:"chirpy's callback URL registered with the provider, ending in /api/auth/{provider}/callback"`
+	OIDCScopes       []string `env:"OIDC_SCOPES" default:"email,profile" usage:"comma-separated scopes to request besides openid"`
+
+	AdminEmails []string `env:"ADMIN_EMAILS" usage:"comma-separated emails of existing users made admins at startup"`
+
+	AccountDeletion string `env:"ACCOUNT_DELETION" default:"delete" oneof:"delete anonymize" usage:"what DELETE /api/users/me does with the account"`
+	RateLimitStore  string `env:"RATE_LIMIT_STORE" default:"memory" oneof:"memory postgres" usage:"where rate limit buckets are kept; postgres shares them between replicas"`
+	EventBus        string `env:"EVENT_BUS" default:"memory" oneof:"memory postgres" usage:"how chirp events reach streams; postgres relays them between replicas"`
+
+	CORSAllowedOrigins   []string      `env:"CORS_ALLOWED_ORIGINS" usage:"comma-separated origins browsers may call the API from, or *"`
+	CORSAllowCredentials bool          `env:"CORS_ALLOW_CREDENTIALS" usage:"let browsers send cookies with cross-origin requests"`
+	CORSMaxAge           time.Duration `env:"CORS_MAX_AGE" default:"10m" usage:"how long browsers may cache preflight responses"`
+	HSTSMaxAge           time.Duration `env:"HSTS_MAX_AGE" usage:"Strict-Transport-Security max-age; 0 leaves the header out"`
+
+	TrustedProxies []netip.Prefix `env:"TRUSTED_PROXIES" usage:"comma-separated IPs or CIDR ranges of proxies whose X-Forwarded-For names the client"`
+}
+
+// configFileEnv names the optional YAML or TOML config file. The -config
+// flag overrides it.
+const configFileEnv = "CHIRPY_CONFIG"
+
+// Load reads the configuration. Later sources override earlier ones:
+// defaults, the config file, .env, the environment and then args, the
+// command-line flags without the program name. It reports every invalid
+// or missing setting in one error.
+func Load(args []string) (*Config, error) {
+	return load(args, os.LookupEnv, ".env")
+}
+
+func load(args []string, lookupEnv func(string) (string, bool), dotenvPath string) (*Config, error) {
+	fields := configFields()
+
+	values := map[string]setting{}
+	for _, f := range fields {
+		if f.def != "" {
+			values[f.env] = setting{f.def, fromDefault}
+		}
+	}
+
+	flagValues, configPath, err := parseFlags(args, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	dotenv, err := godotenv.Read(dotenvPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", dotenvPath, err)
+	}
+	env := func(key string) (string, bool) {
+		if v, ok := lookupEnv(key); ok {
+			return v, true
+		}
+		v, ok := dotenv[key]
+		return v, ok
+	}
+
+	if configPath == "" {
+		configPath, _ = env(configFileEnv)
+	}
+	var errs []error
+	if configPath != "" {
+		fileValues, err := readFile(configPath, fields)
+		if err != nil {
+			return nil, err
+		}
+		for key, v := range fileValues {
+			values[key] = setting{v, fromFile}
+		}
+	}
+
+	for _, f := range fields {
+		for _, key := range f.keys() {
+			if v, ok := env(key); ok {
+				values[key] = setting{v, fromEnv}
+			}
+			if v, ok := flagValues[key]; ok {
+				values[key] = setting{v, fromFlag}
+			}
+		}
+	}
+
+	cfg := &Config{}
+	rv := reflect.ValueOf(cfg).Elem()
+	for _, f := range fields {
+		raw, err := f.resolve(values)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := f.set(rv.Field(f.index), raw); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		errs = append(errs, cfg.validate()...)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// validate checks the rules that involve more than one field.
+func (cfg *Config) validate() []error {
+	var errs []error
+	if cfg.DBDriver == "postgres" && cfg.DBURL == "" {
+		errs = append(errs, errors.New("DB_URL: must be set when DB_DRIVER is postgres"))
+	}
+	if cfg.DBReplicaURL != "" && cfg.DBDriver != "postgres" {
+		errs = append(errs, errors.New("DB_REPLICA_URL: requires DB_DRIVER=postgres"))
+	}
+	if cfg.DBMaxOpenConns > 0 && cfg.DBMaxIdleConns > cfg.DBMaxOpenConns {
+		errs = append(errs, errors.New("DB_MAX_IDLE_CONNS: can't be more than DB_MAX_OPEN_CONNS"))
+	}
+	if cfg.RateLimitStore == "postgres" && cfg.DBDriver != "postgres" {
+		errs = append(errs, errors.New("RATE_LIMIT_STORE: postgres requires DB_DRIVER=postgres"))
+	}
+	if cfg.EventBus == "postgres" && cfg.DBDriver != "postgres" {
+		errs = append(errs, errors.New("EVENT_BUS: postgres requires DB_DRIVER=postgres"))
+	}
+	if cfg.OIDCIssuer != "" {
+		if cfg.OIDCClientID == "" {
+			errs = append(errs, errors.New("OIDC_CLIENT_ID: must be set when OIDC_ISSUER is"))
+		}
+		if cfg.OIDCRedirectURL == "" {
+			errs = append(errs, errors.New("OIDC_REDIRECT_URL: must be set when OIDC_ISSUER is"))
+		}
+	}
+	if cfg.CORSAllowCredentials && slices.Contains(cfg.CORSAllowedOrigins, "*") {
+		errs = append(errs, errors.New("CORS_ALLOW_CREDENTIALS: can't be combined with CORS_ALLOWED_ORIGINS=*"))
+	}
+	return errs
+}
+
+// Sources of settings, from lowest precedence to highest.
+const (
+	fromDefault = iota
+	fromFile
+	fromEnv
+	fromFlag
+)
+
+// setting is a raw value and the source it came from.
+type setting struct {
+	value  string
+	source int
+}
+
+// field describes one Config field.
+type field struct {
+	index    int
+	env      string
+	def      string
+	usage    string
+	required bool
+	secret   bool
+	oneOf    []string
+	kind     reflect.Type
+}
+
+func configFields() []field {
+	t := reflect.TypeOf(Config{})
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		f := field{
+			index:    i,
+			env:      sf.Tag.Get("env"),
+			def:      sf.Tag.Get("default"),
+			usage:    sf.Tag.Get("usage"),
+			required: sf.Tag.Get("required") == "true",
+			secret:   sf.Tag.Get("secret") == "true",
+			kind:     sf.Type,
+		}
+		if oneOf := sf.Tag.Get("oneof"); oneOf != "" {
+			f.oneOf = strings.Fields(oneOf)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// keys returns the variable names the field can be set with.
+func (f field) keys() []string {
+	if f.secret {
+		return []string{f.env, f.env + "_FILE"}
+	}
+	return []string{f.env}
+}
+
+// resolve returns the field's raw value from values. For secrets, the
+// _FILE variable's file is read instead if that variable comes from a
+// higher-precedence source.
+func (f field) resolve(values map[string]setting) (string, error) {
+	direct, hasDirect := values[f.env]
+	raw := direct.value
+	if file, ok := values[f.env+"_FILE"]; f.secret && ok && file.value != "" {
+		if hasDirect && direct.value != "" && direct.source >= file.source {
+			if direct.source == file.source {
+				return "", fmt.Errorf("%s: set either %s or %s_FILE, not both", f.env, f.env, f.env)
+			}
+			return raw, nil
+		}
+		dat, err := os.ReadFile(file.value)
+		if err != nil {
+			return "", fmt.Errorf("%s_FILE: %w", f.env, err)
+		}
+		raw = strings.TrimRight(string(dat), "\r\n")
+	}
+	if raw == "" && f.required {
+		return "", fmt.Errorf("%s: must be set", f.env)
+	}
+	if raw != "" && f.oneOf != nil && !slices.Contains(f.oneOf, raw) {
+		return "", fmt.Errorf("%s: must be one of %s, not %q", f.env, strings.Join(f.oneOf, ", "), raw)
+	}
+	return raw, nil
+}
+
+// set parses raw into v.
+func (f field) set(v reflect.Value, raw string) error {
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(raw)
+	case bool:
+		if raw == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s: must be true or false, not %q", f.env, raw)
+		}
+		v.SetBool(b)
+	case int:
+		if raw == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%s: must be a whole number, not %q", f.env, raw)
+		}
+		v.SetInt(int64(n))
+	case auth.Argon2idParams:
+		params, err := auth.ParseArgon2idParams(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.env, err)
+		}
+		v.Set(reflect.ValueOf(params))
+	case time.Duration:
+		if raw == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil || d < 0 {
+			return fmt.Errorf("%s: must be a duration like 10m or 1h, not %q", f.env, raw)
+		}
+		v.Set(reflect.ValueOf(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	case []netip.Prefix:
+		var prefixes []netip.Prefix
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			prefix, err := parsePrefix(item)
+			if err != nil {
+				return fmt.Errorf("%s: %q isn't an IP address or CIDR range", f.env, item)
+			}
+			prefixes = append(prefixes, prefix)
+		}
+		v.Set(reflect.ValueOf(prefixes))
+	default:
+		panic("config: unsupported field type " + f.kind.String())
+	}
+	return nil
+}
+
+// parsePrefix parses a CIDR range, or a single address as the range
+// holding just it.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// flagName returns the command-line flag for a variable name.
+func flagName(env string) string {
+	return strings.ReplaceAll(strings.ToLower(env), "_", "-")
+}
+
+// parseFlags parses args, returning the values of the flags that were
+// set keyed by variable name, and the -config flag.
+func parseFlags(args []string, fields []field) (map[string]string, string, error) {
+	fset := flag.NewFlagSet("chirpy", flag.ContinueOnError)
+	configPath := fset.String("config", "", "YAML or TOML config file (env "+configFileEnv+")")
+	byFlag := map[string]string{}
+	for _, f := range fields {
+		for _, key := range f.keys() {
+			usage := f.usage
+			if key != f.env {
+				usage = "file to read " + f.env + " from"
+			}
+			if f.def != "" && key == f.env {
+				usage += " (default " + f.def + ")"
+			}
+			fset.String(flagName(key), "", usage+" (env "+key+")")
+			byFlag[flagName(key)] = key
+		}
+	}
+	if err := fset.Parse(args); err != nil {
+		return nil, "", err
+	}
+	if fset.NArg() > 0 {
+		return nil, "", fmt.Errorf("unexpected arguments: %s", strings.Join(fset.Args(), " "))
+	}
+
+	values := map[string]string{}
+	fset.Visit(func(fl *flag.Flag) {
+		if key, ok := byFlag[fl.Name]; ok {
+			values[key] = fl.Value.String()
+		}
+	})
+	return values, *configPath, nil
+}
+
+// readFile reads a YAML or TOML config file, chosen by its extension,
+// returning its settings keyed by variable name.
+func readFile(path string, fields []field) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	defer f.Close()
+
+	raw := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.NewDecoder(f).Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		_, err = toml.NewDecoder(f).Decode(&raw)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported extension %q, use .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	known := map[string]bool{}
+	for _, f := range fields {
+		for _, key := range f.keys() {
+			known[key] = true
+		}
+	}
+	values := map[string]string{}
+	var errs []error
+	for key, v := range raw {
+		name := strings.ToUpper(key)
+		if !known[name] {
+			errs = append(errs, fmt.Errorf("config file %s: unknown setting %q", path, key))
+			continue
+		}
+		values[name] = fileValue(v)
+	}
+	return values, errors.Join(errs...)
+}
+
+// fileValue formats a decoded YAML or TOML value the way it would be
+// written in an environment variable.
+func fileValue(v any) string {
+	list, ok := v.([]any)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		items = append(items, fmt.Sprint(item))
+	}
+	return strings.Join(items, ",")
+}
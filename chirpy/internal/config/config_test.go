@@ -0,0 +1,220 @@
+package config
+
+import (
+	"maps"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+)
+
+// envOf returns a lookupEnv function reading from env.
+func envOf(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var minimalEnv = map[string]string{
+	"PLATFORM":   "dev",
+	"JWT_SECRET": "jwt",
+	"POLKA_KEY":  "polka",
+	"DB_URL":     "postgres://localhost/chirpy",
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(nil, envOf(minimalEnv), filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.Port != "8080" || cfg.FilepathRoot != "." || cfg.DBDriver != "postgres" || cfg.AccountDeletion != "delete" {
+		t.Errorf("load() = %+v, want defaults", cfg)
+	}
+	if cfg.PasswordHashing != auth.DefaultArgon2idParams {
+		t.Errorf("PasswordHashing = %v, want %v", cfg.PasswordHashing, auth.DefaultArgon2idParams)
+	}
+	if cfg.CORSMaxAge != 10*time.Minute || cfg.HSTSMaxAge != 0 {
+		t.Errorf("CORSMaxAge, HSTSMaxAge = %v, %v, want 10m, 0", cfg.CORSMaxAge, cfg.HSTSMaxAge)
+	}
+	if cfg.DBMaxOpenConns != 25 || cfg.DBMaxIdleConns != 25 || cfg.DBConnMaxLifetime != 30*time.Minute || cfg.DBConnectTimeout != 30*time.Second {
+		t.Errorf("pool settings = %d, %d, %v, %v, want 25, 25, 30m, 30s", cfg.DBMaxOpenConns, cfg.DBMaxIdleConns, cfg.DBConnMaxLifetime, cfg.DBConnectTimeout)
+	}
+}
+
+func TestLoadReportsEveryError(t *testing.T) {
+	env := map[string]string{
+		"DB_DRIVER":         "mysql",
+		"ACCOUNT_DELETION":  "shred",
+		"STATIC_EMBED":      "maybe",
+		"CORS_MAX_AGE":      "soon",
+		"PASSWORD_HASHING":  "m=1,t=1",
+		"DB_MAX_OPEN_CONNS": "-1",
+		"DB_MAX_IDLE_CONNS": "lots",
+		"TRUSTED_PROXIES":   "10.0.0.0/8,proxy",
+	}
+	_, err := load(nil, envOf(env), filepath.Join(t.TempDir(), ".env"))
+	if err == nil {
+		t.Fatal("load() error = nil, want an error")
+	}
+	for _, name := range []string{"PLATFORM", "JWT_SECRET", "POLKA_KEY", "DB_DRIVER", "ACCOUNT_DELETION", "STATIC_EMBED", "CORS_MAX_AGE", "PASSWORD_HASHING", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "TRUSTED_PROXIES"} {
+		if !strings.Contains(err.Error(), name+":") {
+			t.Errorf("load() error = %q, want it to mention %s", err, name)
+		}
+	}
+}
+
+func TestLoadTrustedProxies(t *testing.T) {
+	env := maps.Clone(minimalEnv)
+	env["TRUSTED_PROXIES"] = " 10.0.0.0/8, 192.0.2.1,,::ffff:198.51.100.7"
+	cfg, err := load(nil, envOf(env), filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.1/32"),
+		netip.MustParsePrefix("198.51.100.7/32"),
+	}
+	if !slices.Equal(cfg.TrustedProxies, want) {
+		t.Errorf("TrustedProxies = %v, want %v", cfg.TrustedProxies, want)
+	}
+}
+
+func TestLoadValidatesCombinations(t *testing.T) {
+	env := map[string]string{
+		"PLATFORM":               "dev",
+		"JWT_SECRET":             "jwt",
+		"POLKA_KEY":              "polka",
+		"DB_DRIVER":              "json",
+		"EVENT_BUS":              "postgres",
+		"CORS_ALLOWED_ORIGINS":   "*",
+		"CORS_ALLOW_CREDENTIALS": "true",
+		"DB_REPLICA_URL":         "postgres://replica/chirpy",
+		"DB_MAX_OPEN_CONNS":      "5",
+		"DB_MAX_IDLE_CONNS":      "10",
+	}
+	_, err := load(nil, envOf(env), filepath.Join(t.TempDir(), ".env"))
+	if err == nil {
+		t.Fatal("load() error = nil, want an error")
+	}
+	for _, name := range []string{"EVENT_BUS", "CORS_ALLOW_CREDENTIALS", "DB_REPLICA_URL", "DB_MAX_IDLE_CONNS"} {
+		if !strings.Contains(err.Error(), name+":") {
+			t.Errorf("load() error = %q, want it to mention %s", err, name)
+		}
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	configFile := writeFile(t, "chirpy.yaml", "port: 7000\nfilepath_root: /srv/file\nplatform: file\ndb_path: file.json\n")
+	dotenv := writeFile(t, ".env", "PORT=7001\nFILEPATH_ROOT=/srv/dotenv\nCHIRPY_CONFIG="+configFile+"\n")
+	env := map[string]string{
+		"JWT_SECRET": "jwt",
+		"POLKA_KEY":  "polka",
+		"DB_URL":     "postgres://localhost/chirpy",
+		"PORT":       "7002",
+	}
+
+	cfg, err := load([]string{"-port", "7003"}, envOf(env), dotenv)
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.Port != "7003" {
+		t.Errorf("Port = %q, want the flag's 7003", cfg.Port)
+	}
+	if cfg.FilepathRoot != "/srv/dotenv" {
+		t.Errorf("FilepathRoot = %q, want .env's /srv/dotenv", cfg.FilepathRoot)
+	}
+	if cfg.Platform != "file" || cfg.DBPath != "file.json" {
+		t.Errorf("Platform, DBPath = %q, %q, want the config file's", cfg.Platform, cfg.DBPath)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	configFile := writeFile(t, "chirpy.toml", `
+platform = "dev"
+jwt_secret = "jwt"
+polka_key = "polka"
+db_driver = "json"
+static_embed = true
+hsts_max_age = "8760h"
+cors_allowed_origins = ["https://a.example.com", "https://b.example.com"]
+`)
+	cfg, err := load([]string{"-config", configFile}, envOf(nil), filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if !cfg.StaticEmbed || cfg.HSTSMaxAge != 8760*time.Hour || cfg.DBDriver != "json" {
+		t.Errorf("load() = %+v, want the config file's settings", cfg)
+	}
+	want := []string{"https://a.example.com", "https://b.example.com"}
+	if !slices.Equal(cfg.CORSAllowedOrigins, want) {
+		t.Errorf("CORSAllowedOrigins = %v, want %v", cfg.CORSAllowedOrigins, want)
+	}
+}
+
+func TestLoadRejectsUnknownFileSettings(t *testing.T) {
+	configFile := writeFile(t, "chirpy.yml", "admin_key: secret\n")
+	_, err := load([]string{"-config", configFile}, envOf(minimalEnv), filepath.Join(t.TempDir(), ".env"))
+	if err == nil || !strings.Contains(err.Error(), "admin_key") {
+		t.Errorf("load() error = %v, want it to name admin_key", err)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	secretFile := writeFile(t, "jwt_secret", "from-file\n")
+	env := map[string]string{
+		"PLATFORM":        "dev",
+		"JWT_SECRET_FILE": secretFile,
+		"POLKA_KEY":       "polka",
+		"DB_DRIVER":       "json",
+	}
+
+	cfg, err := load(nil, envOf(env), filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.JWTSecret != "from-file" {
+		t.Errorf("JWTSecret = %q, want the file's contents without the newline", cfg.JWTSecret)
+	}
+
+	// A flag outranks the environment's _FILE variable.
+	cfg, err = load([]string{"-jwt-secret", "from-flag"}, envOf(env), filepath.Join(t.TempDir(), ".env"))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.JWTSecret != "from-flag" {
+		t.Errorf("JWTSecret = %q, want from-flag", cfg.JWTSecret)
+	}
+
+	env["JWT_SECRET"] = "from-env"
+	if _, err := load(nil, envOf(env), filepath.Join(t.TempDir(), ".env")); err == nil {
+		t.Error("load() with JWT_SECRET and JWT_SECRET_FILE error = nil, want an error")
+	}
+
+	delete(env, "JWT_SECRET")
+	env["JWT_SECRET_FILE"] = filepath.Join(t.TempDir(), "missing")
+	if _, err := load(nil, envOf(env), filepath.Join(t.TempDir(), ".env")); err == nil || !strings.Contains(err.Error(), "JWT_SECRET_FILE") {
+		t.Errorf("load() error = %v, want a JWT_SECRET_FILE error", err)
+	}
+}
+
+func TestLoadRejectsExtraArguments(t *testing.T) {
+	if _, err := load([]string{"serve"}, envOf(minimalEnv), filepath.Join(t.TempDir(), ".env")); err == nil {
+		t.Error("load() error = nil, want an error")
+	}
+}
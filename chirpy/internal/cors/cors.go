@@ -0,0 +1,143 @@
+// Package cors implements Cross-Origin Resource Sharing, so browser
+// clients served from other origins can call the API.
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is a CORS policy.
+type Config struct {
+	// AllowedOrigins lists the origins, such as https://example.com, that
+	// may make cross-origin requests. "*" allows any origin.
+	AllowedOrigins []string
+	// AllowCredentials lets browsers send cookies and HTTP auth with
+	// cross-origin requests and read the responses. It can't be combined
+	// with "*" in AllowedOrigins.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+	// AllowedMethods and AllowedHeaders are what preflight requests may
+	// ask for.
+	AllowedMethods []string
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers, beyond the CORS-safelisted
+	// ones, that scripts may read.
+	ExposedHeaders []string
+}
+
+// CORS applies a Config to requests.
+type CORS struct {
+	cfg            Config
+	anyOrigin      bool
+	methods        string
+	headers        string
+	exposed        string
+	maxAge         string
+	allowedHeaders map[string]bool
+}
+
+// New checks cfg and returns a CORS that applies it.
+func New(cfg Config) (*CORS, error) {
+	c := &CORS{
+		cfg:            cfg,
+		anyOrigin:      slices.Contains(cfg.AllowedOrigins, "*"),
+		methods:        strings.Join(cfg.AllowedMethods, ", "),
+		headers:        strings.Join(cfg.AllowedHeaders, ", "),
+		exposed:        strings.Join(cfg.ExposedHeaders, ", "),
+		allowedHeaders: map[string]bool{},
+	}
+	if c.anyOrigin && cfg.AllowCredentials {
+		return nil, errors.New("cors: credentials can't be allowed for every origin")
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		if origin != "*" && (strings.HasSuffix(origin, "/") || !strings.Contains(origin, "://")) {
+			return nil, errors.New("cors: allowed origin " + strconv.Quote(origin) + " must be a scheme and host, like https://example.com")
+		}
+	}
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+	for _, h := range cfg.AllowedHeaders {
+		c.allowedHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+	return c, nil
+}
+
+// Handler adds CORS headers to the responses of next for allowed origins
+// and answers preflight requests itself.
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !c.originAllowed(origin) {
+			if preflight {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			// Serve the response without CORS headers; the browser won't
+			// let the page read it.
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if c.exposed != "" {
+				h.Set("Access-Control-Expose-Headers", c.exposed)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		method := r.Header.Get("Access-Control-Request-Method")
+		if !slices.Contains(c.cfg.AllowedMethods, method) {
+			http.Error(w, "method not allowed", http.StatusForbidden)
+			return
+		}
+		for _, requested := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			requested = http.CanonicalHeaderKey(strings.TrimSpace(requested))
+			if requested != "" && !c.allowedHeaders[requested] {
+				http.Error(w, "header "+requested+" not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		h.Set("Access-Control-Allow-Methods", c.methods)
+		if c.headers != "" {
+			h.Set("Access-Control-Allow-Headers", c.headers)
+		}
+		if c.maxAge != "" {
+			h.Set("Access-Control-Max-Age", c.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c *CORS) originAllowed(origin string) bool {
+	return c.anyOrigin || slices.Contains(c.cfg.AllowedOrigins, origin)
+}
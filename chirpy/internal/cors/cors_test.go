@@ -0,0 +1,93 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowedOrigins: []string{"example.com"}},
+		{AllowedOrigins: []string{"https://example.com/"}},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) error = nil, want an error", cfg)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	c, err := New(Config{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"ETag"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	handler := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	serve := func(method, origin string, header http.Header) *http.Response {
+		req := httptest.NewRequest(method, "/api/chirps", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	resp := serve(http.MethodOptions, "https://app.example.com", http.Header{
+		"Access-Control-Request-Method":  {"POST"},
+		"Access-Control-Request-Headers": {"authorization, content-type"},
+	})
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("preflight status = %d, want 204", resp.StatusCode)
+	}
+	for key, want := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST",
+		"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+		"Access-Control-Max-Age":           "600",
+	} {
+		if got := resp.Header.Get(key); got != want {
+			t.Errorf("preflight %s = %q, want %q", key, got, want)
+		}
+	}
+
+	for name, header := range map[string]http.Header{
+		"method": {"Access-Control-Request-Method": {"DELETE"}},
+		"header": {"Access-Control-Request-Method": {"GET"}, "Access-Control-Request-Headers": {"X-Secret"}},
+	} {
+		if resp := serve(http.MethodOptions, "https://app.example.com", header); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("preflight with a disallowed %s = %d, want 403", name, resp.StatusCode)
+		}
+	}
+	if resp := serve(http.MethodOptions, "https://evil.example.com", http.Header{"Access-Control-Request-Method": {"GET"}}); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("preflight from a disallowed origin = %d, want 403", resp.StatusCode)
+	}
+
+	resp = serve(http.MethodGet, "https://app.example.com", nil)
+	if resp.StatusCode != http.StatusTeapot || resp.Header.Get("Access-Control-Allow-Origin") != "https://app.example.com" || resp.Header.Get("Access-Control-Expose-Headers") != "ETag" {
+		t.Errorf("GET from an allowed origin = %d %v, want CORS headers", resp.StatusCode, resp.Header)
+	}
+	resp = serve(http.MethodGet, "https://evil.example.com", nil)
+	if resp.StatusCode != http.StatusTeapot || resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("GET from a disallowed origin = %d %v, want no CORS headers", resp.StatusCode, resp.Header)
+	}
+	if resp := serve(http.MethodGet, "", nil); resp.Header.Get("Vary") != "" {
+		t.Errorf("same-origin GET Vary = %q, want none", resp.Header.Get("Vary"))
+	}
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Backoff between pings of a database that isn't answering yet.
+const (
+	minPingBackoff = 500 * time.Millisecond
+	maxPingBackoff = 10 * time.Second
+)
+
+// PoolConfig sizes a connection pool. The fields are passed to the
+// sql.DB methods of the same names, so zero means unlimited, except for
+// MaxIdleConns where it means none.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// OpenPostgres opens a pool of connections to the database at url and
+// pings it until it answers or ctx is done, backing off between tries,
+// so chirpy can start before its database is up.
+func OpenPostgres(ctx context.Context, url string, pool PoolConfig) (*sql.DB, error) {
+	conn, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(pool.MaxOpenConns)
+	conn.SetMaxIdleConns(pool.MaxIdleConns)
+	conn.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	conn.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+
+	if err := pingWithRetry(ctx, conn.PingContext, minPingBackoff); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// pingWithRetry calls ping until it succeeds or ctx is done, doubling
+// the wait between calls from backoff up to maxPingBackoff.
+func pingWithRetry(ctx context.Context, ping func(context.Context) error, backoff time.Duration) error {
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		}
+		log.Printf("Couldn't reach the database, retrying in %s: %s", backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxPingBackoff)
+	}
+}
+
+type replicaReadsKey struct{}
+
+// WithReplicaReads returns a context in which a Postgres store with a
+// read replica reads from the replica. Replicas lag behind the primary,
+// so it suits requests that only read and can miss a write made just
+// before. Once a query in the context writes, later reads go to the
+// primary so they see the write.
+func WithReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, &atomic.Bool{})
+}
+
+// ReadFromPrimary sends the reads made in ctx from now on to the primary,
+// as if ctx had written. It does nothing to contexts that don't come from
+// WithReplicaReads.
+func ReadFromPrimary(ctx context.Context) {
+	if wrote, ok := ctx.Value(replicaReadsKey{}).(*atomic.Bool); ok {
+		wrote.Store(true)
+	}
+}
+
+// ReadsFromReplica reports whether reads made in ctx go to a replica,
+// when the store has one.
+func ReadsFromReplica(ctx context.Context) bool {
+	wrote, ok := ctx.Value(replicaReadsKey{}).(*atomic.Bool)
+	return ok && !wrote.Load()
+}
+
+// routedDB is the DBTX of a Postgres store with a read replica. Reads in
+// contexts from WithReplicaReads go to replica; writes, and everything
+// else, go to primary. Transactions are begun on the primary and don't
+// pass through it.
+type routedDB struct {
+	primary DBTX
+	replica DBTX
+}
+
+func (r *routedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.route(ctx, query).ExecContext(ctx, query, args...)
+}
+
+func (r *routedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.route(ctx, query).PrepareContext(ctx, query)
+}
+
+func (r *routedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+func (r *routedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.route(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// route picks the database a query runs on, remembering writes made in
+// contexts from WithReplicaReads.
+func (r *routedDB) route(ctx context.Context, query string) DBTX {
+	wrote, ok := ctx.Value(replicaReadsKey{}).(*atomic.Bool)
+	if !ok {
+		return r.primary
+	}
+	if !isReadOnly(query) {
+		wrote.Store(true)
+		return r.primary
+	}
+	if wrote.Load() {
+		return r.primary
+	}
+	return r.replica
+}
+
+// sideEffects matches what makes a SELECT unfit for a replica: row
+// locks, sequences, notifications and advisory locks.
+var sideEffects = regexp.MustCompile(`(?i)\bFOR\s+(NO\s+KEY\s+UPDATE|UPDATE|KEY\s+SHARE|SHARE)\b|\b(nextval|setval|pg_notify|pg_advisory_\w+)\s*\(`)
+
+// isReadOnly reports whether query is a SELECT without side effects,
+// skipping the comment sqlc starts each query with.
+func isReadOnly(query string) bool {
+	query = strings.TrimSpace(query)
+	for strings.HasPrefix(query, "--") {
+		_, query, _ = strings.Cut(query, "\n")
+		query = strings.TrimSpace(query)
+	}
+	fields := strings.Fields(query)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "SELECT") && !sideEffects.MatchString(query)
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeDBTX is a DBTX that only exists to be routed to.
+type fakeDBTX struct {
+	name string
+}
+
+func (f *fakeDBTX) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+
+func (f *fakeDBTX) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, nil
+}
+
+func (f *fakeDBTX) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, nil
+}
+
+func (f *fakeDBTX) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func TestIsReadOnly(t *testing.T) {
+	tests := map[string]bool{
+		getChirp:                     true,
+		getChirpsByUserPage:          true,
+		getChirpLinkPreviews:         true,
+		createChirp:                  false,
+		deleteChirp:                  false,
+		getChirpForUpdate:            false,
+		claimLinkPreviews:            false,
+		nextChirpEventID:             false,
+		notifyChirpEvent:             false,
+		"select 1":                   true,
+		"SELECT pg_advisory_lock(1)": false,
+		"WITH x AS (DELETE FROM chirps RETURNING id) SELECT * FROM x": false,
+		"": false,
+	}
+	for query, want := range tests {
+		if got := isReadOnly(query); got != want {
+			t.Errorf("isReadOnly(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestRoutedDB(t *testing.T) {
+	primary, replica := &fakeDBTX{"primary"}, &fakeDBTX{"replica"}
+	db := &routedDB{primary: primary, replica: replica}
+
+	if got := db.route(context.Background(), getChirp); got != primary {
+		t.Errorf("read in a plain context went to %v, want the primary", got)
+	}
+
+	ctx := WithReplicaReads(context.Background())
+	steps := []struct {
+		query string
+		want  DBTX
+	}{
+		{getChirp, replica},
+		{getChirpsByUserPage, replica},
+		{createChirp, primary},
+		// Reads after a write see it.
+		{getChirp, primary},
+	}
+	for i, step := range steps {
+		if got := db.route(ctx, step.query); got != step.want {
+			t.Errorf("query %d went to %v, want %v", i, got, step.want)
+		}
+	}
+
+	if got := db.route(WithReplicaReads(context.Background()), getChirp); got != replica {
+		t.Errorf("read in a new request went to %v, want the replica", got)
+	}
+}
+
+func TestInTxReadsFromPrimary(t *testing.T) {
+	// Nothing listens on port 1, so the transaction fails to begin; the
+	// reads after it go to the primary all the same.
+	conn, err := sql.Open("postgres", "postgres://127.0.0.1:1/chirpy?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	p := NewPostgresWithReplica(conn, conn)
+
+	ctx := WithReplicaReads(context.Background())
+	p.InTx(ctx, func(Store) error { return nil })
+	if ReadsFromReplica(ctx) {
+		t.Error("reads after InTx go to the replica, want the primary")
+	}
+}
+
+func TestPingWithRetry(t *testing.T) {
+	calls := 0
+	ping := func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	if err := pingWithRetry(context.Background(), ping, time.Millisecond); err != nil || calls != 3 {
+		t.Errorf("pingWithRetry() = %v after %d pings, want success after 3", err, calls)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	down := errors.New("connection refused")
+	err := pingWithRetry(ctx, func(context.Context) error { return down }, time.Millisecond)
+	if !errors.Is(err, down) {
+		t.Errorf("pingWithRetry() of a database that stays down = %v, want %v", err, down)
+	}
+}
@@ -1,354 +1,566 @@
 package database
 
-// import (
-// 	"crypto/rand"
-// 	"encoding/hex"
-// 	"encoding/json"
-// 	"errors"
-// 	"os"
-// 	"strconv"
-// 	"sync"
-// 	"time"
-
-// 	"golang.org/x/crypto/bcrypt"
-// )
-
-// type DB struct {
-// 	path string
-// 	mu   *sync.RWMutex
-// }
-
-// type DBStructure struct {
-// 	Chirps        map[int]Chirp           `json:"chirps"`
-// 	Users         map[int]User            `json:"users"`
-// 	RefreshTokens map[string]RefreshToken `json:"refresh_tokens"`
-// }
-
-// type RefreshToken struct {
-// 	ExpiresAt time.Time `json:"expires_at"`
-// 	UserId    int       `json:"user_id"`
-// }
-
-// type Chirp struct {
-// 	ID       int    `json:"id"`
-// 	Body     string `json:"body"`
-// 	AuthorId int    `json:"author_id"`
-// }
-
-// type User struct {
-// 	ID          int    `json:"id"`
-// 	Email       string `json:"email"`
-// 	Password    string `json:"password"`
-// 	IsChirpyRed bool   `json:"is_chirpy_red"`
-// }
-
-// func NewDB(path string) (*DB, error) {
-// 	db := &DB{
-// 		path: path,
-// 		mu:   &sync.RWMutex{},
-// 	}
-// 	err := db.ensureDB()
-// 	return db, err
-// }
-
-// func RemoveDB(path string) error {
-// 	err := os.Remove(path)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	return nil
-// }
-
-// func (db *DB) CreateChirp(body, userId string) (Chirp, error) {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return Chirp{}, err
-// 	}
-
-// 	id := len(dbStructure.Chirps) + 1
-// 	userIdAsInt, _ := strconv.Atoi(userId)
-// 	chirp := Chirp{
-// 		ID:       id,
-// 		Body:     body,
-// 		AuthorId: userIdAsInt,
-// 	}
-// 	dbStructure.Chirps[id] = chirp
-
-// 	err = db.writeDB(dbStructure)
-// 	if err != nil {
-// 		return Chirp{}, err
-// 	}
-
-// 	return chirp, nil
-// }
-
-// func (db *DB) DeleteChirp(chirpId int, userId string) error {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	userIdAsInt, _ := strconv.Atoi(userId)
-
-// 	if userIdAsInt != dbStructure.Chirps[chirpId].AuthorId {
-// 		return errors.New("you are not authorized to delete that chirp")
-// 	}
-
-// 	dbStructure.Chirps[chirpId] = Chirp{}
-
-// 	err = db.writeDB(dbStructure)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return nil
-// }
-
-// func (db *DB) GetChirps() ([]Chirp, error) {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	chirps := make([]Chirp, 0, len(dbStructure.Chirps))
-// 	for _, chirp := range dbStructure.Chirps {
-// 		chirps = append(chirps, chirp)
-// 	}
-
-// 	return chirps, nil
-// }
-
-// func (db *DB) GetChirp(id int) (Chirp, error) {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return Chirp{}, err
-// 	}
-
-// 	if id > len(dbStructure.Chirps) {
-// 		return Chirp{}, errors.New("Chirp does not exist")
-// 	}
-
-// 	chirp := dbStructure.Chirps[id]
-
-// 	return chirp, nil
-// }
-
-// func (db *DB) CreateUser(email, password string) (User, error) {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-
-// 	id := len(dbStructure.Users) + 1
-// 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 1)
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-// 	user := User{
-// 		ID:       id,
-// 		Email:    email,
-// 		Password: string(hashedPassword),
-// 	}
-// 	dbStructure.Users[id] = user
-
-// 	err = db.writeDB(dbStructure)
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-
-// 	return user, nil
-// }
-
-// func (db *DB) UpdateUser(email, password, userId string) (User, error) {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-
-// 	id, err := strconv.Atoi(userId)
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-// 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 1)
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-// 	user := User{
-// 		ID:       id,
-// 		Email:    email,
-// 		Password: string(hashedPassword),
-// 	}
-// 	dbStructure.Users[id] = user
-
-// 	err = db.writeDB(dbStructure)
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-
-// 	return user, nil
-// }
-
-// func (db *DB) UpgradeUser(userId int) error {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	user, ok := dbStructure.Users[userId]
-// 	if !ok {
-// 		return errors.New("User not found")
-// 	}
-
-// 	user.IsChirpyRed = true
-//     dbStructure.Users[userId] = user
-
-// 	err = db.writeDB(dbStructure)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return nil
-// }
-
-// func (db *DB) AuthenticateUser(email, password string) (User, error) {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-
-// 	user, err := getUserByEmail(email, &dbStructure)
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-
-// 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-// 	if err != nil {
-// 		return User{}, err
-// 	}
-
-// 	return user, nil
-// }
-
-// func (db *DB) GenerateRefreshToken(userId int) (string, error) {
-// 	refreshToken := make([]byte, 32)
-// 	_, _ = rand.Read(refreshToken)
-// 	refreshTokenString := hex.EncodeToString(refreshToken)
-
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	dbStructure.RefreshTokens[refreshTokenString] = RefreshToken{
-// 		ExpiresAt: time.Now().Add(time.Hour * time.Duration(24) * time.Duration(60)),
-// 		UserId:    userId,
-// 	}
-
-// 	err = db.writeDB(dbStructure)
-// 	if err != nil {
-// 		return "", err
-// 	}
-
-// 	_, ok := dbStructure.RefreshTokens[refreshTokenString]
-// 	if !ok {
-// 		return "", errors.New("refresh token was not saved to db")
-// 	}
-
-// 	return refreshTokenString, nil
-// }
-
-// func (db *DB) ValidateRefreshToken(refreshTokenString string) (int, error) {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return 0, err
-// 	}
-
-// 	refreshToken, ok := dbStructure.RefreshTokens[refreshTokenString]
-// 	if !ok {
-// 		return 0, errors.New("token does not exist")
-// 	}
-
-// 	if refreshToken.ExpiresAt.Before(time.Now()) {
-// 		return 0, errors.New("token has expired")
-// 	}
-
-// 	return refreshToken.UserId, nil
-// }
-
-// func (db *DB) RevokeRefreshToken(refreshTokenString string) error {
-// 	dbStructure, err := db.loadDB()
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	_, ok := dbStructure.RefreshTokens[refreshTokenString]
-// 	if !ok {
-// 		return errors.New("token doese not exist")
-// 	}
-
-// 	delete(dbStructure.RefreshTokens, refreshTokenString)
-// 	err = db.writeDB(dbStructure)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	return nil
-// }
-
-// func getUserByEmail(email string, dbStructure *DBStructure) (User, error) {
-// 	for _, user := range dbStructure.Users {
-// 		if user.Email == email {
-// 			return user, nil
-// 		}
-// 	}
-// 	return User{}, errors.New("couldnt find user by that email")
-// }
-
-// func (db *DB) createDB() error {
-// 	dbStructure := DBStructure{
-// 		Chirps:        map[int]Chirp{},
-// 		Users:         map[int]User{},
-// 		RefreshTokens: map[string]RefreshToken{},
-// 	}
-// 	return db.writeDB(dbStructure)
-// }
-
-// func (db *DB) ensureDB() error {
-// 	_, err := os.ReadFile(db.path)
-// 	if errors.Is(err, os.ErrNotExist) {
-// 		return db.createDB()
-// 	}
-// 	return err
-// }
-
-// func (db *DB) loadDB() (DBStructure, error) {
-// 	db.mu.RLock()
-// 	defer db.mu.RUnlock()
-
-// 	dbStructure := DBStructure{}
-// 	dat, err := os.ReadFile(db.path)
-// 	if errors.Is(err, os.ErrNotExist) {
-// 		return dbStructure, err
-// 	}
-// 	err = json.Unmarshal(dat, &dbStructure)
-// 	if err != nil {
-// 		return dbStructure, err
-// 	}
-
-// 	return dbStructure, nil
-// }
-
-// func (db *DB) writeDB(dbStructure DBStructure) error {
-// 	db.mu.Lock()
-// 	defer db.mu.Unlock()
-
-// 	dat, err := json.Marshal(dbStructure)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	err = os.WriteFile(db.path, dat, 0600)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	return nil
-// }
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrEmailTaken is returned when a user would be created or updated with
+// an email that already belongs to another user.
+var ErrEmailTaken = errors.New("email is already in use")
+
+// DB is a file-backed Store that keeps the whole database in a single
+// JSON document. It is meant for demos and small single-node deployments
+// that don't have Postgres available.
+type DB struct {
+	path string
+	mu   *sync.RWMutex
+	// tx is the in-memory database an InTx callback works on. Its changes
+	// are written out once, when the callback returns.
+	tx *DBStructure
+}
+
+// DBStructure is the on-disk layout of a DB.
+type DBStructure struct {
+	Chirps        map[uuid.UUID]Chirp     `json:"chirps"`
+	Users         map[uuid.UUID]User      `json:"users"`
+	RefreshTokens map[string]RefreshToken `json:"refresh_tokens"`
+
+	PasswordResetTokens map[string]PasswordResetToken `json:"password_reset_tokens"`
+	UserIdentities      []UserIdentity                `json:"user_identities"`
+
+	ScheduledChirps map[uuid.UUID]ScheduledChirp `json:"scheduled_chirps"`
+
+	LinkPreviews map[string]LinkPreview `json:"link_previews"`
+
+	ChirpHashtags []ChirpHashtag             `json:"chirp_hashtags"`
+	ChirpMentions []ChirpMention             `json:"chirp_mentions"`
+	ChirpLikes    []ChirpLike                `json:"chirp_likes"`
+	ChirpLinks    []ChirpLink                `json:"chirp_links"`
+	ChirpReports  []ChirpReport              `json:"chirp_reports"`
+	Follows       []Follow                   `json:"follows"`
+	Notifications map[uuid.UUID]Notification `json:"notifications"`
+	UserBlocks    []UserBlock                `json:"user_blocks"`
+	UserMutes     []UserMute                 `json:"user_mutes"`
+	Bookmarks     []Bookmark                 `json:"bookmarks"`
+
+	UserLists       map[uuid.UUID]UserList `json:"user_lists"`
+	UserListMembers []UserListMember       `json:"user_list_members"`
+
+	Conversations            map[uuid.UUID]Conversation `json:"conversations"`
+	ConversationParticipants []ConversationParticipant  `json:"conversation_participants"`
+	Messages                 map[uuid.UUID]Message      `json:"messages"`
+
+	SubscriptionEvents []SubscriptionEvent `json:"subscription_events"`
+
+	AuditEvents []AuditEvent `json:"audit_events"`
+
+	WebhookEndpoints  map[uuid.UUID]WebhookEndpoint `json:"webhook_endpoints"`
+	WebhookDeliveries map[uuid.UUID]WebhookDelivery `json:"webhook_deliveries"`
+	WebhookAttempts   map[uuid.UUID]WebhookAttempt  `json:"webhook_attempts"`
+}
+
+// beforeRename is called with the temp file path after it has been
+// written and synced but before it replaces the database file. Tests use
+// it to simulate a crash partway through a write.
+var beforeRename func(tmpPath string) error
+
+// NewDB opens the database file at path, creating an empty one if it
+// doesn't exist yet.
+func NewDB(path string) (*DB, error) {
+	db := &DB{
+		path: path,
+		mu:   &sync.RWMutex{},
+	}
+	err := db.ensureDB()
+	return db, err
+}
+
+// RemoveDB deletes the database file at path.
+func RemoveDB(path string) error {
+	return os.Remove(path)
+}
+
+func (db *DB) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
+	var chirp Chirp
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("chirp author does not exist")
+		}
+		now := time.Now().UTC()
+		chirp = Chirp{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Body:      arg.Body,
+			UserID:    arg.UserID,
+		}
+		dbStructure.Chirps[chirp.ID] = chirp
+		return nil
+	})
+	return chirp, err
+}
+
+// ImportChirps creates a chirp by UserID for each of Bodies, created and
+// last updated at the matching CreatedAts.
+func (db *DB) ImportChirps(ctx context.Context, arg ImportChirpsParams) ([]Chirp, error) {
+	if len(arg.Bodies) != len(arg.CreatedAts) {
+		return nil, errors.New("bodies and created_ats must have the same length")
+	}
+	var chirps []Chirp
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("chirp author does not exist")
+		}
+		for i, body := range arg.Bodies {
+			chirp := Chirp{
+				ID:        uuid.New(),
+				CreatedAt: arg.CreatedAts[i],
+				UpdatedAt: arg.CreatedAts[i],
+				Body:      body,
+				UserID:    arg.UserID,
+			}
+			dbStructure.Chirps[chirp.ID] = chirp
+			chirps = append(chirps, chirp)
+		}
+		return nil
+	})
+	return chirps, err
+}
+
+// DeleteChirp deletes a chirp along with the hashtags, mentions, likes
+// and notifications that refer to it.
+func (db *DB) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		removeChirp(dbStructure, id)
+		return nil
+	})
+}
+
+func (db *DB) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	var chirp Chirp
+	err := db.read(func(dbStructure DBStructure) error {
+		c, ok := dbStructure.Chirps[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		chirp = c
+		return nil
+	})
+	return chirp, err
+}
+
+// GetChirpForUpdate is GetChirp. Inside InTx the write lock already
+// keeps other writers out.
+func (db *DB) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	return db.GetChirp(ctx, id)
+}
+
+// GetChirps returns every chirp except those by authors the viewer has
+// muted or blocked, or who have blocked the viewer.
+func (db *DB) GetChirps(ctx context.Context, viewerID uuid.UUID) ([]Chirp, error) {
+	var chirps []Chirp
+	err := db.read(func(dbStructure DBStructure) error {
+		hidden := hiddenAuthors(dbStructure, viewerID)
+		chirps = make([]Chirp, 0, len(dbStructure.Chirps))
+		for _, chirp := range dbStructure.Chirps {
+			if !hidden[chirp.UserID] {
+				chirps = append(chirps, chirp)
+			}
+		}
+		return nil
+	})
+	sortChirps(chirps)
+	return chirps, err
+}
+
+func (db *DB) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := findUserByEmail(dbStructure, arg.Email); ok {
+			return ErrEmailTaken
+		}
+		now := time.Now().UTC()
+		user = User{
+			ID:             uuid.New(),
+			CreatedAt:      now,
+			UpdatedAt:      now,
+			Email:          arg.Email,
+			HashedPassword: arg.HashedPassword,
+		}
+		dbStructure.Users[user.ID] = user
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	var user User
+	err := db.read(func(dbStructure DBStructure) error {
+		u, ok := findUserByEmail(&dbStructure, email)
+		if !ok {
+			return sql.ErrNoRows
+		}
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	var user User
+	err := db.read(func(dbStructure DBStructure) error {
+		u, ok := dbStructure.Users[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+// GetUserByIDForUpdate is GetUserByID. Inside InTx the write lock
+// already keeps other writers out.
+func (db *DB) GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	return db.GetUserByID(ctx, id)
+}
+
+func (db *DB) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		u, ok := dbStructure.Users[arg.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		if other, ok := findUserByEmail(dbStructure, arg.Email); ok && other.ID != u.ID {
+			return ErrEmailTaken
+		}
+		u.Email = arg.Email
+		u.HashedPassword = arg.HashedPassword
+		u.UpdatedAt = time.Now().UTC()
+		dbStructure.Users[u.ID] = u
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, error) {
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		u, ok := dbStructure.Users[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		u.IsChirpyRed = true
+		u.UpdatedAt = time.Now().UTC()
+		dbStructure.Users[id] = u
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error) {
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		u, ok := dbStructure.Users[arg.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		u.IsAdmin = arg.IsAdmin
+		u.UpdatedAt = time.Now().UTC()
+		dbStructure.Users[arg.ID] = u
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
+	var refreshToken RefreshToken
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("refresh token user does not exist")
+		}
+		if _, ok := dbStructure.RefreshTokens[arg.Token]; ok {
+			return errors.New("refresh token already exists")
+		}
+		now := time.Now().UTC()
+		refreshToken = RefreshToken{
+			Token:     arg.Token,
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserID:    arg.UserID,
+			ExpiresAt: arg.ExpiresAt,
+		}
+		dbStructure.RefreshTokens[arg.Token] = refreshToken
+		return nil
+	})
+	return refreshToken, err
+}
+
+func (db *DB) GetUserFromRefreshToken(ctx context.Context, token string) (User, error) {
+	var user User
+	err := db.read(func(dbStructure DBStructure) error {
+		refreshToken, ok := dbStructure.RefreshTokens[token]
+		if !ok || refreshToken.RevokedAt.Valid || !refreshToken.ExpiresAt.After(time.Now()) {
+			return sql.ErrNoRows
+		}
+		u, ok := dbStructure.Users[refreshToken.UserID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) RevokeRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
+	var refreshToken RefreshToken
+	err := db.update(func(dbStructure *DBStructure) error {
+		rt, ok := dbStructure.RefreshTokens[token]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		now := time.Now().UTC()
+		rt.RevokedAt = sql.NullTime{Time: now, Valid: true}
+		rt.UpdatedAt = now
+		dbStructure.RefreshTokens[token] = rt
+		refreshToken = rt
+		return nil
+	})
+	return refreshToken, err
+}
+
+// Reset deletes everything but the audit log, which like the Postgres
+// audit_events table doesn't go away with the users it refers to.
+func (db *DB) Reset(ctx context.Context) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		auditEvents := dbStructure.AuditEvents
+		*dbStructure = newDBStructure()
+		dbStructure.AuditEvents = auditEvents
+		return nil
+	})
+}
+
+// InTx runs fn against an in-memory copy of the database and writes it
+// back only if fn succeeds. The write lock is held throughout, so fn must
+// only use the Store it is given: calling db itself from fn, directly or
+// through code holding on to it, waits for the lock forever.
+func (db *DB) InTx(ctx context.Context, fn func(Store) error) error {
+	if db.tx != nil {
+		return fn(db)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	dbStructure, err := db.loadDB()
+	if err != nil {
+		return err
+	}
+	if err := fn(&DB{path: db.path, mu: db.mu, tx: &dbStructure}); err != nil {
+		return err
+	}
+	return db.writeDB(dbStructure)
+}
+
+func removeChirp(dbStructure *DBStructure, id uuid.UUID) {
+	delete(dbStructure.Chirps, id)
+	dbStructure.ChirpHashtags = slices.DeleteFunc(dbStructure.ChirpHashtags, func(h ChirpHashtag) bool {
+		return h.ChirpID == id
+	})
+	dbStructure.ChirpMentions = slices.DeleteFunc(dbStructure.ChirpMentions, func(m ChirpMention) bool {
+		return m.ChirpID == id
+	})
+	dbStructure.ChirpLikes = slices.DeleteFunc(dbStructure.ChirpLikes, func(l ChirpLike) bool {
+		return l.ChirpID == id
+	})
+	dbStructure.ChirpLinks = slices.DeleteFunc(dbStructure.ChirpLinks, func(l ChirpLink) bool {
+		return l.ChirpID == id
+	})
+	dbStructure.ChirpReports = slices.DeleteFunc(dbStructure.ChirpReports, func(r ChirpReport) bool {
+		return r.ChirpID == id
+	})
+	dbStructure.Bookmarks = slices.DeleteFunc(dbStructure.Bookmarks, func(b Bookmark) bool {
+		return b.ChirpID == id
+	})
+	for notificationID, notification := range dbStructure.Notifications {
+		if notification.ChirpID.Valid && notification.ChirpID.UUID == id {
+			delete(dbStructure.Notifications, notificationID)
+		}
+	}
+}
+
+func findUserByEmail(dbStructure *DBStructure, email string) (User, bool) {
+	for _, user := range dbStructure.Users {
+		if user.Email == email {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+func sortChirps(chirps []Chirp) {
+	sort.Slice(chirps, func(i, j int) bool {
+		if chirps[i].CreatedAt.Equal(chirps[j].CreatedAt) {
+			return chirps[i].ID.String() < chirps[j].ID.String()
+		}
+		return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
+	})
+}
+
+func newDBStructure() DBStructure {
+	return DBStructure{
+		Chirps:        map[uuid.UUID]Chirp{},
+		Users:         map[uuid.UUID]User{},
+		RefreshTokens: map[string]RefreshToken{},
+
+		PasswordResetTokens: map[string]PasswordResetToken{},
+
+		ScheduledChirps: map[uuid.UUID]ScheduledChirp{},
+
+		LinkPreviews: map[string]LinkPreview{},
+
+		Notifications: map[uuid.UUID]Notification{},
+
+		UserLists: map[uuid.UUID]UserList{},
+
+		Conversations: map[uuid.UUID]Conversation{},
+		Messages:      map[uuid.UUID]Message{},
+
+		WebhookEndpoints:  map[uuid.UUID]WebhookEndpoint{},
+		WebhookDeliveries: map[uuid.UUID]WebhookDelivery{},
+		WebhookAttempts:   map[uuid.UUID]WebhookAttempt{},
+	}
+}
+
+// read runs fn against a snapshot of the database under a read lock.
+func (db *DB) read(fn func(DBStructure) error) error {
+	if db.tx != nil {
+		return fn(*db.tx)
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	dbStructure, err := db.loadDB()
+	if err != nil {
+		return err
+	}
+	return fn(dbStructure)
+}
+
+// update runs fn against the database and persists its changes. The write
+// lock is held for the whole read-modify-write so concurrent updates
+// can't overwrite each other. Nothing is written if fn returns an error.
+func (db *DB) update(fn func(*DBStructure) error) error {
+	if db.tx != nil {
+		return fn(db.tx)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	dbStructure, err := db.loadDB()
+	if err != nil {
+		return err
+	}
+	if err := fn(&dbStructure); err != nil {
+		return err
+	}
+	return db.writeDB(dbStructure)
+}
+
+func (db *DB) ensureDB() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := os.Stat(db.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db.writeDB(newDBStructure())
+	}
+	return err
+}
+
+func (db *DB) loadDB() (DBStructure, error) {
+	dat, err := os.ReadFile(db.path)
+	if err != nil {
+		return DBStructure{}, err
+	}
+
+	dbStructure := newDBStructure()
+	err = json.Unmarshal(dat, &dbStructure)
+	if err != nil {
+		return DBStructure{}, err
+	}
+	return dbStructure, nil
+}
+
+// writeDB atomically replaces the database file: the new contents are
+// written and synced to a temp file in the same directory, which is then
+// renamed over the old file. A crash at any point leaves either the old
+// or the new database on disk, never a partial one.
+func (db *DB) writeDB(dbStructure DBStructure) (err error) {
+	dat, err := json.Marshal(dbStructure)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(db.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(db.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(dat); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if beforeRename != nil {
+		if err = beforeRename(tmp.Name()); err != nil {
+			return err
+		}
+	}
+
+	if err = os.Rename(tmp.Name(), db.path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir flushes a directory entry change (such as a rename) to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
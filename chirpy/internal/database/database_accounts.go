@@ -0,0 +1,309 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeleteUser deletes a user and, like the Postgres schema's ON DELETE
+// CASCADE, everything that belongs to or refers to them.
+func (db *DB) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		for chirpID, chirp := range dbStructure.Chirps {
+			if chirp.UserID == id {
+				removeChirp(dbStructure, chirpID)
+			}
+		}
+		for chirpID, chirp := range dbStructure.ScheduledChirps {
+			if chirp.UserID == id {
+				delete(dbStructure.ScheduledChirps, chirpID)
+			}
+		}
+		for token, refreshToken := range dbStructure.RefreshTokens {
+			if refreshToken.UserID == id {
+				delete(dbStructure.RefreshTokens, token)
+			}
+		}
+		for tokenHash, resetToken := range dbStructure.PasswordResetTokens {
+			if resetToken.UserID == id {
+				delete(dbStructure.PasswordResetTokens, tokenHash)
+			}
+		}
+		dbStructure.UserIdentities = slices.DeleteFunc(dbStructure.UserIdentities, func(i UserIdentity) bool {
+			return i.UserID == id
+		})
+		dbStructure.ChirpMentions = slices.DeleteFunc(dbStructure.ChirpMentions, func(m ChirpMention) bool {
+			return m.UserID == id
+		})
+		dbStructure.ChirpLikes = slices.DeleteFunc(dbStructure.ChirpLikes, func(l ChirpLike) bool {
+			return l.UserID == id
+		})
+		dbStructure.ChirpReports = slices.DeleteFunc(dbStructure.ChirpReports, func(r ChirpReport) bool {
+			return r.ReporterID == id
+		})
+		dbStructure.Follows = slices.DeleteFunc(dbStructure.Follows, func(f Follow) bool {
+			return f.FollowerID == id || f.FolloweeID == id
+		})
+		dbStructure.UserBlocks = slices.DeleteFunc(dbStructure.UserBlocks, func(b UserBlock) bool {
+			return b.BlockerID == id || b.BlockedID == id
+		})
+		dbStructure.UserMutes = slices.DeleteFunc(dbStructure.UserMutes, func(m UserMute) bool {
+			return m.MuterID == id || m.MutedID == id
+		})
+		dbStructure.Bookmarks = slices.DeleteFunc(dbStructure.Bookmarks, func(b Bookmark) bool {
+			return b.UserID == id
+		})
+		for listID, list := range dbStructure.UserLists {
+			if list.OwnerID == id {
+				delete(dbStructure.UserLists, listID)
+			}
+		}
+		dbStructure.UserListMembers = slices.DeleteFunc(dbStructure.UserListMembers, func(m UserListMember) bool {
+			_, ok := dbStructure.UserLists[m.ListID]
+			return m.UserID == id || !ok
+		})
+		for conversationID, conversation := range dbStructure.Conversations {
+			if conversation.UserLow == id || conversation.UserHigh == id {
+				removeConversation(dbStructure, conversationID)
+			}
+		}
+		for notificationID, notification := range dbStructure.Notifications {
+			if notification.UserID == id || notification.ActorID == id {
+				delete(dbStructure.Notifications, notificationID)
+			}
+		}
+		for endpointID, endpoint := range dbStructure.WebhookEndpoints {
+			if endpoint.UserID == id {
+				removeWebhookEndpoint(dbStructure, endpointID)
+			}
+		}
+		dbStructure.SubscriptionEvents = slices.DeleteFunc(dbStructure.SubscriptionEvents, func(e SubscriptionEvent) bool {
+			return e.UserID == id
+		})
+		delete(dbStructure.Users, id)
+		return nil
+	})
+}
+
+func (db *DB) AnonymizeUser(ctx context.Context, id uuid.UUID) (User, error) {
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		u, ok := dbStructure.Users[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		now := time.Now().UTC()
+		user = User{
+			ID:        u.ID,
+			CreatedAt: u.CreatedAt,
+			UpdatedAt: now,
+			Email:     "deleted-" + u.ID.String() + "@users.invalid",
+			DeletedAt: sql.NullTime{Time: now, Valid: true},
+		}
+		dbStructure.Users[id] = user
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) GetRefreshTokensByUser(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error) {
+	var refreshTokens []RefreshToken
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, refreshToken := range dbStructure.RefreshTokens {
+			if refreshToken.UserID == userID {
+				refreshTokens = append(refreshTokens, refreshToken)
+			}
+		}
+		return nil
+	})
+	sort.Slice(refreshTokens, func(i, j int) bool {
+		return refreshTokens[i].CreatedAt.Before(refreshTokens[j].CreatedAt)
+	})
+	return refreshTokens, err
+}
+
+func (db *DB) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		now := time.Now().UTC()
+		for token, refreshToken := range dbStructure.RefreshTokens {
+			if refreshToken.UserID != userID || refreshToken.RevokedAt.Valid {
+				continue
+			}
+			refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			refreshToken.UpdatedAt = now
+			dbStructure.RefreshTokens[token] = refreshToken
+		}
+		return nil
+	})
+}
+
+func (db *DB) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (User, error) {
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		u, ok := dbStructure.Users[arg.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		u.HashedPassword = arg.HashedPassword
+		u.UpdatedAt = time.Now().UTC()
+		dbStructure.Users[arg.ID] = u
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	var resetToken PasswordResetToken
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("password reset token user does not exist")
+		}
+		if _, ok := dbStructure.PasswordResetTokens[arg.TokenHash]; ok {
+			return errors.New("password reset token already exists")
+		}
+		resetToken = PasswordResetToken{
+			TokenHash: arg.TokenHash,
+			CreatedAt: time.Now().UTC(),
+			UserID:    arg.UserID,
+			ExpiresAt: arg.ExpiresAt,
+		}
+		dbStructure.PasswordResetTokens[arg.TokenHash] = resetToken
+		return nil
+	})
+	return resetToken, err
+}
+
+// UsePasswordResetToken marks an unused, unexpired token as used and
+// returns it, or returns sql.ErrNoRows.
+func (db *DB) UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	var resetToken PasswordResetToken
+	err := db.update(func(dbStructure *DBStructure) error {
+		rt, ok := dbStructure.PasswordResetTokens[tokenHash]
+		if !ok || rt.UsedAt.Valid || !rt.ExpiresAt.After(time.Now()) {
+			return sql.ErrNoRows
+		}
+		rt.UsedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		dbStructure.PasswordResetTokens[tokenHash] = rt
+		resetToken = rt
+		return nil
+	})
+	return resetToken, err
+}
+
+func (db *DB) ExpireUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		now := time.Now().UTC()
+		for tokenHash, resetToken := range dbStructure.PasswordResetTokens {
+			if resetToken.UserID != userID || resetToken.UsedAt.Valid {
+				continue
+			}
+			resetToken.UsedAt = sql.NullTime{Time: now, Valid: true}
+			dbStructure.PasswordResetTokens[tokenHash] = resetToken
+		}
+		return nil
+	})
+}
+
+func (db *DB) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	var identity UserIdentity
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("identity user does not exist")
+		}
+		for _, i := range dbStructure.UserIdentities {
+			if i.Issuer == arg.Issuer && i.Subject == arg.Subject {
+				return errors.New("identity is already linked to a user")
+			}
+		}
+		identity = UserIdentity{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UserID:    arg.UserID,
+			Issuer:    arg.Issuer,
+			Subject:   arg.Subject,
+			Email:     arg.Email,
+		}
+		dbStructure.UserIdentities = append(dbStructure.UserIdentities, identity)
+		return nil
+	})
+	return identity, err
+}
+
+func (db *DB) GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (User, error) {
+	var user User
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, i := range dbStructure.UserIdentities {
+			if i.Issuer != arg.Issuer || i.Subject != arg.Subject {
+				continue
+			}
+			u, ok := dbStructure.Users[i.UserID]
+			if !ok {
+				break
+			}
+			user = u
+			return nil
+		}
+		return sql.ErrNoRows
+	})
+	return user, err
+}
+
+func (db *DB) GetUserIdentitiesByUser(ctx context.Context, userID uuid.UUID) ([]UserIdentity, error) {
+	var identities []UserIdentity
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, i := range dbStructure.UserIdentities {
+			if i.UserID == userID {
+				identities = append(identities, i)
+			}
+		}
+		return nil
+	})
+	return identities, err
+}
+
+func (db *DB) DeleteUserIdentitiesByUser(ctx context.Context, userID uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		dbStructure.UserIdentities = slices.DeleteFunc(dbStructure.UserIdentities, func(i UserIdentity) bool {
+			return i.UserID == userID
+		})
+		return nil
+	})
+}
+
+func (db *DB) CreateSubscriptionEvent(ctx context.Context, arg CreateSubscriptionEventParams) (SubscriptionEvent, error) {
+	var event SubscriptionEvent
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return sql.ErrNoRows
+		}
+		event = SubscriptionEvent{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UserID:    arg.UserID,
+			Event:     arg.Event,
+		}
+		dbStructure.SubscriptionEvents = append(dbStructure.SubscriptionEvents, event)
+		return nil
+	})
+	return event, err
+}
+
+func (db *DB) GetSubscriptionEventsByUser(ctx context.Context, userID uuid.UUID) ([]SubscriptionEvent, error) {
+	var events []SubscriptionEvent
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, event := range dbStructure.SubscriptionEvents {
+			if event.UserID == userID {
+				events = append(events, event)
+			}
+		}
+		return nil
+	})
+	return events, err
+}
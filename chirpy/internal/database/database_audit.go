@@ -0,0 +1,65 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (db *DB) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		dbStructure.AuditEvents = append(dbStructure.AuditEvents, AuditEvent{
+			ID:         uuid.New(),
+			CreatedAt:  time.Now().UTC(),
+			Action:     arg.Action,
+			ActorID:    arg.ActorID,
+			TargetType: arg.TargetType,
+			TargetID:   arg.TargetID,
+			Ip:         arg.Ip,
+			RequestID:  arg.RequestID,
+			Diff:       arg.Diff,
+		})
+		return nil
+	})
+}
+
+// GetAuditEvents returns the events matching every filter that is set,
+// newest first.
+func (db *DB) GetAuditEvents(ctx context.Context, arg GetAuditEventsParams) ([]AuditEvent, error) {
+	var events []AuditEvent
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, e := range dbStructure.AuditEvents {
+			switch {
+			case arg.Action.Valid && e.Action != arg.Action.String:
+			case arg.ActorID.Valid && e.ActorID != arg.ActorID:
+			case arg.TargetType.Valid && e.TargetType != arg.TargetType.String:
+			case arg.TargetID.Valid && e.TargetID != arg.TargetID.String:
+			case arg.Since.Valid && e.CreatedAt.Before(arg.Since.Time):
+			case arg.Until.Valid && !e.CreatedAt.Before(arg.Until.Time):
+			case arg.BeforeCreatedAt.Valid && !auditEventBefore(e, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID):
+			default:
+				events = append(events, e)
+			}
+		}
+		return nil
+	})
+	sort.Slice(events, func(i, j int) bool {
+		return auditEventBefore(events[j], events[i].CreatedAt, events[i].ID)
+	})
+	if len(events) > int(arg.MaxEvents) {
+		events = events[:arg.MaxEvents]
+	}
+	return events, err
+}
+
+// auditEventBefore reports whether e sorts before the (createdAt, id)
+// cursor, comparing rows the way Postgres does.
+func auditEventBefore(e AuditEvent, createdAt time.Time, id uuid.UUID) bool {
+	if !e.CreatedAt.Equal(createdAt) {
+		return e.CreatedAt.Before(createdAt)
+	}
+	return bytes.Compare(e.ID[:], id[:]) < 0
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QueueLinkPreview adds a pending preview for a URL. Previews already
+// fetched, or given up on, before StaleBefore are fetched again.
+func (db *DB) QueueLinkPreview(ctx context.Context, arg QueueLinkPreviewParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		now := time.Now().UTC()
+		preview, ok := dbStructure.LinkPreviews[arg.Url]
+		if !ok {
+			preview = LinkPreview{
+				Url:       arg.Url,
+				CreatedAt: now,
+			}
+		} else if preview.Status == "pending" || !preview.UpdatedAt.Before(arg.StaleBefore) {
+			return nil
+		}
+		preview.Status = "pending"
+		preview.Attempts = 0
+		preview.NextAttemptAt = now
+		preview.UpdatedAt = now
+		dbStructure.LinkPreviews[arg.Url] = preview
+		return nil
+	})
+}
+
+func (db *DB) AddChirpLink(ctx context.Context, arg AddChirpLinkParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Chirps[arg.ChirpID]; !ok {
+			return errors.New("linking chirp does not exist")
+		}
+		if _, ok := dbStructure.LinkPreviews[arg.Url]; !ok {
+			return errors.New("link preview does not exist")
+		}
+		for _, l := range dbStructure.ChirpLinks {
+			if l.ChirpID == arg.ChirpID && l.Url == arg.Url {
+				return nil
+			}
+		}
+		dbStructure.ChirpLinks = append(dbStructure.ChirpLinks, ChirpLink{
+			ChirpID:  arg.ChirpID,
+			Url:      arg.Url,
+			Position: arg.Position,
+		})
+		return nil
+	})
+}
+
+// ClaimLinkPreviews leases the pending previews that are due until
+// LeaseUntil, so other workers skip them, and returns them.
+func (db *DB) ClaimLinkPreviews(ctx context.Context, arg ClaimLinkPreviewsParams) ([]LinkPreview, error) {
+	var claimed []LinkPreview
+	err := db.update(func(dbStructure *DBStructure) error {
+		due := []LinkPreview{}
+		for _, preview := range dbStructure.LinkPreviews {
+			if preview.Status == "pending" && !preview.NextAttemptAt.After(arg.Now) {
+				due = append(due, preview)
+			}
+		}
+		sort.Slice(due, func(i, j int) bool {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		})
+		if len(due) > int(arg.MaxPreviews) {
+			due = due[:arg.MaxPreviews]
+		}
+		for _, preview := range due {
+			preview.NextAttemptAt = arg.LeaseUntil
+			dbStructure.LinkPreviews[preview.Url] = preview
+			claimed = append(claimed, preview)
+		}
+		return nil
+	})
+	return claimed, err
+}
+
+func (db *DB) UpdateLinkPreview(ctx context.Context, arg UpdateLinkPreviewParams) (LinkPreview, error) {
+	var preview LinkPreview
+	err := db.update(func(dbStructure *DBStructure) error {
+		p, ok := dbStructure.LinkPreviews[arg.Url]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		p.Status = arg.Status
+		p.Attempts = arg.Attempts
+		p.NextAttemptAt = arg.NextAttemptAt
+		p.Title = arg.Title
+		p.Description = arg.Description
+		p.ImageUrl = arg.ImageUrl
+		p.SiteName = arg.SiteName
+		p.UpdatedAt = time.Now().UTC()
+		dbStructure.LinkPreviews[arg.Url] = p
+		preview = p
+		return nil
+	})
+	return preview, err
+}
+
+// TouchLinkingChirps marks every chirp linking to url as updated, so
+// cached copies are refreshed to show its new preview.
+func (db *DB) TouchLinkingChirps(ctx context.Context, url string) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		now := time.Now().UTC()
+		for _, l := range dbStructure.ChirpLinks {
+			if chirp, ok := dbStructure.Chirps[l.ChirpID]; ok && l.Url == url {
+				chirp.UpdatedAt = now
+				dbStructure.Chirps[chirp.ID] = chirp
+			}
+		}
+		return nil
+	})
+}
+
+// GetChirpLinkPreviews returns the fetched previews of the links in each
+// chirp, in the order they appear.
+func (db *DB) GetChirpLinkPreviews(ctx context.Context, chirpIds []uuid.UUID) ([]GetChirpLinkPreviewsRow, error) {
+	var rows []GetChirpLinkPreviewsRow
+	err := db.read(func(dbStructure DBStructure) error {
+		links := slices.Clone(dbStructure.ChirpLinks)
+		sort.Slice(links, func(i, j int) bool {
+			if links[i].ChirpID != links[j].ChirpID {
+				return bytes.Compare(links[i].ChirpID[:], links[j].ChirpID[:]) < 0
+			}
+			return links[i].Position < links[j].Position
+		})
+		for _, l := range links {
+			preview, ok := dbStructure.LinkPreviews[l.Url]
+			if !ok || preview.Status != "fetched" || !slices.Contains(chirpIds, l.ChirpID) {
+				continue
+			}
+			rows = append(rows, GetChirpLinkPreviewsRow{
+				ChirpID:     l.ChirpID,
+				Url:         preview.Url,
+				Title:       preview.Title,
+				Description: preview.Description,
+				ImageUrl:    preview.ImageUrl,
+				SiteName:    preview.SiteName,
+			})
+		}
+		return nil
+	})
+	return rows, err
+}
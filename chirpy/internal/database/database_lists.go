@@ -0,0 +1,266 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (db *DB) BookmarkChirp(ctx context.Context, arg BookmarkChirpParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Chirps[arg.ChirpID]; !ok {
+			return errors.New("bookmarked chirp does not exist")
+		}
+		for _, b := range dbStructure.Bookmarks {
+			if b.UserID == arg.UserID && b.ChirpID == arg.ChirpID {
+				return nil
+			}
+		}
+		dbStructure.Bookmarks = append(dbStructure.Bookmarks, Bookmark{
+			UserID:    arg.UserID,
+			ChirpID:   arg.ChirpID,
+			CreatedAt: time.Now().UTC(),
+		})
+		return nil
+	})
+}
+
+func (db *DB) UnbookmarkChirp(ctx context.Context, arg UnbookmarkChirpParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		dbStructure.Bookmarks = slices.DeleteFunc(dbStructure.Bookmarks, func(b Bookmark) bool {
+			return b.UserID == arg.UserID && b.ChirpID == arg.ChirpID
+		})
+		return nil
+	})
+}
+
+// GetBookmarks returns the chirps a user bookmarked, most recently
+// bookmarked first, starting after the (BeforeBookmarkedAt,
+// BeforeChirpID) cursor when it is set. Hidden chirps are left out
+// unless they're the user's own.
+func (db *DB) GetBookmarks(ctx context.Context, arg GetBookmarksParams) ([]GetBookmarksRow, error) {
+	var rows []GetBookmarksRow
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, b := range dbStructure.Bookmarks {
+			if b.UserID != arg.UserID {
+				continue
+			}
+			if arg.BeforeBookmarkedAt.Valid && !keyBefore(b.CreatedAt, b.ChirpID, arg.BeforeBookmarkedAt.Time, arg.BeforeChirpID.UUID) {
+				continue
+			}
+			chirp, ok := dbStructure.Chirps[b.ChirpID]
+			if !ok || (chirp.HiddenAt.Valid && chirp.UserID != arg.UserID) {
+				continue
+			}
+			rows = append(rows, GetBookmarksRow{
+				ID:           chirp.ID,
+				CreatedAt:    chirp.CreatedAt,
+				UpdatedAt:    chirp.UpdatedAt,
+				Body:         chirp.Body,
+				UserID:       chirp.UserID,
+				HiddenAt:     chirp.HiddenAt,
+				BookmarkedAt: b.CreatedAt,
+			})
+		}
+		return nil
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		return keyBefore(rows[j].BookmarkedAt, rows[j].ID, rows[i].BookmarkedAt, rows[i].ID)
+	})
+	if len(rows) > int(arg.MaxBookmarks) {
+		rows = rows[:arg.MaxBookmarks]
+	}
+	return rows, err
+}
+
+func (db *DB) CreateUserList(ctx context.Context, arg CreateUserListParams) (UserList, error) {
+	var list UserList
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.OwnerID]; !ok {
+			return errors.New("list owner does not exist")
+		}
+		now := time.Now().UTC()
+		list = UserList{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			OwnerID:   arg.OwnerID,
+			Name:      arg.Name,
+			IsPrivate: arg.IsPrivate,
+		}
+		dbStructure.UserLists[list.ID] = list
+		return nil
+	})
+	return list, err
+}
+
+// GetUserList returns a list if the viewer may see it: private lists are
+// only visible to their owner.
+func (db *DB) GetUserList(ctx context.Context, arg GetUserListParams) (UserList, error) {
+	var list UserList
+	err := db.read(func(dbStructure DBStructure) error {
+		l, ok := dbStructure.UserLists[arg.ID]
+		if !ok || !listVisibleTo(l, arg.ViewerID) {
+			return sql.ErrNoRows
+		}
+		list = l
+		return nil
+	})
+	return list, err
+}
+
+func (db *DB) GetUserListsByOwner(ctx context.Context, ownerID uuid.UUID) ([]UserList, error) {
+	var lists []UserList
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, list := range dbStructure.UserLists {
+			if list.OwnerID == ownerID {
+				lists = append(lists, list)
+			}
+		}
+		return nil
+	})
+	sort.Slice(lists, func(i, j int) bool {
+		return lists[i].CreatedAt.Before(lists[j].CreatedAt)
+	})
+	return lists, err
+}
+
+// DeleteUserList deletes a list and its members, returning 0 if the list
+// doesn't exist or belongs to someone else.
+func (db *DB) DeleteUserList(ctx context.Context, arg DeleteUserListParams) (int64, error) {
+	var n int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		list, ok := dbStructure.UserLists[arg.ID]
+		if !ok || list.OwnerID != arg.OwnerID {
+			return nil
+		}
+		delete(dbStructure.UserLists, arg.ID)
+		dbStructure.UserListMembers = slices.DeleteFunc(dbStructure.UserListMembers, func(m UserListMember) bool {
+			return m.ListID == arg.ID
+		})
+		n = 1
+		return nil
+	})
+	return n, err
+}
+
+// AddUserListMember adds a user to a list and returns 1, or 0 if they
+// were already on it or the list doesn't belong to OwnerID.
+func (db *DB) AddUserListMember(ctx context.Context, arg AddUserListMemberParams) (int64, error) {
+	var n int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		list, ok := dbStructure.UserLists[arg.ListID]
+		if !ok || list.OwnerID != arg.OwnerID {
+			return nil
+		}
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("list member does not exist")
+		}
+		for _, m := range dbStructure.UserListMembers {
+			if m.ListID == arg.ListID && m.UserID == arg.UserID {
+				return nil
+			}
+		}
+		dbStructure.UserListMembers = append(dbStructure.UserListMembers, UserListMember{
+			ListID:  arg.ListID,
+			UserID:  arg.UserID,
+			AddedAt: time.Now().UTC(),
+		})
+		n = 1
+		return nil
+	})
+	return n, err
+}
+
+func (db *DB) RemoveUserListMember(ctx context.Context, arg RemoveUserListMemberParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		list, ok := dbStructure.UserLists[arg.ListID]
+		if !ok || list.OwnerID != arg.OwnerID {
+			return nil
+		}
+		dbStructure.UserListMembers = slices.DeleteFunc(dbStructure.UserListMembers, func(m UserListMember) bool {
+			return m.ListID == arg.ListID && m.UserID == arg.UserID
+		})
+		return nil
+	})
+}
+
+func (db *DB) GetUserListMembers(ctx context.Context, listIds []uuid.UUID) ([]UserListMember, error) {
+	var members []UserListMember
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, m := range dbStructure.UserListMembers {
+			if slices.Contains(listIds, m.ListID) {
+				members = append(members, m)
+			}
+		}
+		return nil
+	})
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if a.ListID != b.ListID {
+			return bytes.Compare(a.ListID[:], b.ListID[:]) < 0
+		}
+		if !a.AddedAt.Equal(b.AddedAt) {
+			return a.AddedAt.Before(b.AddedAt)
+		}
+		return bytes.Compare(a.UserID[:], b.UserID[:]) < 0
+	})
+	return members, err
+}
+
+// GetUserListChirps returns the chirps of a list's members that the
+// viewer can see, newest first, starting after the (BeforeCreatedAt,
+// BeforeID) cursor when it is set. It returns none if the list is
+// private to someone else.
+func (db *DB) GetUserListChirps(ctx context.Context, arg GetUserListChirpsParams) ([]Chirp, error) {
+	var chirps []Chirp
+	err := db.read(func(dbStructure DBStructure) error {
+		list, ok := dbStructure.UserLists[arg.ListID]
+		if !ok || !listVisibleTo(list, arg.ViewerID) {
+			return nil
+		}
+		members := map[uuid.UUID]bool{}
+		for _, m := range dbStructure.UserListMembers {
+			if m.ListID == arg.ListID {
+				members[m.UserID] = true
+			}
+		}
+		hidden := hiddenAuthors(dbStructure, arg.ViewerID)
+		for _, chirp := range dbStructure.Chirps {
+			switch {
+			case !members[chirp.UserID] || hidden[chirp.UserID]:
+			case chirp.HiddenAt.Valid && chirp.UserID != arg.ViewerID && !arg.ViewerIsAdmin:
+			case arg.BeforeCreatedAt.Valid && !keyBefore(chirp.CreatedAt, chirp.ID, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID):
+			default:
+				chirps = append(chirps, chirp)
+			}
+		}
+		return nil
+	})
+	sort.Slice(chirps, func(i, j int) bool {
+		return keyBefore(chirps[j].CreatedAt, chirps[j].ID, chirps[i].CreatedAt, chirps[i].ID)
+	})
+	if len(chirps) > int(arg.MaxChirps) {
+		chirps = chirps[:arg.MaxChirps]
+	}
+	return chirps, err
+}
+
+func listVisibleTo(list UserList, viewerID uuid.UUID) bool {
+	return !list.IsPrivate || list.OwnerID == viewerID
+}
+
+// keyBefore reports whether the (at, id) key sorts before the
+// (cursorAt, cursorID) cursor, comparing rows the way Postgres does.
+func keyBefore(at time.Time, id uuid.UUID, cursorAt time.Time, cursorID uuid.UUID) bool {
+	if !at.Equal(cursorAt) {
+		return at.Before(cursorAt)
+	}
+	return bytes.Compare(id[:], cursorID[:]) < 0
+}
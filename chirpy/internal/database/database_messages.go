@@ -0,0 +1,329 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateConversation returns sql.ErrNoRows, like the Postgres query's ON
+// CONFLICT DO NOTHING, if the users already have a conversation.
+func (db *DB) CreateConversation(ctx context.Context, arg CreateConversationParams) (Conversation, error) {
+	var conversation Conversation
+	err := db.update(func(dbStructure *DBStructure) error {
+		low, high := orderedPair(arg.UserA, arg.UserB)
+		if low == high {
+			return errors.New("users can't have a conversation with themselves")
+		}
+		for _, id := range []uuid.UUID{low, high} {
+			if _, ok := dbStructure.Users[id]; !ok {
+				return errors.New("conversation user does not exist")
+			}
+		}
+		if _, ok := findConversationBetween(dbStructure, low, high); ok {
+			return sql.ErrNoRows
+		}
+		now := time.Now().UTC()
+		conversation = Conversation{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserLow:   low,
+			UserHigh:  high,
+		}
+		dbStructure.Conversations[conversation.ID] = conversation
+		return nil
+	})
+	return conversation, err
+}
+
+func (db *DB) GetConversationBetween(ctx context.Context, arg GetConversationBetweenParams) (Conversation, error) {
+	var conversation Conversation
+	err := db.read(func(dbStructure DBStructure) error {
+		low, high := orderedPair(arg.UserA, arg.UserB)
+		c, ok := findConversationBetween(&dbStructure, low, high)
+		if !ok {
+			return sql.ErrNoRows
+		}
+		conversation = c
+		return nil
+	})
+	return conversation, err
+}
+
+func (db *DB) GetConversationForParticipant(ctx context.Context, arg GetConversationForParticipantParams) (Conversation, error) {
+	var conversation Conversation
+	err := db.read(func(dbStructure DBStructure) error {
+		c, ok := dbStructure.Conversations[arg.ID]
+		if !ok || findParticipant(&dbStructure, arg.ID, arg.UserID) < 0 {
+			return sql.ErrNoRows
+		}
+		conversation = c
+		return nil
+	})
+	return conversation, err
+}
+
+func (db *DB) GetConversationsByUser(ctx context.Context, arg GetConversationsByUserParams) ([]GetConversationsByUserRow, error) {
+	var rows []GetConversationsByUserRow
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, p := range dbStructure.ConversationParticipants {
+			if p.UserID != arg.UserID {
+				continue
+			}
+			c, ok := dbStructure.Conversations[p.ConversationID]
+			if !ok {
+				continue
+			}
+			row := GetConversationsByUserRow{
+				ID:        c.ID,
+				CreatedAt: c.CreatedAt,
+				UpdatedAt: c.UpdatedAt,
+				UserLow:   c.UserLow,
+				UserHigh:  c.UserHigh,
+			}
+			row.UnreadCount = unreadMessageCount(&dbStructure, p)
+			rows = append(rows, row)
+		}
+		return nil
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].UpdatedAt.Equal(rows[j].UpdatedAt) {
+			return bytes.Compare(rows[i].ID[:], rows[j].ID[:]) > 0
+		}
+		return rows[i].UpdatedAt.After(rows[j].UpdatedAt)
+	})
+	if len(rows) > int(arg.MaxConversations) {
+		rows = rows[:arg.MaxConversations]
+	}
+	return rows, err
+}
+
+func (db *DB) CountUnreadMessages(ctx context.Context, arg CountUnreadMessagesParams) (int64, error) {
+	var count int64
+	err := db.read(func(dbStructure DBStructure) error {
+		i := findParticipant(&dbStructure, arg.ConversationID, arg.UserID)
+		if i >= 0 {
+			count = unreadMessageCount(&dbStructure, dbStructure.ConversationParticipants[i])
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (db *DB) SetConversationUpdatedAt(ctx context.Context, arg SetConversationUpdatedAtParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		c, ok := dbStructure.Conversations[arg.ID]
+		if !ok {
+			return nil
+		}
+		c.UpdatedAt = arg.UpdatedAt
+		dbStructure.Conversations[arg.ID] = c
+		return nil
+	})
+}
+
+func (db *DB) AddConversationParticipant(ctx context.Context, arg AddConversationParticipantParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Conversations[arg.ConversationID]; !ok {
+			return errors.New("conversation does not exist")
+		}
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("participant does not exist")
+		}
+		if findParticipant(dbStructure, arg.ConversationID, arg.UserID) >= 0 {
+			return nil
+		}
+		dbStructure.ConversationParticipants = append(dbStructure.ConversationParticipants, ConversationParticipant{
+			ConversationID: arg.ConversationID,
+			UserID:         arg.UserID,
+			JoinedAt:       time.Now().UTC(),
+		})
+		return nil
+	})
+}
+
+func (db *DB) GetConversationParticipants(ctx context.Context, conversationIds []uuid.UUID) ([]ConversationParticipant, error) {
+	var participants []ConversationParticipant
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, p := range dbStructure.ConversationParticipants {
+			if slices.Contains(conversationIds, p.ConversationID) {
+				participants = append(participants, p)
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(participants, func(i, j int) bool {
+		a, b := participants[i], participants[j]
+		if a.ConversationID != b.ConversationID {
+			return bytes.Compare(a.ConversationID[:], b.ConversationID[:]) < 0
+		}
+		if !a.JoinedAt.Equal(b.JoinedAt) {
+			return a.JoinedAt.Before(b.JoinedAt)
+		}
+		return bytes.Compare(a.UserID[:], b.UserID[:]) < 0
+	})
+	return participants, err
+}
+
+// MarkConversationRead moves the participant's read marker forward to
+// ReadAt. It never moves it back.
+func (db *DB) MarkConversationRead(ctx context.Context, arg MarkConversationReadParams) (ConversationParticipant, error) {
+	var participant ConversationParticipant
+	err := db.update(func(dbStructure *DBStructure) error {
+		i := findParticipant(dbStructure, arg.ConversationID, arg.UserID)
+		if i < 0 {
+			return sql.ErrNoRows
+		}
+		p := dbStructure.ConversationParticipants[i]
+		if !p.LastReadAt.Valid || arg.ReadAt.After(p.LastReadAt.Time) {
+			p.LastReadAt = sql.NullTime{Time: arg.ReadAt, Valid: true}
+		}
+		dbStructure.ConversationParticipants[i] = p
+		participant = p
+		return nil
+	})
+	return participant, err
+}
+
+func (db *DB) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+	var message Message
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Conversations[arg.ConversationID]; !ok {
+			return errors.New("message conversation does not exist")
+		}
+		if _, ok := dbStructure.Users[arg.SenderID]; !ok {
+			return errors.New("message sender does not exist")
+		}
+		message = Message{
+			ID:             uuid.New(),
+			CreatedAt:      time.Now().UTC(),
+			ConversationID: arg.ConversationID,
+			SenderID:       arg.SenderID,
+			Body:           arg.Body,
+		}
+		dbStructure.Messages[message.ID] = message
+		return nil
+	})
+	return message, err
+}
+
+func (db *DB) GetMessage(ctx context.Context, arg GetMessageParams) (Message, error) {
+	var message Message
+	err := db.read(func(dbStructure DBStructure) error {
+		m, ok := dbStructure.Messages[arg.ID]
+		if !ok || m.ConversationID != arg.ConversationID {
+			return sql.ErrNoRows
+		}
+		message = m
+		return nil
+	})
+	return message, err
+}
+
+// GetMessages returns a conversation's messages newest first, starting
+// after the (BeforeCreatedAt, BeforeID) cursor when it is set.
+func (db *DB) GetMessages(ctx context.Context, arg GetMessagesParams) ([]Message, error) {
+	var messages []Message
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, m := range dbStructure.Messages {
+			if m.ConversationID != arg.ConversationID {
+				continue
+			}
+			if arg.BeforeCreatedAt.Valid && !messageBefore(m, arg.BeforeCreatedAt.Time, arg.BeforeID.UUID) {
+				continue
+			}
+			messages = append(messages, m)
+		}
+		return nil
+	})
+	sort.Slice(messages, func(i, j int) bool {
+		return messageBefore(messages[j], messages[i].CreatedAt, messages[i].ID)
+	})
+	if len(messages) > int(arg.MaxMessages) {
+		messages = messages[:arg.MaxMessages]
+	}
+	return messages, err
+}
+
+func (db *DB) GetMessagesBySender(ctx context.Context, senderID uuid.UUID) ([]Message, error) {
+	var messages []Message
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, m := range dbStructure.Messages {
+			if m.SenderID == senderID {
+				messages = append(messages, m)
+			}
+		}
+		return nil
+	})
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	return messages, err
+}
+
+// removeConversation deletes a conversation along with its participants
+// and messages.
+func removeConversation(dbStructure *DBStructure, id uuid.UUID) {
+	delete(dbStructure.Conversations, id)
+	dbStructure.ConversationParticipants = slices.DeleteFunc(dbStructure.ConversationParticipants, func(p ConversationParticipant) bool {
+		return p.ConversationID == id
+	})
+	for messageID, m := range dbStructure.Messages {
+		if m.ConversationID == id {
+			delete(dbStructure.Messages, messageID)
+		}
+	}
+}
+
+// unreadMessageCount counts the messages others have sent p since the
+// last one p read.
+func unreadMessageCount(dbStructure *DBStructure, p ConversationParticipant) int64 {
+	var count int64
+	for _, m := range dbStructure.Messages {
+		if m.ConversationID == p.ConversationID && m.SenderID != p.UserID &&
+			(!p.LastReadAt.Valid || m.CreatedAt.After(p.LastReadAt.Time)) {
+			count++
+		}
+	}
+	return count
+}
+
+func findConversationBetween(dbStructure *DBStructure, low, high uuid.UUID) (Conversation, bool) {
+	for _, c := range dbStructure.Conversations {
+		if c.UserLow == low && c.UserHigh == high {
+			return c, true
+		}
+	}
+	return Conversation{}, false
+}
+
+func findParticipant(dbStructure *DBStructure, conversationID, userID uuid.UUID) int {
+	return slices.IndexFunc(dbStructure.ConversationParticipants, func(p ConversationParticipant) bool {
+		return p.ConversationID == conversationID && p.UserID == userID
+	})
+}
+
+// orderedPair returns a and b in the order Postgres sorts UUIDs, which is
+// byte by byte.
+func orderedPair(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		return b, a
+	}
+	return a, b
+}
+
+// messageBefore reports whether m sorts before the (createdAt, id)
+// cursor, comparing rows the way Postgres does.
+func messageBefore(m Message, createdAt time.Time, id uuid.UUID) bool {
+	if !m.CreatedAt.Equal(createdAt) {
+		return m.CreatedAt.Before(createdAt)
+	}
+	return bytes.Compare(m.ID[:], id[:]) < 0
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (db *DB) SetChirpHidden(ctx context.Context, arg SetChirpHiddenParams) (Chirp, error) {
+	var chirp Chirp
+	err := db.update(func(dbStructure *DBStructure) error {
+		c, ok := dbStructure.Chirps[arg.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		c.HiddenAt = arg.HiddenAt
+		c.UpdatedAt = time.Now().UTC()
+		dbStructure.Chirps[c.ID] = c
+		chirp = c
+		return nil
+	})
+	return chirp, err
+}
+
+func (db *DB) AddUserStrike(ctx context.Context, id uuid.UUID) (User, error) {
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		u, ok := dbStructure.Users[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		u.Strikes++
+		u.UpdatedAt = time.Now().UTC()
+		dbStructure.Users[id] = u
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error) {
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		u, ok := dbStructure.Users[arg.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		u.SuspendedUntil = arg.SuspendedUntil
+		u.UpdatedAt = time.Now().UTC()
+		dbStructure.Users[arg.ID] = u
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+// CreateChirpReport records a report unless the reporter already has an
+// open one for the chirp. It returns how many reports it created.
+func (db *DB) CreateChirpReport(ctx context.Context, arg CreateChirpReportParams) (int64, error) {
+	var created int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Chirps[arg.ChirpID]; !ok {
+			return errors.New("reported chirp does not exist")
+		}
+		if _, ok := dbStructure.Users[arg.ReporterID]; !ok {
+			return errors.New("reporter does not exist")
+		}
+		for _, r := range dbStructure.ChirpReports {
+			if r.ChirpID == arg.ChirpID && r.ReporterID == arg.ReporterID && !r.ResolvedAt.Valid {
+				return nil
+			}
+		}
+		dbStructure.ChirpReports = append(dbStructure.ChirpReports, ChirpReport{
+			ID:         uuid.New(),
+			CreatedAt:  time.Now().UTC(),
+			ChirpID:    arg.ChirpID,
+			ReporterID: arg.ReporterID,
+			Reason:     arg.Reason,
+			Details:    arg.Details,
+		})
+		created = 1
+		return nil
+	})
+	return created, err
+}
+
+func (db *DB) GetModerationQueue(ctx context.Context, maxChirps int32) ([]GetModerationQueueRow, error) {
+	var rows []GetModerationQueueRow
+	err := db.read(func(dbStructure DBStructure) error {
+		byChirp := map[uuid.UUID]*GetModerationQueueRow{}
+		for _, r := range dbStructure.ChirpReports {
+			if r.ResolvedAt.Valid {
+				continue
+			}
+			row, ok := byChirp[r.ChirpID]
+			if !ok {
+				row = &GetModerationQueueRow{ChirpID: r.ChirpID, FirstReportedAt: r.CreatedAt}
+				byChirp[r.ChirpID] = row
+			}
+			row.ReportCount++
+			if !slices.Contains(row.Reasons, r.Reason) {
+				row.Reasons = append(row.Reasons, r.Reason)
+			}
+			if r.CreatedAt.Before(row.FirstReportedAt) {
+				row.FirstReportedAt = r.CreatedAt
+			}
+		}
+		for _, row := range byChirp {
+			slices.Sort(row.Reasons)
+			rows = append(rows, *row)
+		}
+		return nil
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ReportCount != rows[j].ReportCount {
+			return rows[i].ReportCount > rows[j].ReportCount
+		}
+		return rows[i].FirstReportedAt.Before(rows[j].FirstReportedAt)
+	})
+	if len(rows) > int(maxChirps) {
+		rows = rows[:maxChirps]
+	}
+	return rows, err
+}
+
+func (db *DB) ResolveChirpReports(ctx context.Context, arg ResolveChirpReportsParams) (int64, error) {
+	var resolved int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		now := time.Now().UTC()
+		for i, r := range dbStructure.ChirpReports {
+			if r.ChirpID != arg.ChirpID || r.ResolvedAt.Valid {
+				continue
+			}
+			dbStructure.ChirpReports[i].ResolvedAt = sql.NullTime{Time: now, Valid: true}
+			dbStructure.ChirpReports[i].Resolution = arg.Resolution
+			resolved++
+		}
+		return nil
+	})
+	return resolved, err
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (db *DB) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	var user User
+	err := db.read(func(dbStructure DBStructure) error {
+		u, ok := findUserByHandle(&dbStructure, handle)
+		if !ok {
+			return sql.ErrNoRows
+		}
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+// UpdateUserProfile checks arg against the same rules as the Postgres
+// schema, including case-insensitive handle uniqueness.
+func (db *DB) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	if err := checkProfile(arg); err != nil {
+		return User{}, err
+	}
+
+	var user User
+	err := db.update(func(dbStructure *DBStructure) error {
+		u, ok := dbStructure.Users[arg.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		if arg.Handle.Valid {
+			if other, ok := findUserByHandle(dbStructure, arg.Handle.String); ok && other.ID != u.ID {
+				return ErrHandleTaken
+			}
+		}
+		u.Handle = arg.Handle
+		u.DisplayName = arg.DisplayName
+		u.Bio = arg.Bio
+		u.AvatarUrl = arg.AvatarUrl
+		u.UpdatedAt = time.Now().UTC()
+		dbStructure.Users[u.ID] = u
+		user = u
+		return nil
+	})
+	return user, err
+}
+
+func (db *DB) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	var chirps []Chirp
+	err := db.read(func(dbStructure DBStructure) error {
+		chirps = []Chirp{}
+		for _, chirp := range dbStructure.Chirps {
+			if chirp.UserID == userID {
+				chirps = append(chirps, chirp)
+			}
+		}
+		return nil
+	})
+	sortChirps(chirps)
+	return chirps, err
+}
+
+// GetChirpsByUserPage returns up to MaxChirps of a user's chirps, oldest
+// first, starting after the one created at AfterCreatedAt with AfterID.
+func (db *DB) GetChirpsByUserPage(ctx context.Context, arg GetChirpsByUserPageParams) ([]Chirp, error) {
+	chirps, err := db.GetChirpsByUser(ctx, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	page := []Chirp{}
+	for _, chirp := range chirps {
+		if len(page) == int(arg.MaxChirps) {
+			break
+		}
+		if arg.AfterCreatedAt.Valid && !keyBefore(arg.AfterCreatedAt.Time, arg.AfterID.UUID, chirp.CreatedAt, chirp.ID) {
+			continue
+		}
+		page = append(page, chirp)
+	}
+	return page, nil
+}
+
+func findUserByHandle(dbStructure *DBStructure, handle string) (User, bool) {
+	for _, user := range dbStructure.Users {
+		if user.Handle.Valid && strings.EqualFold(user.Handle.String, handle) {
+			return user, true
+		}
+	}
+	return User{}, false
+}
@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (db *DB) PublishScheduledChirp(ctx context.Context, arg PublishScheduledChirpParams) (Chirp, error) {
+	var chirp Chirp
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("chirp author does not exist")
+		}
+		if _, ok := dbStructure.Chirps[arg.ID]; ok {
+			return errors.New("chirp already exists")
+		}
+		chirp = Chirp{
+			ID:        arg.ID,
+			CreatedAt: arg.CreatedAt,
+			UpdatedAt: arg.CreatedAt,
+			Body:      arg.Body,
+			UserID:    arg.UserID,
+		}
+		dbStructure.Chirps[chirp.ID] = chirp
+		return nil
+	})
+	return chirp, err
+}
+
+func (db *DB) CreateScheduledChirp(ctx context.Context, arg CreateScheduledChirpParams) (ScheduledChirp, error) {
+	var chirp ScheduledChirp
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("chirp author does not exist")
+		}
+		now := time.Now().UTC()
+		chirp = ScheduledChirp{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserID:    arg.UserID,
+			Body:      arg.Body,
+			PublishAt: arg.PublishAt,
+		}
+		dbStructure.ScheduledChirps[chirp.ID] = chirp
+		return nil
+	})
+	return chirp, err
+}
+
+func (db *DB) GetScheduledChirp(ctx context.Context, id uuid.UUID) (ScheduledChirp, error) {
+	var chirp ScheduledChirp
+	err := db.read(func(dbStructure DBStructure) error {
+		c, ok := dbStructure.ScheduledChirps[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		chirp = c
+		return nil
+	})
+	return chirp, err
+}
+
+// GetScheduledChirpForUpdate is GetScheduledChirp. Inside InTx the write
+// lock already keeps other writers out.
+func (db *DB) GetScheduledChirpForUpdate(ctx context.Context, id uuid.UUID) (ScheduledChirp, error) {
+	return db.GetScheduledChirp(ctx, id)
+}
+
+func (db *DB) GetScheduledChirpsByUser(ctx context.Context, userID uuid.UUID) ([]ScheduledChirp, error) {
+	var chirps []ScheduledChirp
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, chirp := range dbStructure.ScheduledChirps {
+			if chirp.UserID == userID {
+				chirps = append(chirps, chirp)
+			}
+		}
+		return nil
+	})
+	sortScheduledChirps(chirps)
+	return chirps, err
+}
+
+func (db *DB) UpdateScheduledChirp(ctx context.Context, arg UpdateScheduledChirpParams) (ScheduledChirp, error) {
+	var chirp ScheduledChirp
+	err := db.update(func(dbStructure *DBStructure) error {
+		c, ok := dbStructure.ScheduledChirps[arg.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		c.Body = arg.Body
+		c.PublishAt = arg.PublishAt
+		c.FailedAt = sql.NullTime{}
+		c.UpdatedAt = time.Now().UTC()
+		dbStructure.ScheduledChirps[c.ID] = c
+		chirp = c
+		return nil
+	})
+	return chirp, err
+}
+
+func (db *DB) DeleteScheduledChirp(ctx context.Context, id uuid.UUID) (int64, error) {
+	var deleted int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.ScheduledChirps[id]; ok {
+			delete(dbStructure.ScheduledChirps, id)
+			deleted = 1
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+func (db *DB) DeleteScheduledChirpsByUser(ctx context.Context, userID uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		for id, chirp := range dbStructure.ScheduledChirps {
+			if chirp.UserID == userID {
+				delete(dbStructure.ScheduledChirps, id)
+			}
+		}
+		return nil
+	})
+}
+
+// ClaimDueScheduledChirps returns the due chirps. The file-backed DB has a
+// single writer, so there is nothing to lock.
+func (db *DB) ClaimDueScheduledChirps(ctx context.Context, arg ClaimDueScheduledChirpsParams) ([]ScheduledChirp, error) {
+	var due []ScheduledChirp
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, chirp := range dbStructure.ScheduledChirps {
+			author := dbStructure.Users[chirp.UserID]
+			switch {
+			case chirp.PublishAt.After(arg.Now):
+			case chirp.FailedAt.Valid && !(arg.RetryFailedBefore.Valid && chirp.FailedAt.Time.Before(arg.RetryFailedBefore.Time)):
+			case author.SuspendedUntil.Valid && author.SuspendedUntil.Time.After(arg.Now):
+			case author.DeletedAt.Valid:
+			default:
+				due = append(due, chirp)
+			}
+		}
+		return nil
+	})
+	sortScheduledChirps(due)
+	if len(due) > int(arg.MaxChirps) {
+		due = due[:arg.MaxChirps]
+	}
+	return due, err
+}
+
+func (db *DB) MarkScheduledChirpFailed(ctx context.Context, arg MarkScheduledChirpFailedParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		if c, ok := dbStructure.ScheduledChirps[arg.ID]; ok {
+			c.FailedAt = arg.FailedAt
+			dbStructure.ScheduledChirps[c.ID] = c
+		}
+		return nil
+	})
+}
+
+func sortScheduledChirps(chirps []ScheduledChirp) {
+	sort.Slice(chirps, func(i, j int) bool {
+		if chirps[i].PublishAt.Equal(chirps[j].PublishAt) {
+			return chirps[i].ID.String() < chirps[j].ID.String()
+		}
+		return chirps[i].PublishAt.Before(chirps[j].PublishAt)
+	})
+}
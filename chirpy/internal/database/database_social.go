@@ -0,0 +1,370 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (db *DB) AddChirpHashtag(ctx context.Context, arg AddChirpHashtagParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Chirps[arg.ChirpID]; !ok {
+			return errors.New("hashtag chirp does not exist")
+		}
+		for _, h := range dbStructure.ChirpHashtags {
+			if h.ChirpID == arg.ChirpID && h.Tag == arg.Tag {
+				return nil
+			}
+		}
+		dbStructure.ChirpHashtags = append(dbStructure.ChirpHashtags, ChirpHashtag{
+			ChirpID:   arg.ChirpID,
+			Tag:       arg.Tag,
+			CreatedAt: arg.CreatedAt,
+		})
+		return nil
+	})
+}
+
+func (db *DB) GetChirpsByHashtag(ctx context.Context, arg GetChirpsByHashtagParams) ([]Chirp, error) {
+	var chirps []Chirp
+	err := db.read(func(dbStructure DBStructure) error {
+		hidden := hiddenAuthors(dbStructure, arg.ViewerID)
+		for _, h := range dbStructure.ChirpHashtags {
+			if h.Tag != arg.Tag {
+				continue
+			}
+			chirp, ok := dbStructure.Chirps[h.ChirpID]
+			switch {
+			case !ok || hidden[chirp.UserID]:
+			case chirp.HiddenAt.Valid && chirp.UserID != arg.ViewerID && !arg.ViewerIsAdmin:
+			case arg.AfterCreatedAt.Valid && arg.NewestFirst && !keyBefore(chirp.CreatedAt, chirp.ID, arg.AfterCreatedAt.Time, arg.AfterID.UUID):
+			case arg.AfterCreatedAt.Valid && !arg.NewestFirst && !keyBefore(arg.AfterCreatedAt.Time, arg.AfterID.UUID, chirp.CreatedAt, chirp.ID):
+			default:
+				chirps = append(chirps, chirp)
+			}
+		}
+		return nil
+	})
+	sortChirps(chirps)
+	if arg.NewestFirst {
+		slices.Reverse(chirps)
+	}
+	if len(chirps) > int(arg.MaxChirps) {
+		chirps = chirps[:arg.MaxChirps]
+	}
+	return chirps, err
+}
+
+func (db *DB) GetTrendingHashtags(ctx context.Context, arg GetTrendingHashtagsParams) ([]GetTrendingHashtagsRow, error) {
+	var rows []GetTrendingHashtagsRow
+	err := db.read(func(dbStructure DBStructure) error {
+		uses := map[string]int64{}
+		for _, h := range dbStructure.ChirpHashtags {
+			if !h.CreatedAt.Before(arg.Since) {
+				uses[h.Tag]++
+			}
+		}
+		for tag, n := range uses {
+			rows = append(rows, GetTrendingHashtagsRow{Tag: tag, Uses: n})
+		}
+		return nil
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Uses == rows[j].Uses {
+			return rows[i].Tag < rows[j].Tag
+		}
+		return rows[i].Uses > rows[j].Uses
+	})
+	if len(rows) > int(arg.MaxTags) {
+		rows = rows[:arg.MaxTags]
+	}
+	return rows, err
+}
+
+func (db *DB) AddChirpMention(ctx context.Context, arg AddChirpMentionParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Chirps[arg.ChirpID]; !ok {
+			return errors.New("mention chirp does not exist")
+		}
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("mentioned user does not exist")
+		}
+		m := ChirpMention{ChirpID: arg.ChirpID, UserID: arg.UserID}
+		if !slices.Contains(dbStructure.ChirpMentions, m) {
+			dbStructure.ChirpMentions = append(dbStructure.ChirpMentions, m)
+		}
+		return nil
+	})
+}
+
+// LikeChirp records a like and returns 1, or 0 if the user had already
+// liked the chirp.
+func (db *DB) LikeChirp(ctx context.Context, arg LikeChirpParams) (int64, error) {
+	var n int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Chirps[arg.ChirpID]; !ok {
+			return errors.New("liked chirp does not exist")
+		}
+		for _, l := range dbStructure.ChirpLikes {
+			if l.ChirpID == arg.ChirpID && l.UserID == arg.UserID {
+				return nil
+			}
+		}
+		dbStructure.ChirpLikes = append(dbStructure.ChirpLikes, ChirpLike{
+			ChirpID:   arg.ChirpID,
+			UserID:    arg.UserID,
+			CreatedAt: time.Now().UTC(),
+		})
+		n = 1
+		return nil
+	})
+	return n, err
+}
+
+func (db *DB) UnlikeChirp(ctx context.Context, arg UnlikeChirpParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		dbStructure.ChirpLikes = slices.DeleteFunc(dbStructure.ChirpLikes, func(l ChirpLike) bool {
+			return l.ChirpID == arg.ChirpID && l.UserID == arg.UserID
+		})
+		return nil
+	})
+}
+
+// FollowUser records a follow and returns 1, or 0 if it already existed.
+func (db *DB) FollowUser(ctx context.Context, arg FollowUserParams) (int64, error) {
+	var n int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		if arg.FollowerID == arg.FolloweeID {
+			return errors.New("users can't follow themselves")
+		}
+		if _, ok := dbStructure.Users[arg.FolloweeID]; !ok {
+			return errors.New("followed user does not exist")
+		}
+		for _, f := range dbStructure.Follows {
+			if f.FollowerID == arg.FollowerID && f.FolloweeID == arg.FolloweeID {
+				return nil
+			}
+		}
+		dbStructure.Follows = append(dbStructure.Follows, Follow{
+			FollowerID: arg.FollowerID,
+			FolloweeID: arg.FolloweeID,
+			CreatedAt:  time.Now().UTC(),
+		})
+		n = 1
+		return nil
+	})
+	return n, err
+}
+
+func (db *DB) UnfollowUser(ctx context.Context, arg UnfollowUserParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		dbStructure.Follows = slices.DeleteFunc(dbStructure.Follows, func(f Follow) bool {
+			return f.FollowerID == arg.FollowerID && f.FolloweeID == arg.FolloweeID
+		})
+		return nil
+	})
+}
+
+func (db *DB) CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error) {
+	var notification Notification
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("notified user does not exist")
+		}
+		notification = Notification{
+			ID:        uuid.New(),
+			CreatedAt: time.Now().UTC(),
+			UserID:    arg.UserID,
+			ActorID:   arg.ActorID,
+			Kind:      arg.Kind,
+			ChirpID:   arg.ChirpID,
+		}
+		dbStructure.Notifications[notification.ID] = notification
+		return nil
+	})
+	return notification, err
+}
+
+func (db *DB) GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]Notification, error) {
+	var notifications []Notification
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, notification := range dbStructure.Notifications {
+			if notification.UserID != arg.UserID {
+				continue
+			}
+			if arg.UnreadOnly && notification.ReadAt.Valid {
+				continue
+			}
+			notifications = append(notifications, notification)
+		}
+		return nil
+	})
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	if len(notifications) > int(arg.MaxNotifications) {
+		notifications = notifications[:arg.MaxNotifications]
+	}
+	return notifications, err
+}
+
+func (db *DB) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, notification := range dbStructure.Notifications {
+			if notification.UserID == userID && !notification.ReadAt.Valid {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+func (db *DB) MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		markUserNotificationsRead(dbStructure, arg.UserID, func(n Notification) bool {
+			return slices.Contains(arg.Ids, n.ID)
+		})
+		return nil
+	})
+}
+
+func (db *DB) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		markUserNotificationsRead(dbStructure, userID, func(Notification) bool {
+			return true
+		})
+		return nil
+	})
+}
+
+func markUserNotificationsRead(dbStructure *DBStructure, userID uuid.UUID, match func(Notification) bool) {
+	now := time.Now().UTC()
+	for id, notification := range dbStructure.Notifications {
+		if notification.UserID != userID || notification.ReadAt.Valid || !match(notification) {
+			continue
+		}
+		notification.ReadAt = sql.NullTime{Time: now, Valid: true}
+		dbStructure.Notifications[id] = notification
+	}
+}
+
+// BlockUser records a block and returns 1, or 0 if it already existed.
+func (db *DB) BlockUser(ctx context.Context, arg BlockUserParams) (int64, error) {
+	var n int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		if arg.BlockerID == arg.BlockedID {
+			return errors.New("users can't block themselves")
+		}
+		if _, ok := dbStructure.Users[arg.BlockedID]; !ok {
+			return errors.New("blocked user does not exist")
+		}
+		for _, b := range dbStructure.UserBlocks {
+			if b.BlockerID == arg.BlockerID && b.BlockedID == arg.BlockedID {
+				return nil
+			}
+		}
+		dbStructure.UserBlocks = append(dbStructure.UserBlocks, UserBlock{
+			BlockerID: arg.BlockerID,
+			BlockedID: arg.BlockedID,
+			CreatedAt: time.Now().UTC(),
+		})
+		n = 1
+		return nil
+	})
+	return n, err
+}
+
+// IsBlockedBetween reports whether either user has blocked the other.
+func (db *DB) IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (bool, error) {
+	var blocked bool
+	err := db.read(func(dbStructure DBStructure) error {
+		blocked = slices.ContainsFunc(dbStructure.UserBlocks, func(b UserBlock) bool {
+			return (b.BlockerID == arg.UserA && b.BlockedID == arg.UserB) ||
+				(b.BlockerID == arg.UserB && b.BlockedID == arg.UserA)
+		})
+		return nil
+	})
+	return blocked, err
+}
+
+func (db *DB) UnblockUser(ctx context.Context, arg UnblockUserParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		dbStructure.UserBlocks = slices.DeleteFunc(dbStructure.UserBlocks, func(b UserBlock) bool {
+			return b.BlockerID == arg.BlockerID && b.BlockedID == arg.BlockedID
+		})
+		return nil
+	})
+}
+
+// MuteUser records a mute and returns 1, or 0 if it already existed.
+func (db *DB) MuteUser(ctx context.Context, arg MuteUserParams) (int64, error) {
+	var n int64
+	err := db.update(func(dbStructure *DBStructure) error {
+		if arg.MuterID == arg.MutedID {
+			return errors.New("users can't mute themselves")
+		}
+		if _, ok := dbStructure.Users[arg.MutedID]; !ok {
+			return errors.New("muted user does not exist")
+		}
+		for _, m := range dbStructure.UserMutes {
+			if m.MuterID == arg.MuterID && m.MutedID == arg.MutedID {
+				return nil
+			}
+		}
+		dbStructure.UserMutes = append(dbStructure.UserMutes, UserMute{
+			MuterID:   arg.MuterID,
+			MutedID:   arg.MutedID,
+			CreatedAt: time.Now().UTC(),
+		})
+		n = 1
+		return nil
+	})
+	return n, err
+}
+
+func (db *DB) UnmuteUser(ctx context.Context, arg UnmuteUserParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		dbStructure.UserMutes = slices.DeleteFunc(dbStructure.UserMutes, func(m UserMute) bool {
+			return m.MuterID == arg.MuterID && m.MutedID == arg.MutedID
+		})
+		return nil
+	})
+}
+
+func (db *DB) GetHiddenAuthorIDs(ctx context.Context, muterID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.read(func(dbStructure DBStructure) error {
+		for id := range hiddenAuthors(dbStructure, muterID) {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// hiddenAuthors is the set of users whose chirps viewerID doesn't see:
+// those they muted or blocked and those who blocked them.
+func hiddenAuthors(dbStructure DBStructure, viewerID uuid.UUID) map[uuid.UUID]bool {
+	hidden := map[uuid.UUID]bool{}
+	for _, m := range dbStructure.UserMutes {
+		if m.MuterID == viewerID {
+			hidden[m.MutedID] = true
+		}
+	}
+	for _, b := range dbStructure.UserBlocks {
+		if b.BlockerID == viewerID {
+			hidden[b.BlockedID] = true
+		}
+		if b.BlockedID == viewerID {
+			hidden[b.BlockerID] = true
+		}
+	}
+	return hidden
+}
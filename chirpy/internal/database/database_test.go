@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := NewDB(filepath.Join(t.TempDir(), "database.json"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	return db
+}
+
+func TestDBRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	user, err := db.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := db.CreateUser(ctx, CreateUserParams{Email: "a@example.com"}); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("CreateUser() duplicate email error = %v, want %v", err, ErrEmailTaken)
+	}
+
+	chirp, err := db.CreateChirp(ctx, CreateChirpParams{Body: "hello", UserID: user.ID})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+
+	reopened, err := NewDB(db.path)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	got, err := reopened.GetChirp(ctx, chirp.ID)
+	if err != nil {
+		t.Fatalf("GetChirp() error = %v", err)
+	}
+	if got.Body != "hello" || got.UserID != user.ID {
+		t.Errorf("GetChirp() = %+v, want body %q by %v", got, "hello", user.ID)
+	}
+
+	if err := reopened.DeleteChirp(ctx, chirp.ID); err != nil {
+		t.Fatalf("DeleteChirp() error = %v", err)
+	}
+	if _, err := reopened.GetChirp(ctx, chirp.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetChirp() after delete error = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+func TestDBRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	user, _ := db.CreateUser(ctx, CreateUserParams{Email: "a@example.com"})
+	_, err := db.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		Token:     "live",
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateRefreshToken() error = %v", err)
+	}
+	db.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		Token:     "expired",
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+
+	if got, err := db.GetUserFromRefreshToken(ctx, "live"); err != nil || got.ID != user.ID {
+		t.Errorf("GetUserFromRefreshToken(live) = %v, %v", got.ID, err)
+	}
+	if _, err := db.GetUserFromRefreshToken(ctx, "expired"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserFromRefreshToken(expired) error = %v, want %v", err, sql.ErrNoRows)
+	}
+
+	if _, err := db.RevokeRefreshToken(ctx, "live"); err != nil {
+		t.Fatalf("RevokeRefreshToken() error = %v", err)
+	}
+	if _, err := db.GetUserFromRefreshToken(ctx, "live"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserFromRefreshToken(revoked) error = %v, want %v", err, sql.ErrNoRows)
+	}
+}
+
+func TestDBCrashBeforeRenameKeepsOldData(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	user, _ := db.CreateUser(ctx, CreateUserParams{Email: "a@example.com"})
+	before, err := os.ReadFile(db.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crash := errors.New("simulated crash")
+	beforeRename = func(string) error { return crash }
+	t.Cleanup(func() { beforeRename = nil })
+
+	if _, err := db.CreateChirp(ctx, CreateChirpParams{Body: "lost", UserID: user.ID}); !errors.Is(err, crash) {
+		t.Fatalf("CreateChirp() error = %v, want %v", err, crash)
+	}
+
+	after, err := os.ReadFile(db.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("database file changed by an interrupted write")
+	}
+	chirps, err := db.GetChirps(ctx, uuid.Nil)
+	if err != nil {
+		t.Fatalf("GetChirps() error = %v", err)
+	}
+	if len(chirps) != 0 {
+		t.Errorf("GetChirps() returned %d chirps, want 0", len(chirps))
+	}
+	assertNoTempFiles(t, db.path)
+}
+
+func TestDBIgnoresLeftoverTempFile(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	db.CreateUser(ctx, CreateUserParams{Email: "a@example.com"})
+
+	// A process killed mid-write leaves a truncated temp file behind.
+	stale := db.path + ".tmp-123"
+	if err := os.WriteFile(stale, []byte(`{"users":{"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewDB(db.path)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	if _, err := reopened.GetUserByEmail(ctx, "a@example.com"); err != nil {
+		t.Errorf("GetUserByEmail() error = %v", err)
+	}
+}
+
+func TestDBCorruptFileIsNotOverwritten(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "database.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewDB(path)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	if _, err := db.CreateUser(ctx, CreateUserParams{Email: "a@example.com"}); err == nil {
+		t.Fatalf("CreateUser() on corrupt database succeeded")
+	}
+
+	dat, _ := os.ReadFile(path)
+	if string(dat) != "{not json" {
+		t.Errorf("corrupt database file was overwritten: %q", dat)
+	}
+}
+
+func TestDBConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	user, _ := db.CreateUser(ctx, CreateUserParams{Email: "a@example.com"})
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := db.CreateChirp(ctx, CreateChirpParams{Body: "hi", UserID: user.ID}); err != nil {
+				t.Errorf("CreateChirp() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	chirps, err := db.GetChirps(ctx, uuid.Nil)
+	if err != nil {
+		t.Fatalf("GetChirps() error = %v", err)
+	}
+	if len(chirps) != n {
+		t.Errorf("GetChirps() returned %d chirps, want %d", len(chirps), n)
+	}
+	assertNoTempFiles(t, db.path)
+}
+
+func assertNoTempFiles(t *testing.T, path string) {
+	t.Helper()
+	matches, err := filepath.Glob(path + ".tmp-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("temp files left behind: %v", matches)
+	}
+}
+
+func TestDBInTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	user, err := db.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	errAbort := errors.New("abort")
+	err = db.InTx(ctx, func(tx Store) error {
+		if _, err := tx.CreateChirp(ctx, CreateChirpParams{Body: "hello", UserID: user.ID}); err != nil {
+			return err
+		}
+		chirps, err := tx.GetChirps(ctx, uuid.Nil)
+		if err != nil || len(chirps) != 1 {
+			t.Errorf("GetChirps() inside the transaction = %v, %v, want the new chirp", chirps, err)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("InTx() error = %v, want %v", err, errAbort)
+	}
+	if chirps, _ := db.GetChirps(ctx, uuid.Nil); len(chirps) != 0 {
+		t.Errorf("GetChirps() after rollback = %v, want none", chirps)
+	}
+
+	err = db.InTx(ctx, func(tx Store) error {
+		_, err := tx.CreateChirp(ctx, CreateChirpParams{Body: "hello", UserID: user.ID})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("InTx() error = %v", err)
+	}
+	if chirps, _ := db.GetChirps(ctx, uuid.Nil); len(chirps) != 1 {
+		t.Errorf("GetChirps() after commit = %v, want the new chirp", chirps)
+	}
+}
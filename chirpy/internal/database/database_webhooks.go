@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (db *DB) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	var endpoint WebhookEndpoint
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.Users[arg.UserID]; !ok {
+			return errors.New("webhook endpoint owner does not exist")
+		}
+		now := time.Now().UTC()
+		endpoint = WebhookEndpoint{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserID:    arg.UserID,
+			Url:       arg.Url,
+			Secret:    arg.Secret,
+			Events:    slices.Clone(arg.Events),
+			IsGlobal:  arg.IsGlobal,
+		}
+		dbStructure.WebhookEndpoints[endpoint.ID] = endpoint
+		return nil
+	})
+	return endpoint, err
+}
+
+func (db *DB) GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error) {
+	var endpoint WebhookEndpoint
+	err := db.read(func(dbStructure DBStructure) error {
+		e, ok := dbStructure.WebhookEndpoints[id]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		endpoint = e
+		return nil
+	})
+	return endpoint, err
+}
+
+func (db *DB) GetWebhookEndpointsByUser(ctx context.Context, userID uuid.UUID) ([]WebhookEndpoint, error) {
+	var endpoints []WebhookEndpoint
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, endpoint := range dbStructure.WebhookEndpoints {
+			if endpoint.UserID == userID {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+		return nil
+	})
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].CreatedAt.Before(endpoints[j].CreatedAt)
+	})
+	return endpoints, err
+}
+
+// DeleteWebhookEndpoint deletes an endpoint along with its deliveries and
+// their attempts.
+func (db *DB) DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		removeWebhookEndpoint(dbStructure, id)
+		return nil
+	})
+}
+
+func (db *DB) DeleteWebhookEndpointsByUser(ctx context.Context, userID uuid.UUID) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		for id, endpoint := range dbStructure.WebhookEndpoints {
+			if endpoint.UserID == userID {
+				removeWebhookEndpoint(dbStructure, id)
+			}
+		}
+		return nil
+	})
+}
+
+func removeWebhookEndpoint(dbStructure *DBStructure, id uuid.UUID) {
+	delete(dbStructure.WebhookEndpoints, id)
+	for deliveryID, delivery := range dbStructure.WebhookDeliveries {
+		if delivery.EndpointID != id {
+			continue
+		}
+		delete(dbStructure.WebhookDeliveries, deliveryID)
+		for attemptID, attempt := range dbStructure.WebhookAttempts {
+			if attempt.DeliveryID == deliveryID {
+				delete(dbStructure.WebhookAttempts, attemptID)
+			}
+		}
+	}
+}
+
+// EnqueueWebhookDeliveries queues a delivery of an event to every endpoint
+// subscribed to its type that is either global or owned by the event's
+// subject.
+func (db *DB) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) error {
+	return db.update(func(dbStructure *DBStructure) error {
+		now := time.Now().UTC()
+		for _, endpoint := range dbStructure.WebhookEndpoints {
+			if !slices.Contains(endpoint.Events, arg.EventType) {
+				continue
+			}
+			if !endpoint.IsGlobal && endpoint.UserID != arg.SubjectID {
+				continue
+			}
+			delivery := WebhookDelivery{
+				ID:            uuid.New(),
+				CreatedAt:     now,
+				UpdatedAt:     now,
+				EndpointID:    endpoint.ID,
+				EventID:       arg.EventID,
+				EventType:     arg.EventType,
+				Payload:       arg.Payload,
+				Status:        "pending",
+				NextAttemptAt: now,
+			}
+			dbStructure.WebhookDeliveries[delivery.ID] = delivery
+		}
+		return nil
+	})
+}
+
+// ClaimWebhookDeliveries leases up to MaxDeliveries pending deliveries
+// that are due at Now by pushing their next attempt out to LeaseUntil.
+func (db *DB) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	var rows []ClaimWebhookDeliveriesRow
+	err := db.update(func(dbStructure *DBStructure) error {
+		var due []WebhookDelivery
+		for _, delivery := range dbStructure.WebhookDeliveries {
+			if delivery.Status == "pending" && !delivery.NextAttemptAt.After(arg.Now) {
+				due = append(due, delivery)
+			}
+		}
+		sort.Slice(due, func(i, j int) bool {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		})
+		if len(due) > int(arg.MaxDeliveries) {
+			due = due[:arg.MaxDeliveries]
+		}
+
+		now := time.Now().UTC()
+		for _, delivery := range due {
+			endpoint, ok := dbStructure.WebhookEndpoints[delivery.EndpointID]
+			if !ok {
+				continue
+			}
+			delivery.NextAttemptAt = arg.LeaseUntil
+			delivery.UpdatedAt = now
+			dbStructure.WebhookDeliveries[delivery.ID] = delivery
+			rows = append(rows, ClaimWebhookDeliveriesRow{
+				ID:            delivery.ID,
+				CreatedAt:     delivery.CreatedAt,
+				UpdatedAt:     delivery.UpdatedAt,
+				EndpointID:    delivery.EndpointID,
+				EventID:       delivery.EventID,
+				EventType:     delivery.EventType,
+				Payload:       delivery.Payload,
+				Status:        delivery.Status,
+				Attempts:      delivery.Attempts,
+				NextAttemptAt: delivery.NextAttemptAt,
+				Url:           endpoint.Url,
+				Secret:        endpoint.Secret,
+			})
+		}
+		return nil
+	})
+	return rows, err
+}
+
+func (db *DB) RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) (WebhookAttempt, error) {
+	var attempt WebhookAttempt
+	err := db.update(func(dbStructure *DBStructure) error {
+		if _, ok := dbStructure.WebhookDeliveries[arg.DeliveryID]; !ok {
+			return errors.New("webhook delivery does not exist")
+		}
+		attempt = WebhookAttempt{
+			ID:           uuid.New(),
+			CreatedAt:    time.Now().UTC(),
+			DeliveryID:   arg.DeliveryID,
+			StatusCode:   arg.StatusCode,
+			ResponseBody: arg.ResponseBody,
+			Error:        arg.Error,
+			DurationMs:   arg.DurationMs,
+		}
+		dbStructure.WebhookAttempts[attempt.ID] = attempt
+		return nil
+	})
+	return attempt, err
+}
+
+func (db *DB) UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := db.update(func(dbStructure *DBStructure) error {
+		d, ok := dbStructure.WebhookDeliveries[arg.ID]
+		if !ok {
+			return sql.ErrNoRows
+		}
+		d.Status = arg.Status
+		d.Attempts = arg.Attempts
+		d.NextAttemptAt = arg.NextAttemptAt
+		d.UpdatedAt = time.Now().UTC()
+		dbStructure.WebhookDeliveries[d.ID] = d
+		delivery = d
+		return nil
+	})
+	return delivery, err
+}
+
+func (db *DB) GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, delivery := range dbStructure.WebhookDeliveries {
+			if delivery.EndpointID == arg.EndpointID {
+				deliveries = append(deliveries, delivery)
+			}
+		}
+		return nil
+	})
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if len(deliveries) > int(arg.Limit) {
+		deliveries = deliveries[:arg.Limit]
+	}
+	return deliveries, err
+}
+
+func (db *DB) GetWebhookAttempts(ctx context.Context, deliveryIds []uuid.UUID) ([]WebhookAttempt, error) {
+	var attempts []WebhookAttempt
+	err := db.read(func(dbStructure DBStructure) error {
+		for _, attempt := range dbStructure.WebhookAttempts {
+			if slices.Contains(deliveryIds, attempt.DeliveryID) {
+				attempts = append(attempts, attempt)
+			}
+		}
+		return nil
+	})
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].CreatedAt.Before(attempts[j].CreatedAt)
+	})
+	return attempts, err
+}
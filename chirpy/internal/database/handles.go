@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Profile rules. Postgres enforces them with the constraints in
+// sql/schema/013_profiles.sql; the file-backed DB checks them itself.
+const (
+	MaxDisplayNameLength = 50
+	MaxBioLength         = 160
+)
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// ReservedHandles can't be claimed by users, whatever their case.
+var ReservedHandles = []string{
+	"admin", "administrator", "api", "app", "assets", "chirpy", "help",
+	"me", "mod", "moderator", "null", "root", "settings", "support",
+	"system", "undefined",
+}
+
+var (
+	// ErrHandleTaken is returned when a user would be given a handle that
+	// another user already has in any case.
+	ErrHandleTaken = errors.New("handle is already in use")
+	// ErrInvalidProfile is wrapped by errors for profiles that break the
+	// schema's rules.
+	ErrInvalidProfile = errors.New("invalid profile")
+)
+
+// CheckHandle returns a description of what is wrong with handle, or ""
+// if it is valid.
+func CheckHandle(handle string) string {
+	if !handlePattern.MatchString(handle) {
+		return "must be 3 to 30 letters, digits or underscores"
+	}
+	if slices.Contains(ReservedHandles, strings.ToLower(handle)) {
+		return "is reserved"
+	}
+	return ""
+}
+
+func checkProfile(arg UpdateUserProfileParams) error {
+	if arg.Handle.Valid {
+		if problem := CheckHandle(arg.Handle.String); problem != "" {
+			return errors.Join(ErrInvalidProfile, errors.New("handle "+problem))
+		}
+	}
+	if utf8.RuneCountInString(arg.DisplayName) > MaxDisplayNameLength {
+		return errors.Join(ErrInvalidProfile, errors.New("display name is too long"))
+	}
+	if utf8.RuneCountInString(arg.Bio) > MaxBioLength {
+		return errors.Join(ErrInvalidProfile, errors.New("bio is too long"))
+	}
+	if arg.AvatarUrl != "" && !strings.HasPrefix(arg.AvatarUrl, "http://") && !strings.HasPrefix(arg.AvatarUrl, "https://") {
+		return errors.Join(ErrInvalidProfile, errors.New("avatar URL must be http or https"))
+	}
+	return nil
+}
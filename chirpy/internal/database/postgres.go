@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Postgres is the Postgres-backed Store. It embeds the sqlc generated
+// queries and adds transactions on top of them.
+type Postgres struct {
+	*Queries
+	conn *sql.DB
+	inTx bool
+}
+
+// NewPostgres returns a Store that runs its queries on conn.
+func NewPostgres(conn *sql.DB) *Postgres {
+	return &Postgres{
+		Queries: New(conn),
+		conn:    conn,
+	}
+}
+
+// NewPostgresWithReplica returns a Store that writes to primary and, in
+// contexts from WithReplicaReads, reads from replica. Transactions always
+// run on primary.
+func NewPostgresWithReplica(primary, replica *sql.DB) *Postgres {
+	return &Postgres{
+		Queries: New(&routedDB{primary: primary, replica: replica}),
+		conn:    primary,
+	}
+}
+
+// InTx runs fn in a transaction, committing if it returns nil and rolling
+// back otherwise. Calls nested inside fn join the outer transaction.
+func (p *Postgres) InTx(ctx context.Context, fn func(Store) error) error {
+	if p.inTx {
+		return fn(p)
+	}
+	// Transactions run on the primary and usually write, so later reads
+	// must not go to a replica that hasn't caught up.
+	ReadFromPrimary(ctx)
+
+	tx, err := p.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Postgres{Queries: p.WithTx(tx), conn: p.conn, inTx: true}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateUser reports a clash on the email's unique index as
+// ErrEmailTaken.
+func (p *Postgres) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	user, err := p.Queries.CreateUser(ctx, arg)
+	if isEmailTaken(err) {
+		return User{}, ErrEmailTaken
+	}
+	return user, err
+}
+
+// UpdateUser reports a clash on the email's unique index as
+// ErrEmailTaken.
+func (p *Postgres) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	user, err := p.Queries.UpdateUser(ctx, arg)
+	if isEmailTaken(err) {
+		return User{}, ErrEmailTaken
+	}
+	return user, err
+}
+
+// isEmailTaken reports whether err is a unique violation on users.email.
+func isEmailTaken(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_email_key"
+}
+
+// UpdateUserProfile reports a clash on the handle's unique index as
+// ErrHandleTaken and other constraint violations as ErrInvalidProfile.
+func (p *Postgres) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	user, err := p.Queries.UpdateUserProfile(ctx, arg)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "23505" && pqErr.Constraint == "users_handle_lower_idx":
+			return User{}, ErrHandleTaken
+		case pqErr.Code == "23514":
+			return User{}, errors.Join(ErrInvalidProfile, err)
+		}
+	}
+	return user, err
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Store is the set of storage operations chirpy's handlers depend on.
+// It is satisfied by *Postgres and by the file-backed *DB.
+type Store interface {
+	CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error)
+	DeleteChirp(ctx context.Context, id uuid.UUID) error
+	GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error)
+	GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error)
+	GetChirps(ctx context.Context, viewerID uuid.UUID) ([]Chirp, error)
+	GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error)
+	GetChirpsByUserPage(ctx context.Context, arg GetChirpsByUserPageParams) ([]Chirp, error)
+	ImportChirps(ctx context.Context, arg ImportChirpsParams) ([]Chirp, error)
+	PublishScheduledChirp(ctx context.Context, arg PublishScheduledChirpParams) (Chirp, error)
+	SetChirpHidden(ctx context.Context, arg SetChirpHiddenParams) (Chirp, error)
+
+	CreateChirpReport(ctx context.Context, arg CreateChirpReportParams) (int64, error)
+	GetModerationQueue(ctx context.Context, maxChirps int32) ([]GetModerationQueueRow, error)
+	ResolveChirpReports(ctx context.Context, arg ResolveChirpReportsParams) (int64, error)
+
+	CreateScheduledChirp(ctx context.Context, arg CreateScheduledChirpParams) (ScheduledChirp, error)
+	GetScheduledChirp(ctx context.Context, id uuid.UUID) (ScheduledChirp, error)
+	GetScheduledChirpForUpdate(ctx context.Context, id uuid.UUID) (ScheduledChirp, error)
+	GetScheduledChirpsByUser(ctx context.Context, userID uuid.UUID) ([]ScheduledChirp, error)
+	UpdateScheduledChirp(ctx context.Context, arg UpdateScheduledChirpParams) (ScheduledChirp, error)
+	DeleteScheduledChirp(ctx context.Context, id uuid.UUID) (int64, error)
+	DeleteScheduledChirpsByUser(ctx context.Context, userID uuid.UUID) error
+	ClaimDueScheduledChirps(ctx context.Context, arg ClaimDueScheduledChirpsParams) ([]ScheduledChirp, error)
+	MarkScheduledChirpFailed(ctx context.Context, arg MarkScheduledChirpFailedParams) error
+
+	AddChirpHashtag(ctx context.Context, arg AddChirpHashtagParams) error
+	GetChirpsByHashtag(ctx context.Context, arg GetChirpsByHashtagParams) ([]Chirp, error)
+	GetTrendingHashtags(ctx context.Context, arg GetTrendingHashtagsParams) ([]GetTrendingHashtagsRow, error)
+	AddChirpMention(ctx context.Context, arg AddChirpMentionParams) error
+	QueueLinkPreview(ctx context.Context, arg QueueLinkPreviewParams) error
+	AddChirpLink(ctx context.Context, arg AddChirpLinkParams) error
+	ClaimLinkPreviews(ctx context.Context, arg ClaimLinkPreviewsParams) ([]LinkPreview, error)
+	UpdateLinkPreview(ctx context.Context, arg UpdateLinkPreviewParams) (LinkPreview, error)
+	TouchLinkingChirps(ctx context.Context, url string) error
+	GetChirpLinkPreviews(ctx context.Context, chirpIds []uuid.UUID) ([]GetChirpLinkPreviewsRow, error)
+
+	BookmarkChirp(ctx context.Context, arg BookmarkChirpParams) error
+	UnbookmarkChirp(ctx context.Context, arg UnbookmarkChirpParams) error
+	GetBookmarks(ctx context.Context, arg GetBookmarksParams) ([]GetBookmarksRow, error)
+	LikeChirp(ctx context.Context, arg LikeChirpParams) (int64, error)
+	UnlikeChirp(ctx context.Context, arg UnlikeChirpParams) error
+
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserByHandle(ctx context.Context, handle string) (User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	GetUserByIdentity(ctx context.Context, arg GetUserByIdentityParams) (User, error)
+	GetUserByIDForUpdate(ctx context.Context, id uuid.UUID) (User, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	SetUserPassword(ctx context.Context, arg SetUserPasswordParams) (User, error)
+	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error)
+	UpgradeToChirpyRed(ctx context.Context, id uuid.UUID) (User, error)
+	SetUserAdmin(ctx context.Context, arg SetUserAdminParams) (User, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	AnonymizeUser(ctx context.Context, id uuid.UUID) (User, error)
+	AddUserStrike(ctx context.Context, id uuid.UUID) (User, error)
+	SuspendUser(ctx context.Context, arg SuspendUserParams) (User, error)
+
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	GetUserIdentitiesByUser(ctx context.Context, userID uuid.UUID) ([]UserIdentity, error)
+	DeleteUserIdentitiesByUser(ctx context.Context, userID uuid.UUID) error
+
+	CreateSubscriptionEvent(ctx context.Context, arg CreateSubscriptionEventParams) (SubscriptionEvent, error)
+	GetSubscriptionEventsByUser(ctx context.Context, userID uuid.UUID) ([]SubscriptionEvent, error)
+
+	FollowUser(ctx context.Context, arg FollowUserParams) (int64, error)
+	UnfollowUser(ctx context.Context, arg UnfollowUserParams) error
+
+	BlockUser(ctx context.Context, arg BlockUserParams) (int64, error)
+	UnblockUser(ctx context.Context, arg UnblockUserParams) error
+	IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (bool, error)
+	MuteUser(ctx context.Context, arg MuteUserParams) (int64, error)
+	UnmuteUser(ctx context.Context, arg UnmuteUserParams) error
+	GetHiddenAuthorIDs(ctx context.Context, muterID uuid.UUID) ([]uuid.UUID, error)
+
+	CreateUserList(ctx context.Context, arg CreateUserListParams) (UserList, error)
+	GetUserList(ctx context.Context, arg GetUserListParams) (UserList, error)
+	GetUserListsByOwner(ctx context.Context, ownerID uuid.UUID) ([]UserList, error)
+	DeleteUserList(ctx context.Context, arg DeleteUserListParams) (int64, error)
+	AddUserListMember(ctx context.Context, arg AddUserListMemberParams) (int64, error)
+	RemoveUserListMember(ctx context.Context, arg RemoveUserListMemberParams) error
+	GetUserListMembers(ctx context.Context, listIds []uuid.UUID) ([]UserListMember, error)
+	GetUserListChirps(ctx context.Context, arg GetUserListChirpsParams) ([]Chirp, error)
+
+	CreateConversation(ctx context.Context, arg CreateConversationParams) (Conversation, error)
+	GetConversationBetween(ctx context.Context, arg GetConversationBetweenParams) (Conversation, error)
+	GetConversationForParticipant(ctx context.Context, arg GetConversationForParticipantParams) (Conversation, error)
+	GetConversationsByUser(ctx context.Context, arg GetConversationsByUserParams) ([]GetConversationsByUserRow, error)
+	CountUnreadMessages(ctx context.Context, arg CountUnreadMessagesParams) (int64, error)
+	SetConversationUpdatedAt(ctx context.Context, arg SetConversationUpdatedAtParams) error
+	AddConversationParticipant(ctx context.Context, arg AddConversationParticipantParams) error
+	GetConversationParticipants(ctx context.Context, conversationIds []uuid.UUID) ([]ConversationParticipant, error)
+	MarkConversationRead(ctx context.Context, arg MarkConversationReadParams) (ConversationParticipant, error)
+	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	GetMessage(ctx context.Context, arg GetMessageParams) (Message, error)
+	GetMessages(ctx context.Context, arg GetMessagesParams) ([]Message, error)
+	GetMessagesBySender(ctx context.Context, senderID uuid.UUID) ([]Message, error)
+
+	CreateNotification(ctx context.Context, arg CreateNotificationParams) (Notification, error)
+	GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]Notification, error)
+	CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error)
+	MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) error
+	MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) error
+
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	GetUserFromRefreshToken(ctx context.Context, token string) (User, error)
+	RevokeRefreshToken(ctx context.Context, token string) (RefreshToken, error)
+	GetRefreshTokensByUser(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error)
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	ExpireUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error
+
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (WebhookEndpoint, error)
+	GetWebhookEndpointsByUser(ctx context.Context, userID uuid.UUID) ([]WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) error
+	DeleteWebhookEndpointsByUser(ctx context.Context, userID uuid.UUID) error
+	EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) error
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error)
+	RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) (WebhookAttempt, error)
+	UpdateWebhookDelivery(ctx context.Context, arg UpdateWebhookDeliveryParams) (WebhookDelivery, error)
+	GetWebhookDeliveries(ctx context.Context, arg GetWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	GetWebhookAttempts(ctx context.Context, deliveryIds []uuid.UUID) ([]WebhookAttempt, error)
+
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error
+	GetAuditEvents(ctx context.Context, arg GetAuditEventsParams) ([]AuditEvent, error)
+
+	Reset(ctx context.Context) error
+
+	// InTx runs fn against a Store whose changes are committed together
+	// if fn returns nil and discarded otherwise. fn must make all its
+	// calls through that Store, never through the one InTx was called
+	// on: the file-backed DB holds its lock for the whole of fn, so such
+	// a call deadlocks, and on Postgres it would run outside the
+	// transaction.
+	InTx(ctx context.Context, fn func(Store) error) error
+}
+
+var (
+	_ Store = (*Postgres)(nil)
+	_ Store = (*DB)(nil)
+)
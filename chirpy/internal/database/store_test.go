@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// forEachStore runs test against a fresh file-backed DB and, when
+// CHIRPY_TEST_DB_URL points at a Postgres database, against a fresh
+// schema in it, so the two stores can be held to the same behavior.
+func forEachStore(t *testing.T, test func(t *testing.T, store Store)) {
+	t.Run("json", func(t *testing.T) {
+		test(t, newTestDB(t))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		test(t, newTestPostgres(t))
+	})
+}
+
+// newTestPostgres migrates a scratch schema in the database at
+// CHIRPY_TEST_DB_URL, dropped when the test ends, and returns a Postgres
+// store using it.
+func newTestPostgres(t *testing.T) *Postgres {
+	t.Helper()
+	dbURL := os.Getenv("CHIRPY_TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("CHIRPY_TEST_DB_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := "chirpy_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	conn, err := sql.Open("postgres", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	migrations, err := filepath.Glob("../../sql/schema/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range migrations {
+		migration, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(migration), "-- +goose Down")
+		if _, err := conn.Exec(up); err != nil {
+			t.Fatalf("applying %s: %v", filepath.Base(path), err)
+		}
+	}
+	return NewPostgres(conn)
+}
+
+func TestAnonymizeUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		user, err := store.CreateUser(ctx, CreateUserParams{Email: "alice@example.com", HashedPassword: "hash"})
+		if err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		_, err = store.UpdateUserProfile(ctx, UpdateUserProfileParams{
+			ID:          user.ID,
+			Handle:      sql.NullString{String: "alice", Valid: true},
+			DisplayName: "Alice",
+			Bio:         "hello",
+			AvatarUrl:   "https://example.com/alice.png",
+		})
+		if err != nil {
+			t.Fatalf("UpdateUserProfile() error = %v", err)
+		}
+		if _, err := store.UpgradeToChirpyRed(ctx, user.ID); err != nil {
+			t.Fatalf("UpgradeToChirpyRed() error = %v", err)
+		}
+		if _, err := store.SetUserAdmin(ctx, SetUserAdminParams{ID: user.ID, IsAdmin: true}); err != nil {
+			t.Fatalf("SetUserAdmin() error = %v", err)
+		}
+		if _, err := store.AddUserStrike(ctx, user.ID); err != nil {
+			t.Fatalf("AddUserStrike() error = %v", err)
+		}
+		_, err = store.SuspendUser(ctx, SuspendUserParams{
+			ID:             user.ID,
+			SuspendedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("SuspendUser() error = %v", err)
+		}
+
+		if _, err := store.AnonymizeUser(ctx, user.ID); err != nil {
+			t.Fatalf("AnonymizeUser() error = %v", err)
+		}
+		got, err := store.GetUserByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID() error = %v", err)
+		}
+		if !got.DeletedAt.Valid {
+			t.Errorf("DeletedAt = %v, want it set", got.DeletedAt)
+		}
+		// Only the ID and when the account was made are left.
+		got.CreatedAt, got.UpdatedAt, got.DeletedAt = time.Time{}, time.Time{}, sql.NullTime{}
+		want := User{ID: user.ID, Email: "deleted-" + user.ID.String() + "@users.invalid"}
+		if got != want {
+			t.Errorf("GetUserByID() = %+v, want %+v", got, want)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+// Package events fans chirp and direct message activity out to live
+// subscribers, such as the server-sent events stream.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeChirpCreated     = "chirp.created"
+	TypeChirpDeleted     = "chirp.deleted"
+	TypeMessageCreated   = "message.created"
+	TypeConversationRead = "conversation.read"
+)
+
+// Event is something that happened to a chirp or in a conversation. IDs
+// increase in the order events are published, so subscribers can resume
+// after the last one they saw.
+type Event struct {
+	ID       int64     `json:"id"`
+	Type     string    `json:"type"`
+	AuthorID uuid.UUID `json:"author_id"`
+	// Recipients makes the event private to those users. Events without
+	// recipients are public.
+	Recipients []uuid.UUID     `json:"recipients,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// VisibleTo reports whether userID, which is uuid.Nil for anonymous
+// subscribers, may receive e.
+func (e Event) VisibleTo(userID uuid.UUID) bool {
+	return len(e.Recipients) == 0 || (userID != uuid.Nil && slices.Contains(e.Recipients, userID))
+}
+
+// Publisher publishes events to every subscriber.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// historySize is how many recent events a Broker keeps for subscribers
+// resuming with a Last-Event-ID.
+const historySize = 1024
+
+// subscriptionBuffer is how many events a subscriber may fall behind by
+// before it is disconnected.
+const subscriptionBuffer = 64
+
+// Broker is an in-process Publisher. On its own it only reaches
+// subscribers in the same process; PostgresBridge feeds it events from
+// every replica.
+type Broker struct {
+	mu      sync.Mutex
+	lastID  int64
+	history []Event
+	subs    map[*Subscription]struct{}
+}
+
+// NewBroker returns a Broker with no subscribers.
+func NewBroker() *Broker {
+	return &Broker{
+		subs: map[*Subscription]struct{}{},
+	}
+}
+
+// Subscription receives events from a Broker on C. C is closed when the
+// subscription is closed or when the subscriber falls too far behind, in
+// which case it should resubscribe from the last event it handled.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter func(Event) bool
+	broker *Broker
+}
+
+// Publish assigns e the next ID if it doesn't have one, records it and
+// sends it to every matching subscriber.
+func (b *Broker) Publish(ctx context.Context, e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e.ID == 0 {
+		e.ID = b.lastID + 1
+	}
+	if e.ID > b.lastID {
+		b.lastID = e.ID
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for sub := range b.subs {
+		if !sub.matches(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			b.closeLocked(sub)
+		}
+	}
+	return nil
+}
+
+// Subscribe starts a subscription to events matching filter, which may be
+// nil to receive everything. If afterID is positive, the events after it
+// that the Broker still remembers are returned so the caller can send
+// them before anything received on the subscription.
+func (b *Broker) Subscribe(afterID int64, filter func(Event) bool) (*Subscription, []Event) {
+	ch := make(chan Event, subscriptionBuffer)
+	sub := &Subscription{
+		C:      ch,
+		ch:     ch,
+		filter: filter,
+		broker: b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	missed := []Event{}
+	// An ID from the future was handed out before a restart, so there's
+	// nothing we can replay.
+	if afterID > 0 && afterID <= b.lastID {
+		for _, e := range b.history {
+			if e.ID > afterID && sub.matches(e) {
+				missed = append(missed, e)
+			}
+		}
+	}
+	b.subs[sub] = struct{}{}
+	return sub, missed
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.closeLocked(s)
+}
+
+func (s *Subscription) matches(e Event) bool {
+	return s.filter == nil || s.filter(e)
+}
+
+func (b *Broker) closeLocked(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
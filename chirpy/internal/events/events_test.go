@@ -0,0 +1,89 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBrokerResumeAndFilter(t *testing.T) {
+	ctx := context.Background()
+	broker := NewBroker()
+	alice, bob := uuid.New(), uuid.New()
+
+	broker.Publish(ctx, Event{Type: TypeChirpCreated, AuthorID: alice})
+	broker.Publish(ctx, Event{Type: TypeChirpCreated, AuthorID: bob})
+	broker.Publish(ctx, Event{Type: TypeChirpDeleted, AuthorID: alice})
+
+	onlyAlice := func(e Event) bool { return e.AuthorID == alice }
+	sub, missed := broker.Subscribe(1, onlyAlice)
+	defer sub.Close()
+
+	if len(missed) != 1 || missed[0].ID != 3 || missed[0].Type != TypeChirpDeleted {
+		t.Fatalf("Subscribe(1) missed = %+v, want only event 3", missed)
+	}
+
+	broker.Publish(ctx, Event{Type: TypeChirpCreated, AuthorID: bob})
+	broker.Publish(ctx, Event{Type: TypeChirpCreated, AuthorID: alice})
+	e := <-sub.C
+	if e.ID != 5 || e.AuthorID != alice {
+		t.Errorf("received %+v, want event 5 by alice", e)
+	}
+}
+
+func TestBrokerUnknownLastEventID(t *testing.T) {
+	broker := NewBroker()
+	broker.Publish(context.Background(), Event{Type: TypeChirpCreated})
+
+	sub, missed := broker.Subscribe(100, nil)
+	defer sub.Close()
+	if len(missed) != 0 {
+		t.Errorf("Subscribe() with an ID from before a restart replayed %d events", len(missed))
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	ctx := context.Background()
+	broker := NewBroker()
+	sub, _ := broker.Subscribe(0, nil)
+
+	for i := 0; i < subscriptionBuffer+1; i++ {
+		broker.Publish(ctx, Event{Type: TypeChirpCreated})
+	}
+
+	received := 0
+	for range sub.C {
+		received++
+	}
+	if received != subscriptionBuffer {
+		t.Errorf("received %d events before being dropped, want %d", received, subscriptionBuffer)
+	}
+	sub.Close()
+}
+
+func TestEventVisibleTo(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+	public := Event{Type: TypeChirpCreated, AuthorID: alice}
+	private := Event{Type: TypeMessageCreated, AuthorID: alice, Recipients: []uuid.UUID{alice, bob}}
+
+	tests := []struct {
+		name   string
+		event  Event
+		userID uuid.UUID
+		want   bool
+	}{
+		{"public to anyone", public, uuid.New(), true},
+		{"public to anonymous", public, uuid.Nil, true},
+		{"private to a recipient", private, bob, true},
+		{"private to someone else", private, uuid.New(), false},
+		{"private to anonymous", private, uuid.Nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.VisibleTo(tt.userID); got != tt.want {
+				t.Errorf("VisibleTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
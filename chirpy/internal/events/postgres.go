@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// notifyChannel is the Postgres NOTIFY channel chirp events are sent on.
+const notifyChannel = "chirp_events"
+
+// PostgresBridge is a Publisher that shares events between replicas with
+// Postgres LISTEN/NOTIFY. Published events are numbered from a database
+// sequence, so event IDs match on every replica and a client can resume
+// against any of them.
+type PostgresBridge struct {
+	db     *database.Queries
+	broker *Broker
+}
+
+// NewPostgresBridge returns a bridge that publishes through db and
+// delivers events it hears about to broker.
+func NewPostgresBridge(db *database.Queries, broker *Broker) *PostgresBridge {
+	return &PostgresBridge{db: db, broker: broker}
+}
+
+// Publish numbers e and notifies every replica, including this one. The
+// local broker receives it once the notification comes back.
+func (p *PostgresBridge) Publish(ctx context.Context, e Event) error {
+	id, err := p.db.NextChirpEventID(ctx)
+	if err != nil {
+		return err
+	}
+	e.ID = id
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return p.db.NotifyChirpEvent(ctx, string(payload))
+}
+
+// Listen forwards notifications from dbURL to the broker until ctx is
+// done. Events sent while the connection is down are lost.
+func (p *PostgresBridge) Listen(ctx context.Context, dbURL string) error {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Chirp event listener: %s", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(notifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-listener.Notify:
+			if n == nil {
+				// The connection was re-established.
+				continue
+			}
+			e := Event{}
+			if err := json.Unmarshal([]byte(n.Extra), &e); err != nil {
+				log.Printf("Couldn't decode chirp event: %s", err)
+				continue
+			}
+			p.broker.Publish(ctx, e)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
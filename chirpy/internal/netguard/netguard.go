@@ -0,0 +1,58 @@
+// Package netguard keeps requests to URLs that users pick away from
+// loopback, private and other internal addresses, such as cloud metadata
+// services.
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"syscall"
+)
+
+// ErrBlockedAddress is returned when a URL leads to an address that isn't
+// publicly routable.
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+// nonPublicPrefixes are ranges that IsGlobalUnicast and IsPrivate let
+// through but which don't lead to the public internet.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which reaches IPv4 addresses
+	netip.MustParsePrefix("2002::/16"),     // 6to4, which does too
+}
+
+// IsPublic reports whether addr is a publicly routable unicast address:
+// not loopback, private, link-local (where cloud metadata services
+// live), multicast or otherwise reserved. IPv4-mapped IPv6 addresses are
+// judged by their IPv4 address.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Control is a net.Dialer Control function that refuses to connect to
+// addresses IsPublic rejects. It runs after DNS resolution, so hostnames
+// resolving or rebinding to internal addresses are refused too.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lsherman98/boot.dev/chirpy/internal/oidc"
+	"github.com/lsherman98/boot.dev/chirpy/internal/oidc/oidctest"
+)
+
+const redirectURL = "https://chirpy.example.com/api/auth/oidc/callback"
+
+func newProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	mock := oidctest.NewProvider(t, "chirpy", "secret")
+	return mock, oidc.NewProvider(oidc.Config{
+		Issuer:       mock.URL,
+		ClientID:     "chirpy",
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"email"},
+	})
+}
+
+// authorize follows the provider's authorization URL and returns the
+// code and state it redirects back with.
+func authorize(t *testing.T, p *oidc.Provider, state, nonce, challenge string) (code, gotState string) {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	noRedirects := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirects.Get(authURL)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", authURL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("GET %s status = %d, want 302", authURL, resp.StatusCode)
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc.Query().Get("code"), loc.Query().Get("state")
+}
+
+func TestExchange(t *testing.T) {
+	ctx := context.Background()
+	mock, p := newProvider(t)
+	mock.SetUser(oidctest.User{Subject: "abc", Email: "a@example.com", EmailVerified: true, Name: "A"})
+
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		t.Fatalf("NewPKCE() error = %v", err)
+	}
+	code, state := authorize(t, p, "the-state", "the-nonce", challenge)
+	if state != "the-state" {
+		t.Errorf("state = %q, want the-state", state)
+	}
+
+	claims, err := p.Exchange(ctx, code, verifier, "the-nonce")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if claims.Subject != "abc" || claims.Email != "a@example.com" || !claims.EmailVerified || claims.Name != "A" {
+		t.Errorf("Exchange() claims = %+v", claims)
+	}
+
+	if _, err := p.Exchange(ctx, code, verifier, "the-nonce"); err == nil {
+		t.Error("Exchange() reusing a code error = nil, want an error")
+	}
+}
+
+func TestExchangeChecksPKCEAndNonce(t *testing.T) {
+	ctx := context.Background()
+	_, p := newProvider(t)
+
+	_, challenge, _ := oidc.NewPKCE()
+	otherVerifier, _, _ := oidc.NewPKCE()
+	code, _ := authorize(t, p, "state", "nonce", challenge)
+	if _, err := p.Exchange(ctx, code, otherVerifier, "nonce"); err == nil {
+		t.Error("Exchange() with the wrong code verifier error = nil, want an error")
+	}
+
+	verifier, challenge, _ := oidc.NewPKCE()
+	code, _ = authorize(t, p, "state", "nonce", challenge)
+	if _, err := p.Exchange(ctx, code, verifier, "another-nonce"); err == nil {
+		t.Error("Exchange() with the wrong nonce error = nil, want an error")
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	ctx := context.Background()
+	mock, p := newProvider(t)
+	user := oidctest.User{Subject: "abc"}
+
+	forgeryKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, mock.Claims(user, "nonce"))
+	forged.Header["kid"] = "test-key"
+	forgedToken, _ := forged.SignedString(forgeryKey)
+	unsignedToken, _ := jwt.NewWithClaims(jwt.SigningMethodNone, mock.Claims(user, "nonce")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	modified := func(fn func(jwt.MapClaims)) string {
+		claims := mock.Claims(user, "nonce")
+		fn(claims)
+		return mock.SignIDToken(claims)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", mock.SignIDToken(mock.Claims(user, "nonce")), false},
+		{"signed by another key", forgedToken, true},
+		{"unsigned", unsignedToken, true},
+		{"wrong nonce", modified(func(c jwt.MapClaims) { c["nonce"] = "other" }), true},
+		{"no nonce", modified(func(c jwt.MapClaims) { delete(c, "nonce") }), true},
+		{"wrong audience", modified(func(c jwt.MapClaims) { c["aud"] = "someone-else" }), true},
+		{"other party", modified(func(c jwt.MapClaims) { c["aud"] = []string{"chirpy", "someone-else"}; c["azp"] = "someone-else" }), true},
+		{"wrong issuer", modified(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }), true},
+		{"expired", modified(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }), true},
+		{"no subject", modified(func(c jwt.MapClaims) { delete(c, "sub") }), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.VerifyIDToken(ctx, tt.token, "nonce")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyIDToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
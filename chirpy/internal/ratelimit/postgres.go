@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so every
+// replica shares the same limits. Refills use the database clock, so
+// replicas don't need synchronized clocks.
+type PostgresStore struct {
+	db *database.Queries
+}
+
+// NewPostgresStore returns a PostgresStore using db. Run RunPruner
+// alongside it to keep the table from growing with every client seen.
+func NewPostgresStore(db *database.Queries) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	row, err := s.db.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(limit.Requests),
+		Rate:  limit.rate(),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(limit, row.Allowed, row.Tokens), nil
+}
+
+// Prune deletes the buckets that haven't been touched for idle and
+// returns how many it deleted. With idle at least the longest Period in
+// use, only full buckets go, and Take treats those like missing ones.
+func (s *PostgresStore) Prune(ctx context.Context, idle time.Duration) (int64, error) {
+	return s.db.DeleteIdleRateLimitBuckets(ctx, idle.Seconds())
+}
+
+// RunPruner calls Prune every interval until ctx is done.
+func (s *PostgresStore) RunPruner(ctx context.Context, interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := s.Prune(ctx, idle); err != nil {
+			log.Printf("Couldn't prune rate limit buckets: %s", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// execRecorder is a database.DBTX that records the statements executed
+// on it and reports deleted rows of them.
+type execRecorder struct {
+	queries []string
+	args    [][]interface{}
+	deleted int64
+}
+
+func (e *execRecorder) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	e.queries = append(e.queries, query)
+	e.args = append(e.args, args)
+	return driver.RowsAffected(e.deleted), nil
+}
+
+func (e *execRecorder) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	panic("unexpected PrepareContext")
+}
+
+func (e *execRecorder) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	panic("unexpected QueryContext")
+}
+
+func (e *execRecorder) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	panic("unexpected QueryRowContext")
+}
+
+func TestPostgresStorePrune(t *testing.T) {
+	db := &execRecorder{deleted: 3}
+	store := NewPostgresStore(database.New(db))
+
+	n, err := store.Prune(context.Background(), time.Hour)
+	if err != nil || n != 3 {
+		t.Fatalf("Prune() = %d, %v, want 3", n, err)
+	}
+	if len(db.queries) != 1 || !strings.Contains(db.queries[0], "DELETE FROM rate_limit_buckets") {
+		t.Fatalf("queries = %q, want one DELETE from rate_limit_buckets", db.queries)
+	}
+	if len(db.args[0]) != 1 || db.args[0][0] != float64(3600) {
+		t.Errorf("args = %v, want the idle time in seconds", db.args[0])
+	}
+}
+
+func TestPostgresStoreRunPruner(t *testing.T) {
+	db := &execRecorder{}
+	store := NewPostgresStore(database.New(db))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	store.RunPruner(ctx, 10*time.Millisecond, time.Hour)
+
+	if len(db.queries) < 2 {
+		t.Errorf("RunPruner() pruned %d times in 50ms at a 10ms interval, want several", len(db.queries))
+	}
+}
@@ -0,0 +1,127 @@
+// Package ratelimit implements token bucket rate limiting with pluggable
+// bucket storage.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit allows bursts of up to Requests requests, refilling at a steady
+// rate so the bucket is full again after Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// rate is the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result describes the state of a bucket after taking a token from it.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request would be allowed.
+	// It is zero when Remaining is positive.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+}
+
+func newResult(l Limit, allowed bool, tokens float64) Result {
+	rate := l.rate()
+	res := Result{
+		Allowed:    allowed,
+		Limit:      l.Requests,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: secondsToDuration((float64(l.Requests) - tokens) / rate),
+	}
+	if tokens < 1 {
+		res.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
+
+// Store keeps token buckets keyed by an arbitrary string, usually a
+// route class plus a user ID or IP address.
+type Store interface {
+	// Take removes a token from the bucket at key if one is available.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// MemoryStore keeps buckets in process memory. Each replica enforces its
+// own limits.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]bucket
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	period    time.Duration
+}
+
+// maxIdleBuckets is how many buckets a MemoryStore holds before it
+// starts dropping ones that have refilled completely.
+const maxIdleBuckets = 10000
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: map[string]bucket{},
+		Now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	if len(s.buckets) >= maxIdleBuckets {
+		s.prune(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = bucket{tokens: float64(limit.Requests), updatedAt: now}
+	}
+	elapsed := now.Sub(b.updatedAt).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	b.tokens = math.Min(float64(limit.Requests), b.tokens+elapsed*limit.rate())
+	b.updatedAt = now
+	b.period = limit.Period
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	s.buckets[key] = b
+	return newResult(limit, allowed, b.tokens), nil
+}
+
+// prune drops buckets that have been idle long enough to refill.
+func (s *MemoryStore) prune(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.Now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	for i := 0; i < 3; i++ {
+		res, err := store.Take(ctx, "user", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("Take() #%d = %+v, want allowed with %d remaining", i, res, 2-i)
+		}
+	}
+
+	res, _ := store.Take(ctx, "user", limit)
+	if res.Allowed {
+		t.Fatalf("Take() past the limit was allowed")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want %v", res.RetryAfter, time.Second)
+	}
+	if res.ResetAfter != 3*time.Second {
+		t.Errorf("ResetAfter = %v, want %v", res.ResetAfter, 3*time.Second)
+	}
+
+	if res, _ := store.Take(ctx, "other-user", limit); !res.Allowed {
+		t.Errorf("Take() for a different key was limited")
+	}
+
+	now = now.Add(time.Second)
+	if res, _ := store.Take(ctx, "user", limit); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("Take() after refilling one token = %+v, want allowed with 0 remaining", res)
+	}
+
+	now = now.Add(time.Hour)
+	if res, _ := store.Take(ctx, "user", limit); res.Remaining != 2 {
+		t.Errorf("Take() after a long idle = %+v, want the bucket capped at its burst", res)
+	}
+}
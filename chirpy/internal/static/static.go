@@ -0,0 +1,143 @@
+// Package static serves files from an fs.FS with precompressed variants
+// and cache headers suited to fingerprinted asset names.
+package static
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/compression"
+)
+
+// Cache-Control values for fingerprinted and plain file names.
+const (
+	cacheImmutable  = "public, max-age=31536000, immutable"
+	cacheRevalidate = "no-cache"
+)
+
+// fingerprintPattern matches names with a content hash before the
+// extension, like app.3f9a1c2b.js. Their content never changes, so they
+// can be cached for good.
+var fingerprintPattern = regexp.MustCompile(`\.[0-9a-f]{8,}\.[^./]+$`)
+
+// precompressed maps the codings the handler looks for to the suffix of
+// the file holding that variant, in the order they're preferred.
+var precompressed = []struct {
+	coding, suffix string
+}{
+	{compression.Zstd, ".zst"},
+	{compression.Gzip, ".gz"},
+}
+
+// Handler serves the files in an fs.FS. A request for a directory gets
+// its index.html. When a client accepts a coding and a precompressed copy
+// of the file exists next to it, such as app.js.zst for app.js, the copy
+// is sent instead.
+type Handler struct {
+	fsys fs.FS
+}
+
+// NewHandler returns a Handler serving fsys.
+func NewHandler(fsys fs.FS) *Handler {
+	return &Handler{fsys: fsys}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "405 method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	if info, err := fs.Stat(h.fsys, name); err == nil && info.IsDir() {
+		name = path.Join(name, "index.html")
+	}
+
+	f, info, err := h.open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "500 internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	coding := ""
+	if offered := h.variants(name); len(offered) > 0 {
+		coding = compression.Negotiate(r.Header.Get("Accept-Encoding"), offered...)
+	}
+	if coding != "" {
+		for _, p := range precompressed {
+			if p.coding != coding {
+				continue
+			}
+			variant, variantInfo, err := h.open(name + p.suffix)
+			if err != nil {
+				break
+			}
+			defer variant.Close()
+			f, info = variant, variantInfo
+			w.Header().Set("Content-Encoding", coding)
+		}
+	}
+
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+	if fingerprintPattern.MatchString(name) {
+		w.Header().Set("Cache-Control", cacheImmutable)
+	} else {
+		w.Header().Set("Cache-Control", cacheRevalidate)
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, "500 internal server error", http.StatusInternalServerError)
+		return
+	}
+	// ServeContent handles Range and If-Modified-Since. Embedded files
+	// have no modification time, so they are sent in full each time.
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+// open opens a regular file.
+func (h *Handler) open(name string) (fs.File, fs.FileInfo, error) {
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, nil, fs.ErrNotExist
+	}
+	return f, info, nil
+}
+
+// variants returns the codings name has a precompressed copy in.
+func (h *Handler) variants(name string) []string {
+	codings := []string{}
+	for _, p := range precompressed {
+		if info, err := fs.Stat(h.fsys, name+p.suffix); err == nil && info.Mode().IsRegular() {
+			codings = append(codings, p.coding)
+		}
+	}
+	return codings
+}
@@ -0,0 +1,70 @@
+package static
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(fstest.MapFS{
+		"index.html":               {Data: []byte("<h1>home</h1>")},
+		"assets/app.js":            {Data: []byte("plain")},
+		"assets/app.js.gz":         {Data: []byte("gzipped")},
+		"assets/app.js.zst":        {Data: []byte("zstd")},
+		"assets/logo.3f9a1c2b.png": {Data: []byte("png")},
+	})
+
+	tests := []struct {
+		path           string
+		acceptEncoding string
+		wantStatus     int
+		wantBody       string
+		wantEncoding   string
+		wantCache      string
+	}{
+		{"/", "", http.StatusOK, "<h1>home</h1>", "", cacheRevalidate},
+		{"/assets/app.js", "", http.StatusOK, "plain", "", cacheRevalidate},
+		{"/assets/app.js", "gzip", http.StatusOK, "gzipped", "gzip", cacheRevalidate},
+		{"/assets/app.js", "gzip, zstd", http.StatusOK, "zstd", "zstd", cacheRevalidate},
+		{"/assets/logo.3f9a1c2b.png", "gzip", http.StatusOK, "png", "", cacheImmutable},
+		{"/assets/", "", http.StatusNotFound, "", "", ""},
+		{"/../main.go", "", http.StatusNotFound, "", "", ""},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		resp := rec.Result()
+		body, _ := io.ReadAll(resp.Body)
+
+		if resp.StatusCode != tc.wantStatus {
+			t.Errorf("GET %s = %d, want %d", tc.path, resp.StatusCode, tc.wantStatus)
+			continue
+		}
+		if tc.wantStatus != http.StatusOK {
+			continue
+		}
+		if string(body) != tc.wantBody {
+			t.Errorf("GET %s (%s) body = %q, want %q", tc.path, tc.acceptEncoding, body, tc.wantBody)
+		}
+		if got := resp.Header.Get("Content-Encoding"); got != tc.wantEncoding {
+			t.Errorf("GET %s (%s) Content-Encoding = %q, want %q", tc.path, tc.acceptEncoding, got, tc.wantEncoding)
+		}
+		if got := resp.Header.Get("Cache-Control"); got != tc.wantCache {
+			t.Errorf("GET %s Cache-Control = %q, want %q", tc.path, got, tc.wantCache)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Content-Type"); got != "text/javascript; charset=utf-8" {
+		t.Errorf("Content-Type of app.js = %q", got)
+	}
+}
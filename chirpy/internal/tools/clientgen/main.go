@@ -0,0 +1,582 @@
+// Command clientgen generates the typed Go client in chirpy/client from
+// chirpy's OpenAPI document. It understands the subset of OpenAPI 3 that
+// the document uses: component schemas (objects, arrays, allOf and
+// refs), path, query and header parameters, JSON or binary request
+// bodies and JSON, text or streamed responses.
+//
+// Usage:
+//
+//	go run ./internal/tools/clientgen -spec openapi.json -out client/client.gen.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+type spec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components components                            `json:"components"`
+}
+
+type components struct {
+	Schemas    map[string]*schema    `json:"schemas"`
+	Parameters map[string]*parameter `json:"parameters"`
+	Responses  map[string]*response  `json:"responses"`
+}
+
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Format     string             `json:"format"`
+	Items      *schema            `json:"items"`
+	Properties map[string]*schema `json:"properties"`
+	Required   []string           `json:"required"`
+	AllOf      []*schema          `json:"allOf"`
+}
+
+type parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Content map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref     string               `json:"$ref"`
+	Content map[string]mediaType `json:"content"`
+}
+
+type operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Parameters  []*parameter          `json:"parameters"`
+	RequestBody *requestBody          `json:"requestBody"`
+	Responses   map[string]*response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+}
+
+var methods = []string{"get", "post", "put", "patch", "delete"}
+
+func main() {
+	specPath := flag.String("spec", "openapi.json", "path to the OpenAPI document")
+	outPath := flag.String("out", "client/client.gen.go", "path of the generated file")
+	pkg := flag.String("package", "client", "package name of the generated file")
+	flag.Parse()
+
+	dat, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := spec{}
+	if err := json.Unmarshal(dat, &s); err != nil {
+		log.Fatalf("couldn't parse %s: %s", *specPath, err)
+	}
+
+	g := &generator{spec: s}
+	src, err := g.generate(*pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*outPath, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type generator struct {
+	spec    spec
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) generate(pkg string) ([]byte, error) {
+	g.imports = map[string]bool{"context": true}
+
+	names := sortedKeys(g.spec.Components.Schemas)
+	for _, name := range names {
+		if err := g.genSchema(name, g.spec.Components.Schemas[name]); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+
+	for _, path := range sortedKeys(g.spec.Paths) {
+		item := g.spec.Paths[path]
+		shared := []*parameter{}
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("%s parameters: %w", path, err)
+			}
+		}
+		for _, method := range methods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			op := operation{}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if err := g.genOperation(path, method, shared, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+
+	out := bytes.Buffer{}
+	fmt.Fprintf(&out, "// Code generated by clientgen from openapi.json. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", pkg)
+	thirdParty := []string{}
+	for _, imp := range sortedKeys(g.imports) {
+		if strings.Contains(strings.Split(imp, "/")[0], ".") {
+			thirdParty = append(thirdParty, imp)
+			continue
+		}
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	if len(thirdParty) > 0 {
+		fmt.Fprintf(&out, "\n")
+	}
+	for _, imp := range thirdParty {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	fmt.Fprintf(&out, ")\n\n")
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) genSchema(name string, s *schema) error {
+	switch {
+	case len(s.AllOf) > 0:
+		g.printf("type %s struct {\n", name)
+		for _, part := range s.AllOf {
+			if part.Ref != "" {
+				g.printf("\t%s\n", refName(part.Ref))
+				continue
+			}
+			if err := g.genFields(part); err != nil {
+				return err
+			}
+		}
+		g.printf("}\n\n")
+	case s.Type == "object" && len(s.Properties) > 0:
+		g.printf("type %s struct {\n", name)
+		if err := g.genFields(s); err != nil {
+			return err
+		}
+		g.printf("}\n\n")
+	default:
+		typ, err := g.goType(s)
+		if err != nil {
+			return err
+		}
+		g.printf("type %s %s\n\n", name, typ)
+	}
+	return nil
+}
+
+func (g *generator) genFields(s *schema) error {
+	required := map[string]bool{}
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	for _, prop := range sortedKeys(s.Properties) {
+		typ, err := g.goType(s.Properties[prop])
+		if err != nil {
+			return fmt.Errorf("property %s: %w", prop, err)
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+			// omitempty never omits a struct, so optional times are
+			// pointers.
+			if typ == "time.Time" {
+				typ = "*time.Time"
+			}
+		}
+		g.printf("\t%s %s `json:%q`\n", goName(prop), typ, tag)
+	}
+	return nil
+}
+
+func (g *generator) genOperation(path, method string, shared []*parameter, op operation) error {
+	if op.OperationID == "" {
+		return fmt.Errorf("missing operationId")
+	}
+
+	pathParams := []*parameter{}
+	queryParams := []*parameter{}
+	headerParams := []*parameter{}
+	for _, p := range append(append([]*parameter{}, shared...), op.Parameters...) {
+		p, err := g.resolveParameter(p)
+		if err != nil {
+			return err
+		}
+		switch p.In {
+		case "path":
+			pathParams = append(pathParams, p)
+		case "query":
+			queryParams = append(queryParams, p)
+		case "header":
+			headerParams = append(headerParams, p)
+		default:
+			return fmt.Errorf("unsupported parameter location %q", p.In)
+		}
+	}
+
+	args := []string{"ctx context.Context"}
+	for _, p := range pathParams {
+		typ, err := g.goType(p.Schema)
+		if err != nil {
+			return err
+		}
+		args = append(args, fmt.Sprintf("%s %s", lowerFirst(goName(p.Name)), typ))
+	}
+
+	paramsType := op.OperationID + "Params"
+	optionalParams := append(append([]*parameter{}, queryParams...), headerParams...)
+	if len(optionalParams) > 0 {
+		g.printf("// %s holds the optional parameters of %s.\n", paramsType, op.OperationID)
+		g.printf("type %s struct {\n", paramsType)
+		for _, p := range optionalParams {
+			typ, err := g.goType(p.Schema)
+			if err != nil {
+				return err
+			}
+			if p.Description != "" {
+				g.printf("\t// %s\n", p.Description)
+			}
+			g.printf("\t%s *%s\n", goName(p.Name), typ)
+		}
+		g.printf("}\n\n")
+		args = append(args, "params *"+paramsType)
+	}
+
+	bodyArg := "nil"
+	if op.RequestBody != nil {
+		if mt, ok := op.RequestBody.Content["application/json"]; ok {
+			typ, err := g.goType(mt.Schema)
+			if err != nil {
+				return err
+			}
+			args = append(args, "body "+typ)
+			bodyArg = "body"
+		} else if isBinary(op.RequestBody.Content) {
+			// The caller picks which of the media types it sends.
+			g.imports["io"] = true
+			args = append(args, "contentType string", "body io.Reader")
+			bodyArg = "rawBody{contentType, body}"
+		} else {
+			return fmt.Errorf("request body must be application/json or binary")
+		}
+	}
+
+	resultType, err := g.resultType(op)
+	if err != nil {
+		return err
+	}
+
+	security := ""
+	if len(op.Security) > 0 {
+		for name := range op.Security[0] {
+			security = name
+		}
+	}
+
+	g.printf("// %s calls %s %s.\n", op.OperationID, strings.ToUpper(method), path)
+	if op.Summary != "" {
+		g.printf("//\n// %s.\n", op.Summary)
+	}
+	if resultType == "" {
+		g.printf("func (c *Client) %s(%s) error {\n", op.OperationID, strings.Join(args, ", "))
+	} else {
+		g.printf("func (c *Client) %s(%s) (%s, error) {\n", op.OperationID, strings.Join(args, ", "), resultType)
+	}
+
+	if len(pathParams) > 0 {
+		g.imports["fmt"] = true
+	}
+	g.printf("\tpath := %s\n", pathExpr(path, pathParams))
+	g.imports["net/url"] = true
+	g.printf("\tquery := url.Values{}\n")
+	g.imports["net/http"] = true
+	g.printf("\theader := http.Header{}\n")
+	if len(optionalParams) > 0 {
+		g.printf("\tif params != nil {\n")
+		for _, p := range optionalParams {
+			field := "params." + goName(p.Name)
+			target := "query"
+			if p.In == "header" {
+				target = "header"
+			}
+			g.printf("\t\tif %s != nil {\n", field)
+			g.printf("\t\t\t%s.Set(%q, %s)\n", target, p.Name, g.formatValue("*"+field, p.Schema))
+			g.printf("\t\t}\n")
+		}
+		g.printf("\t}\n")
+	}
+
+	if resultType == "" {
+		g.printf("\treturn c.do(ctx, %q, path, query, header, %q, %s, nil)\n", strings.ToUpper(method), security, bodyArg)
+	} else {
+		g.printf("\tvar out %s\n", resultType)
+		g.printf("\terr := c.do(ctx, %q, path, query, header, %q, %s, &out)\n", strings.ToUpper(method), security, bodyArg)
+		g.printf("\treturn out, err\n")
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// isBinary reports whether every media type of a request body is a
+// binary string, sent as is.
+func isBinary(content map[string]mediaType) bool {
+	if len(content) == 0 {
+		return false
+	}
+	for _, mt := range content {
+		if mt.Schema == nil || mt.Schema.Type != "string" || mt.Schema.Format != "binary" {
+			return false
+		}
+	}
+	return true
+}
+
+// streamedContentTypes are response bodies the client hands to the
+// caller unread, as an io.ReadCloser.
+var streamedContentTypes = map[string]bool{
+	"text/event-stream":    true,
+	"application/x-ndjson": true,
+	"application/zip":      true,
+	"text/csv":             true,
+}
+
+// resultType is the Go type of the operation's first successful response
+// body, or "" if it has none.
+func (g *generator) resultType(op operation) (string, error) {
+	codes := sortedKeys(op.Responses)
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		resp, err := g.resolveResponse(op.Responses[code])
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Content) == 0 {
+			return "", nil
+		}
+		if mt, ok := resp.Content["application/json"]; ok {
+			return g.goType(mt.Schema)
+		}
+		for contentType := range resp.Content {
+			if streamedContentTypes[contentType] {
+				g.imports["io"] = true
+				return "io.ReadCloser", nil
+			}
+		}
+		for contentType := range resp.Content {
+			if strings.HasPrefix(contentType, "text/") {
+				return "string", nil
+			}
+		}
+		return "", fmt.Errorf("unsupported response content %v", sortedKeys(resp.Content))
+	}
+	return "", nil
+}
+
+func (g *generator) goType(s *schema) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("missing schema")
+	}
+	if s.Ref != "" {
+		return refName(s.Ref), nil
+	}
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "uuid":
+			g.imports["github.com/google/uuid"] = true
+			return "uuid.UUID", nil
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "boolean":
+		return "bool", nil
+	case "integer":
+		if s.Format == "int64" {
+			return "int64", nil
+		}
+		return "int", nil
+	case "number":
+		return "float64", nil
+	case "array":
+		item, err := g.goType(s.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case "object":
+		if len(s.Properties) == 0 {
+			g.imports["encoding/json"] = true
+			return "json.RawMessage", nil
+		}
+		return "", fmt.Errorf("inline objects are not supported, use a $ref")
+	}
+	return "", fmt.Errorf("unsupported schema type %q", s.Type)
+}
+
+func (g *generator) formatValue(expr string, s *schema) string {
+	switch {
+	case s.Type == "string" && s.Format == "date-time":
+		if strings.HasPrefix(expr, "*") {
+			expr = "(" + expr + ")"
+		}
+		return expr + ".Format(time.RFC3339Nano)"
+	case s.Type == "string" && s.Format != "uuid":
+		return expr
+	}
+	g.imports["fmt"] = true
+	return "fmt.Sprint(" + expr + ")"
+}
+
+func (g *generator) resolveParameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	resolved, ok := g.spec.Components.Parameters[refName(p.Ref)]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %s", p.Ref)
+	}
+	return resolved, nil
+}
+
+func (g *generator) resolveResponse(r *response) (*response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	resolved, ok := g.spec.Components.Responses[refName(r.Ref)]
+	if !ok {
+		return nil, fmt.Errorf("unknown response %s", r.Ref)
+	}
+	return resolved, nil
+}
+
+// pathExpr builds a Go expression for path with its {placeholders}
+// replaced by the escaped path parameter arguments.
+func pathExpr(path string, params []*parameter) string {
+	parts := []string{}
+	rest := path
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest, "}")
+		parts = append(parts, fmt.Sprintf("%q", rest[:start]))
+		name := rest[start+1 : end]
+		arg := lowerFirst(goName(name))
+		for _, p := range params {
+			if p.Name == name {
+				parts = append(parts, fmt.Sprintf("url.PathEscape(fmt.Sprint(%s))", arg))
+			}
+		}
+		rest = rest[end+1:]
+	}
+	if rest != "" || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%q", rest))
+	}
+	return strings.Join(parts, " + ")
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+var initialisms = map[string]string{
+	"id":   "ID",
+	"ids":  "IDs",
+	"ip":   "IP",
+	"url":  "URL",
+	"uri":  "URI",
+	"api":  "API",
+	"json": "JSON",
+	"http": "HTTP",
+}
+
+// goName converts a snake_case or camelCase name from the spec into an
+// exported Go identifier.
+func goName(name string) string {
+	words := []string{}
+	word := []rune{}
+	for _, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == '.':
+			words = append(words, string(word))
+			word = nil
+		case r >= 'A' && r <= 'Z' && len(word) > 0:
+			words = append(words, string(word))
+			word = []rune{r}
+		default:
+			word = append(word, r)
+		}
+	}
+	words = append(words, string(word))
+
+	out := strings.Builder{}
+	for _, w := range words {
+		if w == "" {
+			continue
+		}
+		if upper, ok := initialisms[strings.ToLower(w)]; ok {
+			out.WriteString(upper)
+			continue
+		}
+		out.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return out.String()
+}
+
+func lowerFirst(name string) string {
+	for upper, lower := range map[string]string{"ID": "id", "URL": "url", "IP": "ip"} {
+		if name == upper {
+			return lower
+		}
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
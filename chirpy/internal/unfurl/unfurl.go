@@ -0,0 +1,239 @@
+// Package unfurl fetches previews of the links in chirps: the title,
+// description and image a page advertises in its OpenGraph and Twitter
+// card meta tags. Links are queued in the link_previews table when a
+// chirp is created, in the same transaction, and fetched in the
+// background by a Worker.
+//
+// Chirp authors pick the URLs, so fetches are made with a client that
+// refuses to connect to loopback, private and other internal addresses.
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/netguard"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// MaxBodySize is how much of a page is read looking for its meta
+	// tags, which belong in the head.
+	MaxBodySize = 512 << 10
+
+	// requestTimeout bounds a whole fetch, redirects included.
+	requestTimeout = 10 * time.Second
+	dialTimeout    = 5 * time.Second
+	maxRedirects   = 5
+
+	maxTitleLength       = 300
+	maxDescriptionLength = 1000
+	maxURLLength         = 2048
+)
+
+// ErrNoPreview is returned for pages without a title or description.
+var ErrNoPreview = errors.New("page has no preview metadata")
+
+// Preview is what a page says about itself. Fields the page doesn't set
+// are empty.
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+	SiteName    string
+}
+
+// Fetcher fetches and parses pages.
+type Fetcher struct {
+	Client *http.Client
+	// MaxBodySize is how much of each page is read.
+	MaxBodySize int64
+}
+
+// NewFetcher returns a Fetcher whose client times out, follows a few
+// redirects at most and only connects to public addresses. The
+// addresses are checked as connections are made, after DNS resolution,
+// so hostnames resolving or rebinding to internal addresses are refused
+// too. Proxies are never used, since they would connect on its behalf.
+func NewFetcher() *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: netguard.Control,
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: requestTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	return &Fetcher{
+		Client: &http.Client{
+			Transport:     transport,
+			Timeout:       requestTimeout,
+			CheckRedirect: checkRedirect,
+		},
+		MaxBodySize: MaxBodySize,
+	}
+}
+
+// Fetch gets the page at link and returns its preview. Pages that aren't
+// HTML, or don't have a title or description, return an error.
+func (f *Fetcher) Fetch(ctx context.Context, link string) (Preview, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return Preview{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Preview{}, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("User-Agent", "Chirpy-Unfurl/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Preview{}, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Preview{}, fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.MaxBodySize), contentType)
+	if err != nil {
+		return Preview{}, err
+	}
+	preview := parse(body, resp.Request.URL)
+	if preview.Title == "" && preview.Description == "" {
+		return Preview{}, ErrNoPreview
+	}
+	return preview, nil
+}
+
+// parse reads a page's meta tags and title, stopping at the body. base
+// is the page's URL, which relative image URLs are resolved against.
+func parse(r io.Reader, base *url.URL) Preview {
+	meta := map[string]string{}
+	title := ""
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		name, hasAttr := z.TagName()
+		if string(name) == "body" {
+			break
+		}
+		if string(name) == "title" && tt == html.StartTagToken && title == "" {
+			if z.Next() == html.TextToken {
+				title = string(z.Text())
+			}
+			continue
+		}
+		if string(name) != "meta" || !hasAttr {
+			continue
+		}
+
+		key, content := "", ""
+		for hasAttr {
+			var k, v []byte
+			k, v, hasAttr = z.TagAttr()
+			switch string(k) {
+			case "property", "name":
+				key = strings.ToLower(string(v))
+			case "content":
+				content = string(v)
+			}
+		}
+		if _, ok := meta[key]; key != "" && !ok {
+			meta[key] = content
+		}
+	}
+
+	first := func(keys ...string) string {
+		for _, key := range keys {
+			if value := clean(meta[key]); value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+	preview := Preview{
+		Title:       truncate(first("og:title", "twitter:title"), maxTitleLength),
+		Description: truncate(first("og:description", "twitter:description", "description"), maxDescriptionLength),
+		SiteName:    truncate(first("og:site_name"), maxTitleLength),
+	}
+	if preview.Title == "" {
+		preview.Title = truncate(clean(title), maxTitleLength)
+	}
+	if image := first("og:image:secure_url", "og:image", "og:image:url", "twitter:image", "twitter:image:src"); image != "" {
+		preview.ImageURL = resolveImage(base, image)
+	}
+	return preview
+}
+
+// resolveImage returns the absolute http or https URL of an image, or ""
+// if it has another scheme or is unreasonably long.
+func resolveImage(base *url.URL, image string) string {
+	u, err := base.Parse(image)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	if s := u.String(); len(s) <= maxURLLength {
+		return s
+	}
+	return ""
+}
+
+// clean collapses runs of whitespace and drops what Postgres text
+// columns can't hold: invalid UTF-8 and NUL bytes.
+func clean(s string) string {
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, "\uFFFD"), "\x00", "")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate cuts s to at most n characters, marking the cut with an
+// ellipsis.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("too many redirects")
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+	}
+	return nil
+}
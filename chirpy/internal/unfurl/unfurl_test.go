@@ -0,0 +1,209 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/netguard"
+)
+
+// testFetcher returns a Fetcher for srv. httptest servers listen on
+// loopback, which NewFetcher's client refuses to connect to.
+func testFetcher(srv *httptest.Server) *Fetcher {
+	f := NewFetcher()
+	f.Client = srv.Client()
+	return f
+}
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			t.Errorf("request has no User-Agent")
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!doctype html><html><head>
+			<title>Fallback title</title>
+			<meta property="og:title" content="  Chirpy &amp; friends  ">
+			<meta name="twitter:title" content="Twitter title">
+			<meta property="og:description" content="A place
+			to chirp.">
+			<meta property="og:image" content="/img/card.png">
+			<meta property="og:site_name" content="Chirpy">
+			</head><body><meta property="og:title" content="Not in the head"></body></html>`)
+	})
+	mux.HandleFunc("/twitter", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head>
+			<title>Page title</title>
+			<meta name="twitter:description" content="Twitter description">
+			<meta name="twitter:image" content="javascript:alert(1)">
+			</head></html>`)
+	})
+	mux.HandleFunc("/latin1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		w.Write([]byte("<title>Caf\xe9</title>"))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"title":"not a page"}`)
+	})
+	mux.HandleFunc("/bare", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head></head><body><h1>Hi</h1></body></html>`)
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head>"+strings.Repeat(" ", MaxBodySize)+"<title>Too far down</title></head></html>")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	f := testFetcher(srv)
+
+	tests := []struct {
+		path    string
+		want    Preview
+		wantErr bool
+	}{
+		{
+			path: "/og",
+			want: Preview{
+				Title:       "Chirpy & friends",
+				Description: "A place to chirp.",
+				ImageURL:    srv.URL + "/img/card.png",
+				SiteName:    "Chirpy",
+			},
+		},
+		{
+			path: "/twitter",
+			want: Preview{Title: "Page title", Description: "Twitter description"},
+		},
+		{
+			path: "/latin1",
+			want: Preview{Title: "Café"},
+		},
+		{path: "/json", wantErr: true},
+		{path: "/bare", wantErr: true},
+		{path: "/huge", wantErr: true},
+		{path: "/missing", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := f.Fetch(context.Background(), srv.URL+tc.path)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Fetch() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Fetch() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFetchRefusesInternalAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request reached the server")
+	}))
+	defer srv.Close()
+
+	_, err := NewFetcher().Fetch(context.Background(), srv.URL)
+	if !errors.Is(err, netguard.ErrBlockedAddress) {
+		t.Errorf("Fetch(%s) error = %v, want netguard.ErrBlockedAddress", srv.URL, err)
+	}
+}
+
+func TestWorker(t *testing.T) {
+	ctx := context.Background()
+	store, err := database.NewDB(filepath.Join(t.TempDir(), "database.json"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	user, err := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	mu := sync.Mutex{}
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[r.URL.Path]++
+
+		if r.URL.Path == "/down" {
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<meta property="og:title" content="Page %s">`, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	chirp, err := store.CreateChirp(ctx, database.CreateChirpParams{
+		Body:   fmt.Sprintf("see %s/up and %s/down", srv.URL, srv.URL),
+		UserID: user.ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if err := Queue(ctx, store, chirp); err != nil {
+		t.Fatalf("Queue() error = %v", err)
+	}
+
+	now := time.Now()
+	worker := NewWorker(store)
+	worker.Fetcher = testFetcher(srv)
+	worker.Now = func() time.Time { return now }
+
+	if n, err := worker.FetchDue(ctx); err != nil || n != 2 {
+		t.Fatalf("FetchDue() = %d, %v, want 2 previews tried", n, err)
+	}
+	if n, _ := worker.FetchDue(ctx); n != 0 {
+		t.Fatalf("FetchDue() before the backoff elapsed = %d, want 0", n)
+	}
+
+	rows, err := store.GetChirpLinkPreviews(ctx, []uuid.UUID{chirp.ID})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("GetChirpLinkPreviews() = %+v, %v, want the fetched preview", rows, err)
+	}
+	if rows[0].Url != srv.URL+"/up" || rows[0].Title != "Page /up" {
+		t.Errorf("preview = %+v, want the title of %s/up", rows[0], srv.URL)
+	}
+	updated, err := store.GetChirp(ctx, chirp.ID)
+	if err != nil {
+		t.Fatalf("GetChirp() error = %v", err)
+	}
+	if !updated.UpdatedAt.After(chirp.UpdatedAt) {
+		t.Errorf("chirp updated_at = %v, want it bumped past %v", updated.UpdatedAt, chirp.UpdatedAt)
+	}
+
+	for i := 2; i <= MaxAttempts; i++ {
+		now = now.Add(baseBackoff << (i - 2))
+		if n, err := worker.FetchDue(ctx); err != nil || n != 1 {
+			t.Fatalf("FetchDue() #%d = %d, %v, want the failing preview tried", i, n, err)
+		}
+	}
+	now = now.Add(time.Hour)
+	if n, _ := worker.FetchDue(ctx); n != 0 {
+		t.Errorf("FetchDue() after giving up = %d, want 0", n)
+	}
+	if calls["/up"] != 1 || calls["/down"] != MaxAttempts {
+		t.Errorf("calls = %v, want /up once and /down %d times", calls, MaxAttempts)
+	}
+}
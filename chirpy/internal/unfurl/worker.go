@@ -0,0 +1,176 @@
+package unfurl
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/chirptext"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// Preview statuses.
+const (
+	StatusPending = "pending"
+	StatusFetched = "fetched"
+	StatusFailed  = "failed"
+)
+
+const (
+	// MaxLinksPerChirp is how many of a chirp's links get previews.
+	MaxLinksPerChirp = 4
+	// MaxAttempts is how many times a preview is tried before it is
+	// marked failed.
+	MaxAttempts = 3
+	// StaleAfter is how long a preview is kept before a new chirp
+	// linking to it has it fetched again.
+	StaleAfter = 24 * time.Hour
+
+	// leaseDuration is how long a claimed preview is hidden from other
+	// workers. It must outlast requestTimeout.
+	leaseDuration = time.Minute
+	baseBackoff   = time.Minute
+)
+
+// Queue records the links in chirp's body and queues previews for those
+// that aren't cached. Call it with the Store passed to InTx so they are
+// only queued if the chirp is committed.
+func Queue(ctx context.Context, store database.Store, chirp database.Chirp) error {
+	links := chirptext.Links(chirp.Body)
+	if len(links) > MaxLinksPerChirp {
+		links = links[:MaxLinksPerChirp]
+	}
+	for i, link := range links {
+		if len(link) > maxURLLength {
+			continue
+		}
+		err := store.QueueLinkPreview(ctx, database.QueueLinkPreviewParams{
+			Url:         link,
+			StaleBefore: time.Now().UTC().Add(-StaleAfter),
+		})
+		if err != nil {
+			return err
+		}
+		err = store.AddChirpLink(ctx, database.AddChirpLinkParams{
+			ChirpID:  chirp.ID,
+			Url:      link,
+			Position: int32(i),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Worker fetches queued previews. Several workers, in one process or
+// many, can share a database: each preview is claimed by one of them.
+type Worker struct {
+	Store   database.Store
+	Fetcher *Fetcher
+	// Interval is how often the queue is polled.
+	Interval time.Duration
+	// BatchSize is the most previews fetched per poll.
+	BatchSize int32
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewWorker returns a Worker fetching previews queued in store.
+func NewWorker(store database.Store) *Worker {
+	return &Worker{
+		Store:     store,
+		Fetcher:   NewFetcher(),
+		Interval:  time.Second,
+		BatchSize: 20,
+		Now:       time.Now,
+	}
+}
+
+// Run fetches due previews every Interval until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("Fetching link previews every %s...", w.Interval)
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.FetchDue(ctx); err != nil {
+			log.Printf("Couldn't fetch link previews: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FetchDue claims the previews that are due and tries to fetch each of
+// them once. It returns how many were tried.
+func (w *Worker) FetchDue(ctx context.Context) (int, error) {
+	now := w.Now().UTC()
+	previews, err := w.Store.ClaimLinkPreviews(ctx, database.ClaimLinkPreviewsParams{
+		LeaseUntil:  now.Add(leaseDuration),
+		Now:         now,
+		MaxPreviews: w.BatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, preview := range previews {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.fetch(ctx, preview)
+		}()
+	}
+	wg.Wait()
+	return len(previews), nil
+}
+
+// fetch makes one attempt at a preview and stores the result. Failed
+// attempts are retried with exponential backoff, keeping whatever was
+// fetched before. A new preview marks the chirps linking to it as
+// updated.
+func (w *Worker) fetch(ctx context.Context, preview database.LinkPreview) {
+	params := database.UpdateLinkPreviewParams{
+		Url:         preview.Url,
+		Status:      StatusFetched,
+		Attempts:    preview.Attempts + 1,
+		Title:       preview.Title,
+		Description: preview.Description,
+		ImageUrl:    preview.ImageUrl,
+		SiteName:    preview.SiteName,
+	}
+	fetched, err := w.Fetcher.Fetch(ctx, preview.Url)
+	if err != nil {
+		log.Printf("Couldn't fetch link preview of %s: %s", preview.Url, err)
+		params.Status = StatusPending
+		params.NextAttemptAt = w.Now().UTC().Add(baseBackoff << (params.Attempts - 1))
+		if params.Attempts >= MaxAttempts {
+			params.Status = StatusFailed
+		}
+	} else {
+		params.NextAttemptAt = w.Now().UTC()
+		params.Title = fetched.Title
+		params.Description = fetched.Description
+		params.ImageUrl = fetched.ImageURL
+		params.SiteName = fetched.SiteName
+	}
+
+	err = w.Store.InTx(ctx, func(tx database.Store) error {
+		if _, err := tx.UpdateLinkPreview(ctx, params); err != nil {
+			return err
+		}
+		if params.Status != StatusFetched {
+			return nil
+		}
+		return tx.TouchLinkingChirps(ctx, preview.Url)
+	})
+	if err != nil {
+		log.Printf("Couldn't update link preview of %s: %s", preview.Url, err)
+	}
+}
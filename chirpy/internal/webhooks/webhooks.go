@@ -0,0 +1,142 @@
+// Package webhooks delivers chirpy events to endpoints registered by
+// users. Events are queued in the webhook_deliveries outbox in the same
+// transaction as the change they describe, then sent by a Worker, which
+// signs every request and retries failures with exponential backoff.
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+const (
+	EventChirpCreated = "chirp.created"
+	EventChirpDeleted = "chirp.deleted"
+	EventUserUpgraded = "user.upgraded"
+)
+
+// EventTypes lists every event an endpoint can subscribe to.
+var EventTypes = []string{EventChirpCreated, EventChirpDeleted, EventUserUpgraded}
+
+// IsEventType reports whether eventType is one of EventTypes.
+func IsEventType(eventType string) bool {
+	return slices.Contains(EventTypes, eventType)
+}
+
+const (
+	// SignatureHeader carries the request's signature in the form
+	// "t=<unix seconds>,v1=<hex HMAC-SHA256>".
+	SignatureHeader = "X-Chirpy-Signature"
+	EventHeader     = "X-Chirpy-Event"
+	DeliveryHeader  = "X-Chirpy-Delivery"
+)
+
+// Payload is the JSON body of every delivery.
+type Payload struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Enqueue queues an event for every endpoint subscribed to eventType that
+// is global or owned by subjectID. Call it with the Store passed to InTx
+// so the deliveries are only queued if the change is committed.
+func Enqueue(ctx context.Context, store database.Store, eventType string, subjectID uuid.UUID, data any) error {
+	dat, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	eventID := uuid.New()
+	payload, err := json.Marshal(Payload{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      dat,
+	})
+	if err != nil {
+		return err
+	}
+
+	return store.EnqueueWebhookDeliveries(ctx, database.EnqueueWebhookDeliveriesParams{
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   string(payload),
+		SubjectID: subjectID,
+	})
+}
+
+// NewSecret returns a random signing secret for a new endpoint.
+func NewSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
+
+// Sign returns the SignatureHeader value for body sent at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + signature(secret, timestamp, body)
+}
+
+// Verify checks a SignatureHeader value against body. Signatures made
+// more than tolerance away from now are rejected so captured requests
+// can't be replayed later.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return errors.New("malformed signature header")
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("malformed signature timestamp: %w", err)
+	}
+	if age := now.Sub(time.Unix(unix, 0)).Abs(); age > tolerance {
+		return errors.New("signature timestamp is outside the tolerance")
+	}
+
+	expected := signature(secret, timestamp, body)
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match")
+}
+
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
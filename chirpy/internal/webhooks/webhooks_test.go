@@ -0,0 +1,293 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/netguard"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	body := []byte(`{"type":"chirp.created"}`)
+	header := Sign("secret", now, body)
+
+	if err := Verify("secret", header, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+	}{
+		{"wrong secret", "other", header, body, now},
+		{"tampered body", "secret", header, []byte(`{"type":"chirp.deleted"}`), now},
+		{"stale", "secret", header, body, now.Add(time.Hour)},
+		{"malformed", "secret", "v1=abc", body, now},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Verify(tc.secret, tc.header, tc.body, tc.now, 5*time.Minute); err == nil {
+				t.Errorf("Verify() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		1:  10 * time.Second,
+		2:  20 * time.Second,
+		3:  40 * time.Second,
+		20: 6 * time.Hour,
+	}
+	for attempts, want := range tests {
+		if got := Backoff(attempts); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestWorkerRetriesUntilDelivered(t *testing.T) {
+	ctx := context.Background()
+	store, err := database.NewDB(filepath.Join(t.TempDir(), "database.json"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	user, err := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	mu := sync.Mutex{}
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify("whsec_test", r.Header.Get(SignatureHeader), body, time.Now(), time.Hour); err != nil {
+			t.Errorf("delivery signature: %v", err)
+		}
+		if got := r.Header.Get(EventHeader); got != EventChirpCreated {
+			t.Errorf("%s = %q, want %q", EventHeader, got, EventChirpCreated)
+		}
+		if calls == 1 {
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer receiver.Close()
+
+	endpoint, err := store.CreateWebhookEndpoint(ctx, database.CreateWebhookEndpointParams{
+		UserID: user.ID,
+		Url:    receiver.URL,
+		Secret: "whsec_test",
+		Events: []string{EventChirpCreated},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhookEndpoint() error = %v", err)
+	}
+	if err := Enqueue(ctx, store, EventChirpCreated, user.ID, map[string]string{"body": "hello"}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if err := Enqueue(ctx, store, EventChirpCreated, uuid.New(), nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	now := time.Now()
+	worker := NewWorker(store)
+	worker.Now = func() time.Time { return now }
+	// The receiver is on loopback, which the worker's client refuses.
+	worker.HTTPClient = receiver.Client()
+
+	if n, err := worker.DeliverDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverDue() = %d, %v, want 1 delivery tried", n, err)
+	}
+	if n, _ := worker.DeliverDue(ctx); n != 0 {
+		t.Fatalf("DeliverDue() before the backoff elapsed = %d, want 0", n)
+	}
+
+	now = now.Add(Backoff(1))
+	if n, err := worker.DeliverDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverDue() after the backoff = %d, %v, want 1 delivery tried", n, err)
+	}
+
+	deliveries, err := store.GetWebhookDeliveries(ctx, database.GetWebhookDeliveriesParams{EndpointID: endpoint.ID, Limit: 10})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("GetWebhookDeliveries() = %v, %v, want 1 delivery", deliveries, err)
+	}
+	if deliveries[0].Status != StatusDelivered || deliveries[0].Attempts != 2 {
+		t.Errorf("delivery = %+v, want delivered after 2 attempts", deliveries[0])
+	}
+
+	attempts, err := store.GetWebhookAttempts(ctx, []uuid.UUID{deliveries[0].ID})
+	if err != nil || len(attempts) != 2 {
+		t.Fatalf("GetWebhookAttempts() = %v, %v, want 2 attempts", attempts, err)
+	}
+	if attempts[0].StatusCode.Int32 != http.StatusServiceUnavailable || attempts[0].ResponseBody != "try again later\n" {
+		t.Errorf("first attempt = %+v, want the 503 and its body", attempts[0])
+	}
+	if attempts[1].StatusCode.Int32 != http.StatusOK {
+		t.Errorf("second attempt = %+v, want a 200", attempts[1])
+	}
+}
+
+func TestWorkerGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	store, err := database.NewDB(filepath.Join(t.TempDir(), "database.json"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	user, err := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	endpoint, err := store.CreateWebhookEndpoint(ctx, database.CreateWebhookEndpointParams{
+		UserID: user.ID,
+		Url:    "http://127.0.0.1:0/unreachable",
+		Secret: "whsec_test",
+		Events: []string{EventUserUpgraded},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhookEndpoint() error = %v", err)
+	}
+	if err := Enqueue(ctx, store, EventUserUpgraded, user.ID, nil); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	now := time.Now()
+	worker := NewWorker(store)
+	worker.Now = func() time.Time { return now }
+	for i := 1; i <= MaxAttempts; i++ {
+		if n, err := worker.DeliverDue(ctx); err != nil || n != 1 {
+			t.Fatalf("DeliverDue() #%d = %d, %v, want 1 delivery tried", i, n, err)
+		}
+		now = now.Add(Backoff(i))
+	}
+	if n, _ := worker.DeliverDue(ctx); n != 0 {
+		t.Errorf("DeliverDue() after giving up = %d, want 0", n)
+	}
+
+	deliveries, _ := store.GetWebhookDeliveries(ctx, database.GetWebhookDeliveriesParams{EndpointID: endpoint.ID, Limit: 10})
+	if len(deliveries) != 1 || deliveries[0].Status != StatusFailed {
+		t.Fatalf("deliveries = %+v, want 1 failed delivery", deliveries)
+	}
+	attempts, _ := store.GetWebhookAttempts(ctx, []uuid.UUID{deliveries[0].ID})
+	if len(attempts) != MaxAttempts || attempts[0].Error == "" || attempts[0].StatusCode.Valid {
+		t.Errorf("attempts = %+v, want %d connection errors", attempts, MaxAttempts)
+	}
+}
+
+func TestWorkerOnlyReachesPublicAddresses(t *testing.T) {
+	ctx := context.Background()
+	store, err := database.NewDB(filepath.Join(t.TempDir(), "database.json"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	user, err := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	mu := sync.Mutex{}
+	paths := []string{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/hook" {
+			http.Redirect(w, r, "/internal", http.StatusFound)
+		}
+	}))
+	defer receiver.Close()
+
+	endpoint, err := store.CreateWebhookEndpoint(ctx, database.CreateWebhookEndpointParams{
+		UserID: user.ID,
+		Url:    receiver.URL + "/hook",
+		Secret: "whsec_test",
+		Events: []string{EventChirpCreated},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhookEndpoint() error = %v", err)
+	}
+	for range 2 {
+		if err := Enqueue(ctx, store, EventChirpCreated, user.ID, nil); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+
+	worker := NewWorker(store)
+	worker.BatchSize = 1
+	if n, err := worker.DeliverDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverDue() = %d, %v, want 1 delivery tried", n, err)
+	}
+	if len(paths) != 0 {
+		t.Fatalf("receiver on loopback was called for %v, want the connection refused", paths)
+	}
+
+	// With the dialer swapped for one that reaches the receiver, the
+	// redirect is recorded rather than followed.
+	worker.HTTPClient.Transport = receiver.Client().Transport
+	if n, err := worker.DeliverDue(ctx); err != nil || n != 1 {
+		t.Fatalf("DeliverDue() = %d, %v, want 1 delivery tried", n, err)
+	}
+	if len(paths) != 1 || paths[0] != "/hook" {
+		t.Errorf("receiver paths = %v, want only /hook", paths)
+	}
+
+	deliveries, _ := store.GetWebhookDeliveries(ctx, database.GetWebhookDeliveriesParams{EndpointID: endpoint.ID, Limit: 10})
+	ids := []uuid.UUID{}
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.ID)
+	}
+	attempts, _ := store.GetWebhookAttempts(ctx, ids)
+	blocked, redirected := 0, 0
+	for _, attempt := range attempts {
+		switch {
+		case strings.Contains(attempt.Error, netguard.ErrBlockedAddress.Error()):
+			blocked++
+		case attempt.StatusCode.Int32 == http.StatusFound:
+			redirected++
+		}
+	}
+	if blocked != 1 || redirected != 1 {
+		t.Errorf("attempts = %+v, want one refused connection and one 302", attempts)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		blocked bool
+	}{
+		{"https://93.184.215.14/hook", false},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://[::1]/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://10.0.0.5/hook", true},
+		{"http://[::ffff:192.168.1.1]/hook", true},
+		{"http://localhost:8080/hook", true},
+	}
+	for _, tc := range tests {
+		err := CheckURL(context.Background(), tc.url)
+		if got := errors.Is(err, netguard.ErrBlockedAddress); got != tc.blocked {
+			t.Errorf("CheckURL(%q) = %v, want blocked %v", tc.url, err, tc.blocked)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/netguard"
+)
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is
+	// marked failed.
+	MaxAttempts = 8
+
+	// requestTimeout bounds a single delivery attempt.
+	requestTimeout = 10 * time.Second
+	dialTimeout    = 5 * time.Second
+	// leaseDuration is how long a claimed delivery is hidden from other
+	// workers. It must outlast requestTimeout.
+	leaseDuration = time.Minute
+	// maxResponseBody is how much of an endpoint's response is kept in
+	// the delivery log.
+	maxResponseBody = 4 << 10
+
+	baseBackoff = 10 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+// Backoff returns how long to wait after the given number of failed
+// attempts: 10s, 20s, 40s and so on, up to 6h.
+func Backoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return backoff
+}
+
+// Worker sends queued deliveries. Several workers, in one process or
+// many, can share a database: each delivery is claimed by one of them.
+type Worker struct {
+	Store      database.Store
+	HTTPClient *http.Client
+	// Interval is how often the outbox is polled.
+	Interval time.Duration
+	// BatchSize is the most deliveries sent per poll.
+	BatchSize int32
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewWorker returns a Worker sending deliveries queued in store.
+func NewWorker(store database.Store) *Worker {
+	return &Worker{
+		Store:      store,
+		HTTPClient: NewHTTPClient(),
+		Interval:   time.Second,
+		BatchSize:  20,
+		Now:        time.Now,
+	}
+}
+
+// NewHTTPClient returns the client deliveries are sent with. Users pick
+// the URLs, so it only connects to public addresses, checked after DNS
+// resolution, and never uses a proxy. It doesn't follow redirects
+// either: a redirect's status is the response.
+func NewHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: netguard.Control,
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   dialTimeout,
+		ResponseHeaderTimeout: requestTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   requestTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// CheckURL reports whether deliveries to rawURL could reach a public
+// address. It returns an error wrapping netguard.ErrBlockedAddress if the
+// host is, or resolves to, an address that isn't publicly routable.
+// Endpoints are checked when they are created; the worker checks every
+// connection again, since DNS answers can change.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else {
+		addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return err
+		}
+	}
+	for _, addr := range addrs {
+		if !netguard.IsPublic(addr) {
+			return fmt.Errorf("%w: %s", netguard.ErrBlockedAddress, addr)
+		}
+	}
+	return nil
+}
+
+// Run sends due deliveries every Interval until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("Sending webhooks every %s...", w.Interval)
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.DeliverDue(ctx); err != nil {
+			log.Printf("Couldn't send webhooks: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue claims the deliveries that are due and tries to send each
+// of them once. It returns how many were tried.
+func (w *Worker) DeliverDue(ctx context.Context) (int, error) {
+	now := w.Now().UTC()
+	deliveries, err := w.Store.ClaimWebhookDeliveries(ctx, database.ClaimWebhookDeliveriesParams{
+		LeaseUntil:    now.Add(leaseDuration),
+		Now:           now,
+		MaxDeliveries: w.BatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return len(deliveries), nil
+}
+
+// deliver makes one attempt at a delivery, records it and schedules the
+// next attempt if it failed.
+func (w *Worker) deliver(ctx context.Context, delivery database.ClaimWebhookDeliveriesRow) {
+	attempt := w.send(ctx, delivery)
+	_, err := w.Store.RecordWebhookAttempt(ctx, attempt)
+	if err != nil {
+		log.Printf("Couldn't record webhook attempt for %s: %s", delivery.ID, err)
+	}
+
+	attempts := delivery.Attempts + 1
+	status := StatusDelivered
+	nextAttemptAt := delivery.NextAttemptAt
+	succeeded := attempt.Error == "" && attempt.StatusCode.Int32 >= 200 && attempt.StatusCode.Int32 <= 299
+	if !succeeded {
+		status = StatusPending
+		nextAttemptAt = w.Now().UTC().Add(Backoff(int(attempts)))
+		if attempts >= MaxAttempts {
+			status = StatusFailed
+		}
+	}
+
+	_, err = w.Store.UpdateWebhookDelivery(ctx, database.UpdateWebhookDeliveryParams{
+		ID:            delivery.ID,
+		Status:        status,
+		Attempts:      attempts,
+		NextAttemptAt: nextAttemptAt,
+	})
+	if err != nil {
+		log.Printf("Couldn't update webhook delivery %s: %s", delivery.ID, err)
+	}
+}
+
+func (w *Worker) send(ctx context.Context, delivery database.ClaimWebhookDeliveriesRow) database.RecordWebhookAttemptParams {
+	attempt := database.RecordWebhookAttemptParams{DeliveryID: delivery.ID}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Chirpy-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, w.Now(), body))
+
+	start := time.Now()
+	resp, err := w.HTTPClient.Do(req)
+	if err != nil {
+		attempt.DurationMs = int32(time.Since(start).Milliseconds())
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	dat, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	attempt.DurationMs = int32(time.Since(start).Milliseconds())
+	attempt.StatusCode = sql.NullInt32{Int32: int32(resp.StatusCode), Valid: true}
+	// Postgres text columns only hold valid UTF-8 without NUL bytes.
+	attempt.ResponseBody = strings.ReplaceAll(strings.ToValidUTF8(string(dat), "\uFFFD"), "\x00", "")
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
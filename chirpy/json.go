@@ -2,25 +2,124 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 )
 
+// errorCode is a machine-readable identifier for the kind of error in an
+// error response, so clients don't have to match on messages.
+type errorCode string
+
+const (
+	errCodeBadRequest           errorCode = "bad_request"
+	errCodeInvalidJSON          errorCode = "invalid_json"
+	errCodeUnknownField         errorCode = "unknown_field"
+	errCodeValidation           errorCode = "validation_failed"
+	errCodeBodyTooLarge         errorCode = "body_too_large"
+	errCodeUnsupportedMediaType errorCode = "unsupported_media_type"
+	errCodeUnauthorized         errorCode = "unauthorized"
+	errCodeForbidden            errorCode = "forbidden"
+	errCodeNotFound             errorCode = "not_found"
+	errCodeConflict             errorCode = "conflict"
+	errCodePreconditionFailed   errorCode = "precondition_failed"
+	errCodeRateLimited          errorCode = "rate_limited"
+	errCodeInternal             errorCode = "internal_error"
+	errCodeNotImplemented       errorCode = "not_implemented"
+)
+
+// errorResponse is the envelope every error is sent in.
+type errorResponse struct {
+	Error   string       `json:"error"`
+	Code    errorCode    `json:"code"`
+	Details []fieldError `json:"details,omitempty"`
+}
+
+// fieldError describes a problem with a single field of a request body.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// requestError is an error caused by the client's request. It carries the
+// status, code and field details to respond with.
+type requestError struct {
+	status  int
+	code    errorCode
+	msg     string
+	details []fieldError
+	err     error
+}
+
+func (e *requestError) Error() string {
+	if e.err != nil {
+		return e.msg + ": " + e.err.Error()
+	}
+	return e.msg
+}
+
+func (e *requestError) Unwrap() error {
+	return e.err
+}
+
 func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
+	respondWithErrorCode(w, code, errorCodeForStatus(code), msg, err)
+}
+
+func respondWithErrorCode(w http.ResponseWriter, code int, errCode errorCode, msg string, err error, details ...fieldError) {
 	if err != nil {
 		log.Println(err)
 	}
 	if code > 499 {
 		log.Printf("Responding with 5XX error: %s", msg)
 	}
-	type errorResponse struct {
-		Error string `json:"error"`
-	}
 	respondWithJSON(w, code, errorResponse{
-		Error: msg,
+		Error:   msg,
+		Code:    errCode,
+		Details: details,
 	})
 }
 
+// respondWithRequestError responds with the status and details of a
+// *requestError, or with a 500 for any other error.
+func respondWithRequestError(w http.ResponseWriter, err error) {
+	reqErr := &requestError{}
+	if !errors.As(err, &reqErr) {
+		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
+		return
+	}
+	respondWithErrorCode(w, reqErr.status, reqErr.code, reqErr.msg, reqErr.err, reqErr.details...)
+}
+
+func errorCodeForStatus(code int) errorCode {
+	switch code {
+	case http.StatusBadRequest:
+		return errCodeBadRequest
+	case http.StatusUnauthorized:
+		return errCodeUnauthorized
+	case http.StatusForbidden:
+		return errCodeForbidden
+	case http.StatusNotFound:
+		return errCodeNotFound
+	case http.StatusConflict:
+		return errCodeConflict
+	case http.StatusPreconditionFailed:
+		return errCodePreconditionFailed
+	case http.StatusRequestEntityTooLarge:
+		return errCodeBodyTooLarge
+	case http.StatusUnsupportedMediaType:
+		return errCodeUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return errCodeRateLimited
+	case http.StatusNotImplemented:
+		return errCodeNotImplemented
+	}
+	if code > 499 {
+		return errCodeInternal
+	}
+	return errCodeBadRequest
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	dat, err := json.Marshal(payload)
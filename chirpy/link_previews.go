@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// LinkPreview describes the page a link in a chirp leads to, as the page
+// describes itself.
+type LinkPreview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// withLinkPreviews fills in the previews that have been fetched for the
+// links in chirps.
+func (cfg *apiConfig) withLinkPreviews(ctx context.Context, chirps []Chirp) error {
+	if len(chirps) == 0 {
+		return nil
+	}
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+	rows, err := cfg.db.GetChirpLinkPreviews(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byChirp := map[uuid.UUID][]LinkPreview{}
+	for _, row := range rows {
+		byChirp[row.ChirpID] = append(byChirp[row.ChirpID], LinkPreview{
+			URL:         row.Url,
+			Title:       row.Title,
+			Description: row.Description,
+			ImageURL:    row.ImageUrl,
+			SiteName:    row.SiteName,
+		})
+	}
+	for i := range chirps {
+		chirps[i].LinkPreviews = byChirp[chirps[i].ID]
+	}
+	return nil
+}
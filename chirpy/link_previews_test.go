@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/unfurl"
+)
+
+func TestLinkPreviews(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, _ := newTestUser(t, srv, "alice@example.com")
+
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<meta property="og:title" content="A page"><meta property="og:description" content="About things.">`)
+	}))
+	defer page.Close()
+
+	chirp, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "read this: " + page.URL + "/post."})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if len(chirp.LinkPreviews) != 0 {
+		t.Errorf("new chirp link_previews = %+v, want none before the fetch", chirp.LinkPreviews)
+	}
+
+	worker := unfurl.NewWorker(cfg.db)
+	worker.Fetcher.Client = page.Client()
+	if n, err := worker.FetchDue(ctx); err != nil || n != 1 {
+		t.Fatalf("FetchDue() = %d, %v, want 1 preview tried", n, err)
+	}
+
+	got, err := alice.GetChirp(ctx, chirp.ID, nil)
+	if err != nil {
+		t.Fatalf("GetChirp() error = %v", err)
+	}
+	want := client.LinkPreview{URL: page.URL + "/post", Title: "A page", Description: "About things."}
+	if len(got.LinkPreviews) != 1 || got.LinkPreviews[0] != want {
+		t.Errorf("link_previews = %+v, want [%+v]", got.LinkPreviews, want)
+	}
+	if !got.UpdatedAt.After(chirp.UpdatedAt) {
+		t.Errorf("updated_at = %v, want it bumped past %v so cached copies refresh", got.UpdatedAt, chirp.UpdatedAt)
+	}
+
+	// A second chirp linking to the page gets the cached preview at once.
+	again, err := alice.CreateChirp(ctx, client.CreateChirpRequest{Body: "again " + page.URL + "/post"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if len(again.LinkPreviews) != 1 || again.LinkPreviews[0] != want {
+		t.Errorf("link_previews = %+v, want the cached preview", again.LinkPreviews)
+	}
+	if n, _ := worker.FetchDue(ctx); n != 0 {
+		t.Errorf("FetchDue() for a cached preview = %d, want 0", n)
+	}
+}
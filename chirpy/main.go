@@ -1,89 +1,236 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"sync/atomic"
 
-	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/compression"
+	"github.com/lsherman98/boot.dev/chirpy/internal/config"
+	"github.com/lsherman98/boot.dev/chirpy/internal/cors"
 	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+	"github.com/lsherman98/boot.dev/chirpy/internal/oidc"
+	"github.com/lsherman98/boot.dev/chirpy/internal/ratelimit"
+	"github.com/lsherman98/boot.dev/chirpy/internal/unfurl"
+	"github.com/lsherman98/boot.dev/chirpy/internal/webhooks"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
-	db             *database.Queries
+	db             database.Store
 	platform       string
 	jwtSecret      string
 	polkaKey       string
+	rateLimiter    ratelimit.Store
+	events         *events.Broker
+	eventPublisher events.Publisher
+	// checkWebhookURL refuses webhook URLs that lead to internal
+	// addresses. It defaults to webhooks.CheckURL.
+	checkWebhookURL func(ctx context.Context, rawURL string) error
+	// passwordHashing are the Argon2id parameters new password hashes are
+	// made with. Login rehashes passwords whose hashes use others.
+	passwordHashing auth.Argon2idParams
+	// passwordResetMailer delivers password reset tokens. Without one,
+	// POST /api/password-reset answers 501.
+	passwordResetMailer passwordResetMailer
+	// oidcProviders are the identity providers users can sign in with,
+	// by the name used in their routes.
+	oidcProviders map[string]*oidc.Provider
+	// accountDeletion is what DELETE /api/users/me does with the user:
+	// accountDeletionHardDelete or accountDeletionAnonymize.
+	accountDeletion string
+	// recentWrites keeps users' reads on the primary for a while after
+	// they write, so they see their writes.
+	recentWrites recentWrites
 }
 
 func main() {
-	const filepathRoot = "."
-	const port = "8080"
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%s", err)
+	}
 
-	godotenv.Load(".env")
-	dbURL := os.Getenv("DB_URL")
-	if dbURL == "" {
-		log.Fatal("DB_URL must be set")
+	store, err := openStore(cfg)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	dbConn, err := sql.Open("postgres", dbURL)
+	if err := promoteAdmins(context.Background(), store, cfg.AdminEmails); err != nil {
+		log.Fatal(err)
+	}
+
+	rateLimiter, err := openRateLimiter(cfg, store)
 	if err != nil {
-		log.Fatalf("Error opening database: %s", err)
+		log.Fatal(err)
 	}
-	platform := os.Getenv("PLATFORM")
-	if platform == "" {
-		log.Fatal("ADMIN_KEY environment variable is not set")
+
+	broker := events.NewBroker()
+	eventPublisher, err := openEventPublisher(cfg, store, broker)
+	if err != nil {
+		log.Fatal(err)
 	}
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		log.Fatal("JWT_SECRET environment variable is not set")
+
+	apiCfg := &apiConfig{
+		fileserverHits:  atomic.Int32{},
+		db:              store,
+		rateLimiter:     rateLimiter,
+		events:          broker,
+		eventPublisher:  eventPublisher,
+		jwtSecret:       cfg.JWTSecret,
+		polkaKey:        cfg.PolkaKey,
+		platform:        cfg.Platform,
+		accountDeletion: cfg.AccountDeletion,
+		passwordHashing: cfg.PasswordHashing,
+		oidcProviders:   openOIDCProviders(cfg),
 	}
-	polkaKey := os.Getenv("POLKA_KEY")
-	if polkaKey == "" {
-		log.Fatal("POLKA_KEY environment variable is not set")
+	if cfg.Platform == "dev" {
+		apiCfg.passwordResetMailer = logPasswordResetMailer{}
 	}
-	dbQueries := database.New(dbConn)
-
-	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             dbQueries,
-		jwtSecret:      jwtSecret,
-		polkaKey:       polkaKey,
-		platform:       platform,
+
+	go webhooks.NewWorker(store).Run(context.Background())
+	go unfurl.NewWorker(store).Run(context.Background())
+	go apiCfg.newChirpPublisher().Run(context.Background())
+
+	var appFiles fs.FS = os.DirFS(cfg.FilepathRoot)
+	if cfg.StaticEmbed {
+		appFiles = embeddedAppFiles
 	}
 
-	mux := http.NewServeMux()
-	fsHandler := apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
-	mux.Handle("/app/", fsHandler)
+	corsPolicy, err := openCORS(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	mux.HandleFunc("GET /api/healthz", handlerReadiness)
+	mux := http.NewServeMux()
+	apiCfg.registerRoutes(mux, appFiles)
 
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerWebhook)
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: middlewareRequestID(middlewareClientIP(cfg.TrustedProxies, compression.Middleware(middlewareSecurityHeaders(cfg.HSTSMaxAge, corsPolicy.Handler(apiCfg.middlewareReplicaReads(mux)))))),
+	}
 
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	log.Printf("Serving on: %s\n", cfg.Port)
+	log.Fatal(srv.ListenAndServe())
+}
 
-	mux.HandleFunc("POST /api/users", apiCfg.handlerUsersCreate)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUsersUpdate)
+// openStore connects to the storage backend selected by DB_DRIVER:
+// "postgres" using DB_URL, and DB_REPLICA_URL for reads if it is set, or
+// "json" using the file at DB_PATH. Postgres databases have
+// DB_CONNECT_TIMEOUT to answer.
+func openStore(cfg *config.Config) (database.Store, error) {
+	switch cfg.DBDriver {
+	case "postgres":
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.DBConnectTimeout)
+		defer cancel()
+		pool := database.PoolConfig{
+			MaxOpenConns:    cfg.DBMaxOpenConns,
+			MaxIdleConns:    cfg.DBMaxIdleConns,
+			ConnMaxLifetime: cfg.DBConnMaxLifetime,
+			ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+		}
+		primary, err := database.OpenPostgres(ctx, cfg.DBURL, pool)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening database: %w", err)
+		}
+		if cfg.DBReplicaURL == "" {
+			return database.NewPostgres(primary), nil
+		}
+		replica, err := database.OpenPostgres(ctx, cfg.DBReplicaURL, pool)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening read replica: %w", err)
+		}
+		return database.NewPostgresWithReplica(primary, replica), nil
+	case "json":
+		db, err := database.NewDB(cfg.DBPath)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening database file: %w", err)
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q", cfg.DBDriver)
+	}
+}
 
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerChirpsCreate)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerChirpsRetrieve)
-	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerChirpsGet)
-	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerChirpsDelete)
+// openRateLimiter returns the rate limit store selected by
+// RATE_LIMIT_STORE: "memory" limits each replica on its own, "postgres"
+// shares limits between replicas through the database.
+func openRateLimiter(cfg *config.Config, store database.Store) (ratelimit.Store, error) {
+	switch cfg.RateLimitStore {
+	case "memory":
+		return ratelimit.NewMemoryStore(), nil
+	case "postgres":
+		pg, ok := store.(*database.Postgres)
+		if !ok {
+			return nil, errors.New("RATE_LIMIT_STORE=postgres requires DB_DRIVER=postgres")
+		}
+		limiter := ratelimit.NewPostgresStore(pg.Queries)
+		go limiter.RunPruner(context.Background(), rateLimitPruneInterval, maxRateLimitPeriod())
+		return limiter, nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q", cfg.RateLimitStore)
+	}
+}
 
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+// openEventPublisher returns the chirp event publisher selected by
+// EVENT_BUS: "memory" only reaches streams served by this replica,
+// "postgres" relays events between replicas with LISTEN/NOTIFY.
+func openEventPublisher(cfg *config.Config, store database.Store, broker *events.Broker) (events.Publisher, error) {
+	switch cfg.EventBus {
+	case "memory":
+		return broker, nil
+	case "postgres":
+		pg, ok := store.(*database.Postgres)
+		if !ok {
+			return nil, errors.New("EVENT_BUS=postgres requires DB_DRIVER=postgres")
+		}
+		bridge := events.NewPostgresBridge(pg.Queries, broker)
+		go func() {
+			err := bridge.Listen(context.Background(), cfg.DBURL)
+			log.Fatalf("Chirp event listener stopped: %s", err)
+		}()
+		return bridge, nil
+	default:
+		return nil, fmt.Errorf("unknown EVENT_BUS %q", cfg.EventBus)
+	}
+}
 
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+// openOIDCProviders returns the identity provider set up with
+// OIDC_ISSUER, if any, under the name OIDC_PROVIDER.
+func openOIDCProviders(cfg *config.Config) map[string]*oidc.Provider {
+	providers := map[string]*oidc.Provider{}
+	if cfg.OIDCIssuer == "" {
+		return providers
 	}
+	providers[cfg.OIDCProvider] = oidc.NewProvider(oidc.Config{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       cfg.OIDCScopes,
+	})
+	return providers
+}
 
-	log.Printf("Serving on: %s\n", port)
-	log.Fatal(srv.ListenAndServe())
+// openCORS returns the CORS policy for browser clients on other origins.
+// With no CORS_ALLOWED_ORIGINS, no cross-origin requests are allowed.
+func openCORS(cfg *config.Config) (*cors.CORS, error) {
+	return cors.New(cors.Config{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		MaxAge:           cfg.CORSMaxAge,
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", "If-Modified-Since", "Last-Event-ID", "X-Request-ID"},
+		ExposedHeaders:   []string{"ETag", "Last-Modified", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Request-ID"},
+	})
 }
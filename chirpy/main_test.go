@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+	"github.com/lsherman98/boot.dev/chirpy/internal/events"
+	"github.com/lsherman98/boot.dev/chirpy/internal/ratelimit"
+)
+
+// newTestServer serves chirpy's routes backed by a fresh file-backed
+// database.
+func newTestServer(t *testing.T) (*httptest.Server, *apiConfig) {
+	t.Helper()
+
+	db, err := database.NewDB(filepath.Join(t.TempDir(), "database.json"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	broker := events.NewBroker()
+	cfg := &apiConfig{
+		db:             db,
+		platform:       "dev",
+		jwtSecret:      "test-secret",
+		polkaKey:       "test-polka-key",
+		rateLimiter:    ratelimit.NewMemoryStore(),
+		events:         broker,
+		eventPublisher: broker,
+		// Hashing passwords with the default Argon2id parameters would
+		// slow down every signup and login.
+		passwordHashing: auth.Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1},
+	}
+
+	mux := http.NewServeMux()
+	cfg.registerRoutes(mux, os.DirFS("."))
+	srv := httptest.NewServer(middlewareRequestID(mux))
+	t.Cleanup(srv.Close)
+	return srv, cfg
+}
+
+// newTestUser signs up a user and returns a client logged in as them.
+func newTestUser(t *testing.T, srv *httptest.Server, email string) (*client.Client, client.LoginResponse) {
+	t.Helper()
+	ctx := context.Background()
+	c := client.New(srv.URL)
+
+	creds := client.Credentials{Email: email, Password: "password"}
+	if _, err := c.CreateUser(ctx, creds); err != nil {
+		t.Fatalf("CreateUser(%s) error = %v", email, err)
+	}
+	login, err := c.Login(ctx, creds)
+	if err != nil {
+		t.Fatalf("Login(%s) error = %v", email, err)
+	}
+	c.AccessToken = login.Token
+	c.RefreshToken = login.RefreshToken
+	return c, login
+}
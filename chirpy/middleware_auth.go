@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+type authedHandler func(http.ResponseWriter, *http.Request, database.User)
+
+// middlewareAuth passes the user whose access token authorizes the
+// request to handler.
+func (cfg *apiConfig) middlewareAuth(handler authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+			return
+		}
+		userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+			return
+		}
+
+		user, err := cfg.db.GetUserByID(r.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Couldn't get user", err)
+			return
+		}
+		if user.DeletedAt.Valid {
+			respondWithError(w, http.StatusUnauthorized, "Account has been deleted", nil)
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
+
+// middlewareAdmin is middlewareAuth for routes only admins may use.
+func (cfg *apiConfig) middlewareAdmin(handler authedHandler) http.HandlerFunc {
+	return cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if !user.IsAdmin {
+			respondWithError(w, http.StatusForbidden, "Admins only", nil)
+			return
+		}
+		handler(w, r, user)
+	})
+}
+
+// middlewareCanPost is middlewareAuth for routes that publish content or
+// interact with other users, which suspended users may not use. They can
+// still sign in, read, and export or delete their account.
+func (cfg *apiConfig) middlewareCanPost(handler authedHandler) http.HandlerFunc {
+	return cfg.middlewareAuth(func(w http.ResponseWriter, r *http.Request, user database.User) {
+		if isSuspended(user) {
+			respondWithError(w, http.StatusForbidden, "Account is suspended until "+user.SuspendedUntil.Time.Format(time.RFC3339), nil)
+			return
+		}
+		handler(w, r, user)
+	})
+}
+
+func isSuspended(user database.User) bool {
+	return user.SuspendedUntil.Valid && user.SuspendedUntil.Time.After(time.Now())
+}
+
+// optionalUser returns the user whose access token authorizes the
+// request, for public routes that show more to some users. Requests
+// without a valid token get the zero User.
+func (cfg *apiConfig) optionalUser(r *http.Request) database.User {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return database.User{}
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		return database.User{}
+	}
+	user, err := cfg.db.GetUserByID(r.Context(), userID)
+	if err != nil || user.DeletedAt.Valid {
+		return database.User{}
+	}
+	return user
+}
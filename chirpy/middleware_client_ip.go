@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type clientIPKey struct{}
+
+// middlewareClientIP works out the address each request came from, for
+// rate limits and the audit log. Requests from trustedProxies come from
+// the last address in X-Forwarded-For that isn't one of theirs; earlier
+// addresses were added by the client itself and can't be believed.
+func middlewareClientIP(trustedProxies []netip.Prefix, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := forwardedFor(r, trustedProxies)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+	})
+}
+
+// forwardedFor walks X-Forwarded-For back from the connection's peer for
+// as long as each hop is a trusted proxy.
+func forwardedFor(r *http.Request, trustedProxies []netip.Prefix) string {
+	ip := remoteIP(r)
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops); ; i-- {
+		addr, err := netip.ParseAddr(ip)
+		if err != nil || !isTrustedProxy(addr, trustedProxies) || i == 0 {
+			return ip
+		}
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i-1]))
+		if err != nil {
+			// A garbled hop; the proxy that passed it on is as far back
+			// as can be trusted.
+			return ip
+		}
+		ip = hop.Unmap().String()
+	}
+}
+
+func isTrustedProxy(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the address middlewareClientIP found for r, or the
+// connection's peer outside it.
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
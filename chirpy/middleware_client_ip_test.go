@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestMiddlewareClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted peer's header is ignored", "203.0.113.5:1234", []string{"198.51.100.7"}, "203.0.113.5"},
+		{"through a trusted proxy", "10.1.2.3:1234", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"through two trusted proxies", "10.1.2.3:1234", []string{"198.51.100.7, 192.0.2.1"}, "198.51.100.7"},
+		{"forged hops are skipped", "10.1.2.3:1234", []string{"1.1.1.1, 198.51.100.7"}, "198.51.100.7"},
+		{"split across headers", "10.1.2.3:1234", []string{"198.51.100.7", "192.0.2.1"}, "198.51.100.7"},
+		{"garbled hop", "10.1.2.3:1234", []string{"198.51.100.7, nonsense"}, "10.1.2.3"},
+		{"only proxies", "10.1.2.3:1234", []string{"10.9.9.9"}, "10.9.9.9"},
+		{"no header from a proxy", "10.1.2.3:1234", nil, "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := middlewareClientIP(trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = clientIP(r)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/api/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if seen != tt.want {
+				t.Errorf("clientIP() = %q, want %q", seen, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+// primaryStickiness is how long after a user's last write their reads
+// keep going to the primary, which is ample time for a replica to catch
+// up.
+const primaryStickiness = 10 * time.Second
+
+// middlewareReplicaReads lets GET and HEAD requests read from the read
+// replica, if there is one. Other requests, and any reads a GET makes
+// after writing, use the primary so they see their own writes. So do the
+// GETs of a user who made any other request in the last
+// primaryStickiness, so a chirp can be read back right after it is
+// posted.
+func (cfg *apiConfig) middlewareReplicaReads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, signedIn := uuid.Nil, false
+		if token, err := auth.GetBearerToken(r.Header); err == nil {
+			userID, err = auth.ValidateJWT(token, cfg.jwtSecret)
+			signedIn = err == nil
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			if signedIn {
+				cfg.recentWrites.record(userID, time.Now())
+			}
+			return
+		}
+		if !signedIn || !cfg.recentWrites.since(userID, time.Now().Add(-primaryStickiness)) {
+			r = r.WithContext(database.WithReplicaReads(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recentWrites remembers when each user last made a request that may
+// have written. It only knows about requests this replica served. The
+// zero value is ready to use.
+type recentWrites struct {
+	mu        sync.Mutex
+	last      map[uuid.UUID]time.Time
+	lastSweep time.Time
+}
+
+func (rw *recentWrites) record(userID uuid.UUID, now time.Time) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if rw.last == nil {
+		rw.last = map[uuid.UUID]time.Time{}
+	}
+	rw.last[userID] = now
+	// Users who stopped writing are forgotten every so often, so the map
+	// only holds the ones who still stick to the primary.
+	if now.Sub(rw.lastSweep) > primaryStickiness {
+		for id, t := range rw.last {
+			if now.Sub(t) > primaryStickiness {
+				delete(rw.last, id)
+			}
+		}
+		rw.lastSweep = now
+	}
+}
+
+// since reports whether the user has written at or after t.
+func (rw *recentWrites) since(userID uuid.UUID, t time.Time) bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	last, ok := rw.last[userID]
+	return ok && !last.Before(t)
+}
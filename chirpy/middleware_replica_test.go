@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/database"
+)
+
+func TestMiddlewareReplicaReads(t *testing.T) {
+	cfg := &apiConfig{jwtSecret: "test-secret"}
+	var fromReplica bool
+	handler := cfg.middlewareReplicaReads(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromReplica = database.ReadsFromReplica(r.Context())
+	}))
+	token := func(userID uuid.UUID) string {
+		t.Helper()
+		token, err := auth.MakeJWT(userID, cfg.jwtSecret, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	alice, bob := token(uuid.New()), token(uuid.New())
+	serve := func(method, path, token string) bool {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return fromReplica
+	}
+
+	if !serve(http.MethodGet, "/api/chirps", alice) {
+		t.Error("GET before any write read from the primary, want the replica")
+	}
+	if serve(http.MethodPost, "/api/chirps", alice) {
+		t.Error("POST read from the replica, want the primary")
+	}
+	if serve(http.MethodGet, "/api/chirps/1", alice) {
+		t.Error("GET right after the user's POST read from the replica, want the primary")
+	}
+	if !serve(http.MethodGet, "/api/chirps/1", bob) {
+		t.Error("another user's GET read from the primary, want the replica")
+	}
+	if !serve(http.MethodGet, "/api/chirps/1", "") {
+		t.Error("anonymous GET read from the primary, want the replica")
+	}
+
+	// Once the replica has had time to catch up, the user reads from it
+	// again.
+	rw := &recentWrites{}
+	userID, now := uuid.New(), time.Now()
+	rw.record(userID, now.Add(-primaryStickiness-time.Second))
+	if rw.since(userID, now.Add(-primaryStickiness)) {
+		t.Error("since() = true for a write older than primaryStickiness, want false")
+	}
+	rw.record(uuid.New(), now)
+	if _, ok := rw.last[userID]; ok {
+		t.Error("record() kept a user whose writes are too old to matter")
+	}
+}
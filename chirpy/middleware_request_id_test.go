@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"none", "", false},
+		{"from a proxy", "req-1234", true},
+		{"with spaces", "req 1234", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := middlewareRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = requestID(r)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/healthz", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if seen == "" || rec.Header().Get("X-Request-ID") != seen {
+				t.Errorf("request ID = %q, response header = %q, want the same non-empty ID", seen, rec.Header().Get("X-Request-ID"))
+			}
+			if (seen == tt.header) != tt.keep {
+				t.Errorf("request ID = %q for header %q, keep = %v", seen, tt.header, tt.keep)
+			}
+		})
+	}
+}
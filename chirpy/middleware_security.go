@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// securityPolicy is the set of security headers sent with a response.
+type securityPolicy struct {
+	ContentSecurityPolicy string
+	ReferrerPolicy        string
+	FrameOptions          string
+}
+
+var (
+	// apiSecurityPolicy is for JSON and the admin pages, which load
+	// nothing and should never be framed.
+	apiSecurityPolicy = securityPolicy{
+		ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+		ReferrerPolicy:        "no-referrer",
+		FrameOptions:          "DENY",
+	}
+	// appSecurityPolicy is for the web app under /app/, which loads its
+	// own scripts, styles and images.
+	appSecurityPolicy = securityPolicy{
+		ContentSecurityPolicy: "default-src 'self'; img-src 'self' data: https:; object-src 'none'; base-uri 'self'; frame-ancestors 'none'",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		FrameOptions:          "DENY",
+	}
+)
+
+// routeSecurityPolicies picks the policy for a request by the longest
+// matching path prefix. Paths no prefix matches get apiSecurityPolicy.
+var routeSecurityPolicies = map[string]securityPolicy{
+	"/app/": appSecurityPolicy,
+	"/api/": apiSecurityPolicy,
+}
+
+// middlewareSecurityHeaders sets the security headers of the policy
+// routeSecurityPolicies picks for each request, plus
+// Strict-Transport-Security when hstsMaxAge is positive. Handlers can
+// still override any of them.
+func middlewareSecurityHeaders(hstsMaxAge time.Duration, next http.Handler) http.Handler {
+	hsts := ""
+	if hstsMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(hstsMaxAge.Seconds())) + "; includeSubDomains"
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := securityPolicyFor(r.URL.Path)
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Content-Security-Policy", policy.ContentSecurityPolicy)
+		h.Set("Referrer-Policy", policy.ReferrerPolicy)
+		h.Set("X-Frame-Options", policy.FrameOptions)
+		if hsts != "" {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func securityPolicyFor(path string) securityPolicy {
+	policy, longest := apiSecurityPolicy, 0
+	for prefix, p := range routeSecurityPolicies {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			policy, longest = p, len(prefix)
+		}
+	}
+	return policy
+}
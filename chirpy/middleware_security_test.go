@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMiddlewareSecurityHeaders(t *testing.T) {
+	handler := middlewareSecurityHeaders(365*24*time.Hour, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		path   string
+		policy securityPolicy
+	}{
+		{"/app/", appSecurityPolicy},
+		{"/app/assets/logo.png", appSecurityPolicy},
+		{"/api/chirps", apiSecurityPolicy},
+		{"/admin/metrics", apiSecurityPolicy},
+	}
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		h := rec.Header()
+		if got := h.Get("Content-Security-Policy"); got != tc.policy.ContentSecurityPolicy {
+			t.Errorf("%s Content-Security-Policy = %q, want %q", tc.path, got, tc.policy.ContentSecurityPolicy)
+		}
+		if got := h.Get("Referrer-Policy"); got != tc.policy.ReferrerPolicy {
+			t.Errorf("%s Referrer-Policy = %q, want %q", tc.path, got, tc.policy.ReferrerPolicy)
+		}
+		if h.Get("X-Content-Type-Options") != "nosniff" || h.Get("Strict-Transport-Security") != "max-age=31536000; includeSubDomains" {
+			t.Errorf("%s headers = %v, want nosniff and HSTS", tc.path, h)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec documents every /api and /admin route. The typed client in
+// ./client is generated from it, so run go generate ./client after
+// editing openapi.json.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func handlerOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
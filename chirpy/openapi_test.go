@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+type recordingMux struct {
+	patterns []string
+}
+
+func (m *recordingMux) Handle(pattern string, handler http.Handler) {
+	m.patterns = append(m.patterns, pattern)
+}
+
+func (m *recordingMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+}
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	spec := struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	mux := &recordingMux{}
+	(&apiConfig{}).registerRoutes(mux, os.DirFS("."))
+
+	registered := map[string]bool{}
+	for _, pattern := range mux.patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			// Method-less patterns like the /app/ fileserver aren't
+			// part of the API.
+			continue
+		}
+		if !strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/admin/") {
+			continue
+		}
+		registered[pattern] = true
+		if _, ok := spec.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("route %q is missing from openapi.json", pattern)
+		}
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			pattern := strings.ToUpper(method) + " " + path
+			if !registered[pattern] {
+				t.Errorf("openapi.json documents %q, which isn't registered", pattern)
+			}
+		}
+	}
+}
+
+func TestGeneratedClient(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ctx := context.Background()
+	c := client.New(srv.URL)
+
+	creds := client.Credentials{Email: "walt@example.com", Password: "04234"}
+	if _, err := c.CreateUser(ctx, creds); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	login, err := c.Login(ctx, creds)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	c.AccessToken = login.Token
+	c.RefreshToken = login.RefreshToken
+
+	chirp, err := c.CreateChirp(ctx, client.CreateChirpRequest{Body: "I had a kerfuffle"})
+	if err != nil {
+		t.Fatalf("CreateChirp() error = %v", err)
+	}
+	if chirp.Body != "I had a ****" || chirp.UserID != login.ID {
+		t.Errorf("CreateChirp() = %+v", chirp)
+	}
+
+	desc := "desc"
+	chirps, err := c.ListChirps(ctx, &client.ListChirpsParams{AuthorID: &login.ID, Sort: &desc})
+	if err != nil {
+		t.Fatalf("ListChirps() error = %v", err)
+	}
+	if len(chirps) != 1 || chirps[0].ID != chirp.ID {
+		t.Errorf("ListChirps() = %+v, want [%v]", chirps, chirp.ID)
+	}
+
+	if _, err := c.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if err := c.DeleteChirp(ctx, chirp.ID, nil); err != nil {
+		t.Fatalf("DeleteChirp() error = %v", err)
+	}
+
+	_, err = c.GetChirp(ctx, chirp.ID, nil)
+	apiErr := &client.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("GetChirp() after delete error = %v, want 404", err)
+	}
+
+	spec, err := c.GetOpenAPI(ctx)
+	if err != nil || !json.Valid(spec) {
+		t.Errorf("GetOpenAPI() = %d bytes, %v", len(spec), err)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/auth"
+	"github.com/lsherman98/boot.dev/chirpy/internal/ratelimit"
+)
+
+// rateLimitClass groups routes that share a rate limit.
+type rateLimitClass string
+
+const (
+	rateLimitLogin         rateLimitClass = "login"
+	rateLimitPasswordReset rateLimitClass = "password_reset"
+	rateLimitChirpCreate   rateLimitClass = "chirp_create"
+	rateLimitChirpImport   rateLimitClass = "chirp_import"
+	rateLimitMessageCreate rateLimitClass = "message_create"
+	rateLimitWebhook       rateLimitClass = "webhook"
+)
+
+// rateLimitPolicy is the limit for a class of routes. Chirpy Red users
+// get chirpyRed instead, when it is set.
+type rateLimitPolicy struct {
+	limit     ratelimit.Limit
+	chirpyRed ratelimit.Limit
+}
+
+var rateLimitPolicies = map[rateLimitClass]rateLimitPolicy{
+	rateLimitLogin: {
+		limit: ratelimit.Limit{Requests: 10, Period: time.Minute},
+	},
+	// Each reset request can send an email, so they're scarcer than
+	// logins.
+	rateLimitPasswordReset: {
+		limit: ratelimit.Limit{Requests: 5, Period: 15 * time.Minute},
+	},
+	rateLimitChirpCreate: {
+		limit:     ratelimit.Limit{Requests: 30, Period: time.Minute},
+		chirpyRed: ratelimit.Limit{Requests: 120, Period: time.Minute},
+	},
+	// An import can create thousands of chirps at once.
+	rateLimitChirpImport: {
+		limit: ratelimit.Limit{Requests: 5, Period: time.Hour},
+	},
+	rateLimitMessageCreate: {
+		limit: ratelimit.Limit{Requests: 60, Period: time.Minute},
+	},
+	rateLimitWebhook: {
+		limit: ratelimit.Limit{Requests: 300, Period: time.Minute},
+	},
+}
+
+// rateLimitPruneInterval is how often idle buckets are deleted from a
+// shared rate limit store.
+const rateLimitPruneInterval = 10 * time.Minute
+
+// maxRateLimitPeriod returns the longest Period of any policy. Buckets
+// idle for that long have refilled completely.
+func maxRateLimitPeriod() time.Duration {
+	longest := time.Duration(0)
+	for _, policy := range rateLimitPolicies {
+		longest = max(longest, policy.limit.Period, policy.chirpyRed.Period)
+	}
+	return longest
+}
+
+// middlewareRateLimit limits requests to next by the authenticated user,
+// or by client IP for anonymous requests. Every response carries
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds
+// until the bucket is full again) headers.
+func (cfg *apiConfig) middlewareRateLimit(class rateLimitClass, next http.HandlerFunc) http.HandlerFunc {
+	policy := rateLimitPolicies[class]
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cfg.rateLimiter == nil {
+			next(w, r)
+			return
+		}
+
+		principal, limit := cfg.rateLimitPrincipal(r, policy)
+		res, err := cfg.rateLimiter.Take(r.Context(), string(class)+":"+principal, limit)
+		if err != nil {
+			// Fail open: an unavailable limiter shouldn't take the API down.
+			log.Printf("Couldn't check rate limit: %s", err)
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter)))
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			respondWithError(w, http.StatusTooManyRequests, "Too many requests, slow down", nil)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// rateLimitPrincipal identifies who a request counts against and picks
+// their limit.
+func (cfg *apiConfig) rateLimitPrincipal(r *http.Request, policy rateLimitPolicy) (string, ratelimit.Limit) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err == nil {
+		userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+		if err == nil {
+			limit := policy.limit
+			if policy.chirpyRed.Requests > 0 {
+				user, err := cfg.db.GetUserByID(r.Context(), userID)
+				if err == nil && user.IsChirpyRed {
+					limit = policy.chirpyRed
+				}
+			}
+			return "user:" + userID.String(), limit
+		}
+	}
+	return "ip:" + clientIP(r), policy.limit
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
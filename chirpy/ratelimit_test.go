@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+func TestRateLimitLogin(t *testing.T) {
+	srv, _ := newTestServer(t)
+	limit := rateLimitPolicies[rateLimitLogin].limit.Requests
+
+	for i := 0; i <= limit; i++ {
+		resp, err := http.Post(srv.URL+"/api/login", "application/json",
+			strings.NewReader(`{"email":"nobody@example.com","password":"guess"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Get("X-RateLimit-Limit"); got != strconv.Itoa(limit) {
+			t.Errorf("X-RateLimit-Limit = %q, want %d", got, limit)
+		}
+		if i < limit {
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("request %d status = %d, want %d", i, resp.StatusCode, http.StatusUnauthorized)
+			}
+			if got := resp.Header.Get("X-RateLimit-Remaining"); got != strconv.Itoa(limit-i-1) {
+				t.Errorf("request %d X-RateLimit-Remaining = %q, want %d", i, got, limit-i-1)
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("request past the limit status = %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+		}
+		if resp.Header.Get("Retry-After") == "" {
+			t.Errorf("429 response has no Retry-After header")
+		}
+	}
+}
+
+func TestRateLimitChirpyRedQuota(t *testing.T) {
+	srv, cfg := newTestServer(t)
+	ctx := context.Background()
+	c := client.New(srv.URL)
+
+	creds := client.Credentials{Email: "red@example.com", Password: "pw"}
+	user, err := c.CreateUser(ctx, creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, err := c.Login(ctx, creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chirpLimit := func() string {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/chirps", strings.NewReader(`{"body":"hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+login.Token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.Header.Get("X-RateLimit-Limit")
+	}
+
+	policy := rateLimitPolicies[rateLimitChirpCreate]
+	if got := chirpLimit(); got != strconv.Itoa(policy.limit.Requests) {
+		t.Errorf("X-RateLimit-Limit = %q, want %d", got, policy.limit.Requests)
+	}
+
+	if _, err := cfg.db.UpgradeToChirpyRed(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := chirpLimit(); got != strconv.Itoa(policy.chirpyRed.Requests) {
+		t.Errorf("Chirpy Red X-RateLimit-Limit = %q, want %d", got, policy.chirpyRed.Requests)
+	}
+}
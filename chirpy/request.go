@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxRequestBodyBytes caps the size of JSON request bodies.
+const maxRequestBodyBytes = 1 << 20
+
+// decodeJSON decodes a single JSON object from the request body into dst.
+// The request must be sent as application/json, fit in
+// maxRequestBodyBytes and only contain fields that dst declares. The
+// returned error is a *requestError ready for respondWithRequestError.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return &requestError{
+			status: http.StatusUnsupportedMediaType,
+			code:   errCodeUnsupportedMediaType,
+			msg:    "Content-Type must be application/json",
+			err:    err,
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(dst)
+	if err != nil {
+		return decodeError(err)
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return &requestError{
+			status: http.StatusBadRequest,
+			code:   errCodeInvalidJSON,
+			msg:    "Request body must contain a single JSON object",
+			err:    err,
+		}
+	}
+	return nil
+}
+
+// decodeLenientJSON decodes a JSON object from the request body into dst
+// like decodeJSON, but accepts any Content-Type and ignores fields dst
+// doesn't declare. It is for requests from third parties, whose payloads
+// can grow new fields without warning.
+func decodeLenientJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+func decodeError(err error) *requestError {
+	syntaxErr := &json.SyntaxError{}
+	typeErr := &json.UnmarshalTypeError{}
+	maxBytesErr := &http.MaxBytesError{}
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &requestError{
+			status: http.StatusRequestEntityTooLarge,
+			code:   errCodeBodyTooLarge,
+			msg:    fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit),
+			err:    err,
+		}
+	case errors.Is(err, io.EOF):
+		return &requestError{
+			status: http.StatusBadRequest,
+			code:   errCodeInvalidJSON,
+			msg:    "Request body must not be empty",
+			err:    err,
+		}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &requestError{
+			status: http.StatusBadRequest,
+			code:   errCodeInvalidJSON,
+			msg:    "Request body contains malformed JSON",
+			err:    err,
+		}
+	case errors.As(err, &typeErr):
+		return &requestError{
+			status:  http.StatusBadRequest,
+			code:    errCodeValidation,
+			msg:     "Request body contains an invalid value",
+			details: []fieldError{{Field: typeErr.Field, Message: fmt.Sprintf("must be a %s", typeErr.Type)}},
+			err:     err,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &requestError{
+			status:  http.StatusBadRequest,
+			code:    errCodeUnknownField,
+			msg:     "Request body contains an unknown field",
+			details: []fieldError{{Field: field, Message: "is not allowed"}},
+			err:     err,
+		}
+	}
+	return &requestError{
+		status: http.StatusBadRequest,
+		code:   errCodeInvalidJSON,
+		msg:    "Couldn't decode parameters",
+		err:    err,
+	}
+}
+
+// validation collects field-level problems with a request so they can be
+// reported all at once.
+type validation []fieldError
+
+func (v *validation) add(field, msg string) {
+	*v = append(*v, fieldError{Field: field, Message: msg})
+}
+
+func (v *validation) require(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *validation) requireEmail(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		v.add(field, "must be a valid email address")
+	}
+}
+
+func (v *validation) requireURL(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, "must be an absolute http or https URL")
+	}
+}
+
+func (v *validation) requirePublishAt(field string, value time.Time) {
+	now := time.Now()
+	switch {
+	case !value.After(now):
+		v.add(field, "must be in the future")
+	case value.After(now.Add(maxScheduleAhead)):
+		v.add(field, "must be within a year")
+	}
+}
+
+// err returns a *requestError listing every problem, or nil if there
+// were none.
+func (v validation) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	msg := "Request failed validation"
+	if len(v) == 1 {
+		msg = v[0].Field + " " + v[0].Message
+	}
+	return &requestError{
+		status:  http.StatusBadRequest,
+		code:    errCodeValidation,
+		msg:     msg,
+		details: v,
+	}
+}
+
+// encodeCursor returns the cursor for the page of rows after the one
+// created at createdAt with id, which are older ones unless the list is
+// oldest first. It is opaque to clients.
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	dat, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	createdAtString, idString, ok := strings.Cut(string(dat), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+	return createdAt, id, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lsherman98/boot.dev/chirpy/client"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type parameters struct {
+		Email string `json:"email"`
+		Age   int    `json:"age"`
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    errorCode
+		wantField   string
+	}{
+		{
+			name:        "Valid body",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"email":"a@example.com","age":3}`,
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "Missing content type",
+			contentType: "",
+			body:        `{"email":"a@example.com"}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    errCodeUnsupportedMediaType,
+		},
+		{
+			name:        "Wrong content type",
+			contentType: "text/plain",
+			body:        `{"email":"a@example.com"}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    errCodeUnsupportedMediaType,
+		},
+		{
+			name:        "Malformed JSON",
+			contentType: "application/json",
+			body:        `{"email":`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    errCodeInvalidJSON,
+		},
+		{
+			name:        "Empty body",
+			contentType: "application/json",
+			body:        ``,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    errCodeInvalidJSON,
+		},
+		{
+			name:        "Unknown field",
+			contentType: "application/json",
+			body:        `{"email":"a@example.com","is_admin":true}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    errCodeUnknownField,
+			wantField:   "is_admin",
+		},
+		{
+			name:        "Wrong type",
+			contentType: "application/json",
+			body:        `{"age":"three"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    errCodeValidation,
+			wantField:   "age",
+		},
+		{
+			name:        "Trailing data",
+			contentType: "application/json",
+			body:        `{"email":"a@example.com"}{}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    errCodeInvalidJSON,
+		},
+		{
+			name:        "Too large",
+			contentType: "application/json",
+			body:        `{"email":"` + strings.Repeat("a", maxRequestBodyBytes) + `"}`,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantCode:    errCodeBodyTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			params := parameters{}
+			err := decodeJSON(w, r, &params)
+			if err == nil {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				respondWithRequestError(w, err)
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (error: %v)", w.Code, tt.wantStatus, err)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+
+			resp := errorResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("error response isn't JSON: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+			if tt.wantField != "" && (len(resp.Details) != 1 || resp.Details[0].Field != tt.wantField) {
+				t.Errorf("details = %+v, want field %q", resp.Details, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidationErrorsReportEveryField(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	resp, err := http.Post(srv.URL+"/api/users", "application/json", strings.NewReader(`{"email":"not-an-email"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	body := errorResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != errCodeValidation {
+		t.Errorf("code = %q, want %q", body.Code, errCodeValidation)
+	}
+	fields := map[string]bool{}
+	for _, d := range body.Details {
+		fields[d.Field] = true
+	}
+	if !fields["email"] || !fields["password"] {
+		t.Errorf("details = %+v, want problems with email and password", body.Details)
+	}
+}
+
+func TestPolkaWebhookIsLenient(t *testing.T) {
+	ctx := context.Background()
+	srv, cfg := newTestServer(t)
+	alice, err := client.New(srv.URL).CreateUser(ctx, client.Credentials{Email: "alice@example.com", Password: "password"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	body := `{"event": "user.upgraded", "data": {"user_id": "` + alice.ID.String() + `", "plan": "gold"}, "sent_at": 1}`
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/api/polka/webhooks", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "ApiKey "+cfg.polkaKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d for a payload without a Content-Type and with extra fields", resp.StatusCode, http.StatusNoContent)
+	}
+	user, err := cfg.db.GetUserByEmail(ctx, alice.Email)
+	if err != nil || !user.IsChirpyRed {
+		t.Errorf("GetUserByEmail() = %+v, %v, want an upgraded user", user, err)
+	}
+}
@@ -9,8 +9,11 @@ func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The reset deletes whoever is signed in, so find them first.
+	actor := cfg.optionalUser(r)
 	cfg.fileserverHits.Store(0)
 	cfg.db.Reset(r.Context())
+	cfg.audit(r, auditRecord{Action: auditAdminReset, ActorID: actor.ID})
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Hits reset to 0 and database reset to initial state."))
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"io/fs"
+	"net/http"
+
+	"github.com/lsherman98/boot.dev/chirpy/internal/static"
+)
+
+// routeRegistrar is the part of *http.ServeMux that registerRoutes uses,
+// so tests can record the registered patterns.
+type routeRegistrar interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+func (cfg *apiConfig) registerRoutes(mux routeRegistrar, appFiles fs.FS) {
+	fsHandler := cfg.middlewareMetricsInc(http.StripPrefix("/app", static.NewHandler(appFiles)))
+	mux.Handle("/app/", fsHandler)
+
+	mux.HandleFunc("GET /api/healthz", withCacheControl(cacheNoStore, handlerReadiness))
+	mux.HandleFunc("GET /api/openapi.json", withCacheControl(cacheShort, handlerOpenAPI))
+
+	mux.HandleFunc("POST /api/polka/webhooks", cfg.middlewareRateLimit(rateLimitWebhook, cfg.handlerWebhook))
+
+	mux.HandleFunc("POST /api/login", withCacheControl(cacheNoStore, cfg.middlewareRateLimit(rateLimitLogin, cfg.handlerLogin)))
+	mux.HandleFunc("POST /api/refresh", withCacheControl(cacheNoStore, cfg.handlerRefresh))
+	mux.HandleFunc("POST /api/revoke", cfg.handlerRevoke)
+	mux.HandleFunc("GET /api/auth/{provider}/login", withCacheControl(cacheNoStore, cfg.handlerOIDCLogin))
+	mux.HandleFunc("GET /api/auth/{provider}/callback", withCacheControl(cacheNoStore, cfg.middlewareRateLimit(rateLimitLogin, cfg.handlerOIDCCallback)))
+	mux.HandleFunc("POST /api/password-reset", cfg.middlewareRateLimit(rateLimitPasswordReset, cfg.handlerPasswordResetRequest))
+	mux.HandleFunc("POST /api/password-reset/confirm", cfg.middlewareRateLimit(rateLimitPasswordReset, cfg.handlerPasswordResetConfirm))
+
+	mux.HandleFunc("POST /api/users", cfg.handlerUsersCreate)
+	mux.HandleFunc("PUT /api/users", cfg.middlewareCanPost(cfg.handlerUsersUpdate))
+	mux.HandleFunc("GET /api/users/me/export", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerUsersExport)))
+	mux.HandleFunc("DELETE /api/users/me", cfg.middlewareAuth(cfg.handlerUsersDelete))
+	mux.HandleFunc("GET /api/users/{handle}", withCacheControl(cacheRevalidate, cfg.handlerProfileGet))
+	mux.HandleFunc("GET /api/users/{handle}/chirps", withCacheControl(cacheRevalidate, cfg.handlerProfileChirps))
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.middlewareCanPost(cfg.handlerUsersFollow))
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.middlewareAuth(cfg.handlerUsersUnfollow))
+	mux.HandleFunc("POST /api/users/{userID}/block", cfg.middlewareAuth(cfg.handlerUsersBlock))
+	mux.HandleFunc("DELETE /api/users/{userID}/block", cfg.middlewareAuth(cfg.handlerUsersUnblock))
+	mux.HandleFunc("POST /api/users/{userID}/mute", cfg.middlewareAuth(cfg.handlerUsersMute))
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", cfg.middlewareAuth(cfg.handlerUsersUnmute))
+
+	mux.HandleFunc("POST /api/chirps", cfg.middlewareRateLimit(rateLimitChirpCreate, cfg.middlewareCanPost(cfg.handlerChirpsCreate)))
+	mux.HandleFunc("GET /api/chirps", withCacheControl(cacheRevalidate, cfg.handlerChirpsRetrieve))
+	mux.HandleFunc("GET /api/chirps/stream", cfg.handlerChirpsStream)
+	mux.HandleFunc("POST /api/chirps/import", cfg.middlewareRateLimit(rateLimitChirpImport, cfg.middlewareCanPost(cfg.handlerChirpsImport)))
+	mux.HandleFunc("GET /api/chirps/export", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerChirpsExport)))
+	mux.HandleFunc("GET /api/chirps/{chirpID}", withCacheControl(cacheRevalidate, cfg.handlerChirpsGet))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.middlewareAuth(cfg.handlerChirpsDelete))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/likes", cfg.middlewareCanPost(cfg.handlerChirpsLike))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", cfg.middlewareAuth(cfg.handlerChirpsUnlike))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/report", cfg.middlewareCanPost(cfg.handlerChirpsReport))
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", cfg.middlewareAuth(cfg.handlerChirpsBookmark))
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.middlewareAuth(cfg.handlerChirpsUnbookmark))
+	mux.HandleFunc("GET /api/bookmarks", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerBookmarksList)))
+
+	mux.HandleFunc("POST /api/lists", cfg.middlewareAuth(cfg.handlerListsCreate))
+	mux.HandleFunc("GET /api/lists", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerListsList)))
+	mux.HandleFunc("GET /api/lists/{listID}", withCacheControl(cacheRevalidate, cfg.handlerListGet))
+	mux.HandleFunc("DELETE /api/lists/{listID}", cfg.middlewareAuth(cfg.handlerListsDelete))
+	mux.HandleFunc("PUT /api/lists/{listID}/members/{userID}", cfg.middlewareAuth(cfg.handlerListMembersAdd))
+	mux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", cfg.middlewareAuth(cfg.handlerListMembersRemove))
+	mux.HandleFunc("GET /api/lists/{listID}/chirps", withCacheControl(cacheRevalidate, cfg.handlerListChirps))
+
+	mux.HandleFunc("GET /api/scheduled-chirps", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerScheduledChirpsList)))
+	mux.HandleFunc("PUT /api/scheduled-chirps/{chirpID}", cfg.middlewareCanPost(cfg.handlerScheduledChirpsUpdate))
+	mux.HandleFunc("DELETE /api/scheduled-chirps/{chirpID}", cfg.middlewareAuth(cfg.handlerScheduledChirpsCancel))
+
+	mux.HandleFunc("GET /api/hashtags/trending", withCacheControl(cacheShort, cfg.handlerHashtagsTrending))
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", withCacheControl(cacheRevalidate, cfg.handlerHashtagChirps))
+
+	mux.HandleFunc("POST /api/conversations", cfg.middlewareCanPost(cfg.handlerConversationsCreate))
+	mux.HandleFunc("GET /api/conversations", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerConversationsList)))
+	mux.HandleFunc("GET /api/conversations/{conversationID}", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerConversationGet)))
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerMessagesList)))
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", cfg.middlewareRateLimit(rateLimitMessageCreate, cfg.middlewareCanPost(cfg.handlerMessagesCreate)))
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", cfg.middlewareAuth(cfg.handlerConversationRead))
+
+	mux.HandleFunc("GET /api/notifications", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerNotificationsList)))
+	mux.HandleFunc("POST /api/notifications/read", cfg.middlewareAuth(cfg.handlerNotificationsRead))
+
+	mux.HandleFunc("POST /api/webhooks", cfg.middlewareAuth(cfg.handlerWebhookEndpointsCreate))
+	mux.HandleFunc("GET /api/webhooks", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerWebhookEndpointsList)))
+	mux.HandleFunc("DELETE /api/webhooks/{webhookID}", cfg.middlewareAuth(cfg.handlerWebhookEndpointsDelete))
+	mux.HandleFunc("GET /api/webhooks/{webhookID}/deliveries", withCacheControl(cacheNoStore, cfg.middlewareAuth(cfg.handlerWebhookDeliveriesList)))
+
+	mux.HandleFunc("POST /admin/reset", cfg.handlerReset)
+	mux.HandleFunc("GET /admin/metrics", withCacheControl(cacheNoStore, cfg.handlerMetrics))
+	mux.HandleFunc("GET /admin/moderation/queue", withCacheControl(cacheNoStore, cfg.middlewareAdmin(cfg.handlerModerationQueue)))
+	mux.HandleFunc("POST /admin/moderation/chirps/{chirpID}", cfg.middlewareAdmin(cfg.handlerModerationAction))
+	mux.HandleFunc("GET /admin/audit", withCacheControl(cacheNoStore, cfg.middlewareAdmin(cfg.handlerAuditList)))
+	mux.HandleFunc("GET /admin/audit/export", withCacheControl(cacheNoStore, cfg.middlewareAdmin(cfg.handlerAuditExport)))
+}
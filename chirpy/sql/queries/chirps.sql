@@ -11,12 +11,61 @@ RETURNING *;
 
 -- name: GetChirps :many
 SELECT * FROM chirps
+WHERE NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE muter_id = sqlc.arg(viewer_id) AND muted_id = chirps.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = sqlc.arg(viewer_id) AND blocked_id = chirps.user_id)
+    OR (blocker_id = chirps.user_id AND blocked_id = sqlc.arg(viewer_id))
+)
 ORDER BY created_at ASC;
 
 -- name: GetChirp :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE id = $1;
+
+-- name: GetChirpsByUser :many
+SELECT * FROM chirps
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: PublishScheduledChirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+VALUES (
+    $1,
+    $2,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: SetChirpHidden :one
+UPDATE chirps SET hidden_at = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ImportChirps :many
+INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+SELECT gen_random_uuid(), imported.created_at, imported.created_at, imported.body, sqlc.arg(user_id)
+FROM unnest(sqlc.arg(bodies)::text[], sqlc.arg(created_ats)::timestamp[]) AS imported(body, created_at)
+RETURNING *;
+
+-- name: GetChirpsByUserPage :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+AND (sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(max_chirps);
@@ -0,0 +1,38 @@
+-- name: AddChirpHashtag :exec
+-- created_at is the chirp's, so imported chirps don't trend as new.
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpsByHashtag :many
+-- A page of the chirps using a hashtag that the viewer can see, oldest
+-- first or, with newest_first, newest first, starting after the cursor
+-- row if there is one.
+SELECT chirps.* FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+WHERE chirp_hashtags.tag = sqlc.arg(tag)
+AND (chirps.hidden_at IS NULL OR chirps.user_id = sqlc.arg(viewer_id) OR sqlc.arg(viewer_is_admin)::bool)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE muter_id = sqlc.arg(viewer_id) AND muted_id = chirps.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = sqlc.arg(viewer_id) AND blocked_id = chirps.user_id)
+    OR (blocker_id = chirps.user_id AND blocked_id = sqlc.arg(viewer_id))
+)
+AND (sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (sqlc.arg(newest_first)::bool AND (chirps.created_at, chirps.id) < (sqlc.narg(after_created_at), sqlc.narg(after_id)::uuid))
+    OR (NOT sqlc.arg(newest_first)::bool AND (chirps.created_at, chirps.id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::uuid)))
+ORDER BY
+    CASE WHEN sqlc.arg(newest_first)::bool THEN chirps.created_at END DESC,
+    CASE WHEN sqlc.arg(newest_first)::bool THEN chirps.id END DESC,
+    chirps.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg(max_chirps);
+
+-- name: GetTrendingHashtags :many
+SELECT tag, COUNT(*) AS uses FROM chirp_hashtags
+WHERE created_at >= sqlc.arg(since)
+GROUP BY tag
+ORDER BY uses DESC, tag ASC
+LIMIT sqlc.arg(max_tags);
@@ -0,0 +1,50 @@
+-- name: QueueLinkPreview :exec
+INSERT INTO link_previews (url, created_at, updated_at, next_attempt_at)
+VALUES ($1, NOW(), NOW(), NOW())
+ON CONFLICT (url) DO UPDATE SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+WHERE link_previews.status <> 'pending' AND link_previews.updated_at < sqlc.arg(stale_before);
+
+-- name: AddChirpLink :exec
+INSERT INTO chirp_links (chirp_id, url, position)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: ClaimLinkPreviews :many
+UPDATE link_previews SET next_attempt_at = sqlc.arg(lease_until)
+WHERE url IN (
+    SELECT url FROM link_previews
+    WHERE status = 'pending'
+    AND next_attempt_at <= sqlc.arg(now)
+    ORDER BY next_attempt_at ASC
+    LIMIT sqlc.arg(max_previews)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateLinkPreview :one
+UPDATE link_previews SET
+    status = $2,
+    attempts = $3,
+    next_attempt_at = $4,
+    title = $5,
+    description = $6,
+    image_url = $7,
+    site_name = $8,
+    updated_at = NOW()
+WHERE url = $1
+RETURNING *;
+
+-- name: TouchLinkingChirps :exec
+UPDATE chirps SET updated_at = NOW()
+FROM chirp_links
+WHERE chirp_links.chirp_id = chirps.id
+AND chirp_links.url = $1;
+
+-- name: GetChirpLinkPreviews :many
+SELECT chirp_links.chirp_id, link_previews.url, link_previews.title, link_previews.description,
+    link_previews.image_url, link_previews.site_name
+FROM chirp_links
+JOIN link_previews ON link_previews.url = chirp_links.url
+WHERE chirp_links.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+AND link_previews.status = 'fetched'
+ORDER BY chirp_links.chirp_id, chirp_links.position;
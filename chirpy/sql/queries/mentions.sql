@@ -0,0 +1,4 @@
+-- name: AddChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,91 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, user_low, user_high)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    LEAST(sqlc.arg(user_a)::uuid, sqlc.arg(user_b)::uuid),
+    GREATEST(sqlc.arg(user_a)::uuid, sqlc.arg(user_b)::uuid)
+)
+ON CONFLICT (user_low, user_high) DO NOTHING
+RETURNING *;
+
+-- name: GetConversationBetween :one
+SELECT * FROM conversations
+WHERE user_low = LEAST(sqlc.arg(user_a)::uuid, sqlc.arg(user_b)::uuid)
+AND user_high = GREATEST(sqlc.arg(user_a)::uuid, sqlc.arg(user_b)::uuid);
+
+-- name: GetConversationForParticipant :one
+SELECT conversations.* FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(id)
+AND conversation_participants.user_id = sqlc.arg(user_id);
+
+-- name: GetConversationsByUser :many
+SELECT conversations.*, (
+    SELECT COUNT(*) FROM messages
+    WHERE messages.conversation_id = conversations.id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND (conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at)
+) AS unread_count
+FROM conversations
+JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = sqlc.arg(user_id)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg(max_conversations);
+
+-- name: CountUnreadMessages :one
+SELECT COUNT(*) FROM messages
+JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id
+WHERE messages.conversation_id = sqlc.arg(conversation_id)
+AND conversation_participants.user_id = sqlc.arg(user_id)
+AND messages.sender_id <> conversation_participants.user_id
+AND (conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at);
+
+-- name: SetConversationUpdatedAt :exec
+UPDATE conversations SET updated_at = $2
+WHERE id = $1;
+
+-- name: AddConversationParticipant :exec
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversationParticipants :many
+SELECT * FROM conversation_participants
+WHERE conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+ORDER BY conversation_id, joined_at, user_id;
+
+-- name: MarkConversationRead :one
+UPDATE conversation_participants SET last_read_at = GREATEST(last_read_at, sqlc.arg(read_at))
+WHERE conversation_id = sqlc.arg(conversation_id)
+AND user_id = sqlc.arg(user_id)
+RETURNING *;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: GetMessage :one
+SELECT * FROM messages
+WHERE id = $1 AND conversation_id = $2;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_messages);
+
+-- name: GetMessagesBySender :many
+SELECT * FROM messages
+WHERE sender_id = $1
+ORDER BY created_at ASC;
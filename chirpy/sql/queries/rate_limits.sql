@@ -0,0 +1,21 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+VALUES (
+    sqlc.arg(key),
+    sqlc.arg(burst)::float8 - 1,
+    true,
+    NOW()
+)
+ON CONFLICT (key) DO UPDATE SET
+    tokens = CASE
+        WHEN LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * sqlc.arg(rate)::float8) >= 1
+        THEN LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * sqlc.arg(rate)::float8) - 1
+        ELSE LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * sqlc.arg(rate)::float8)
+    END,
+    allowed = LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM NOW() - rate_limit_buckets.updated_at)::float8 * sqlc.arg(rate)::float8) >= 1,
+    updated_at = NOW()
+RETURNING tokens, allowed;
+
+-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < NOW() - make_interval(secs => sqlc.arg(idle_seconds)::float8);
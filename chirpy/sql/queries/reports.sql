@@ -0,0 +1,26 @@
+-- name: CreateChirpReport :execrows
+INSERT INTO chirp_reports (id, created_at, chirp_id, reporter_id, reason, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (chirp_id, reporter_id) WHERE resolved_at IS NULL DO NOTHING;
+
+-- name: GetModerationQueue :many
+SELECT chirp_id, COUNT(*) AS report_count,
+array_agg(DISTINCT reason)::text[] AS reasons,
+MIN(created_at)::timestamp AS first_reported_at
+FROM chirp_reports
+WHERE resolved_at IS NULL
+GROUP BY chirp_id
+ORDER BY report_count DESC, first_reported_at ASC
+LIMIT sqlc.arg(max_chirps);
+
+-- name: ResolveChirpReports :execrows
+UPDATE chirp_reports SET resolved_at = NOW(), resolution = $2
+WHERE chirp_id = $1
+AND resolved_at IS NULL;
@@ -0,0 +1,60 @@
+-- name: CreateScheduledChirp :one
+INSERT INTO scheduled_chirps (id, created_at, updated_at, user_id, body, publish_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: GetScheduledChirp :one
+SELECT * FROM scheduled_chirps
+WHERE id = $1;
+
+-- name: GetScheduledChirpForUpdate :one
+SELECT * FROM scheduled_chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetScheduledChirpsByUser :many
+SELECT * FROM scheduled_chirps
+WHERE user_id = $1
+ORDER BY publish_at ASC;
+
+-- name: UpdateScheduledChirp :one
+UPDATE scheduled_chirps SET body = $2, publish_at = $3, failed_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM scheduled_chirps
+WHERE id = $1;
+
+-- name: DeleteScheduledChirpsByUser :exec
+DELETE FROM scheduled_chirps
+WHERE user_id = $1;
+
+-- name: ClaimDueScheduledChirps :many
+-- Locks the due chirps for the rest of the transaction. Other publishers
+-- skip them rather than waiting, so replicas can publish side by side.
+-- Chirps by suspended authors wait for the suspension to end, ones by
+-- deleted authors are never published, and ones that failed to publish
+-- since retry_failed_before wait.
+SELECT * FROM scheduled_chirps
+WHERE publish_at <= sqlc.arg(now)
+AND (failed_at IS NULL OR failed_at < sqlc.arg(retry_failed_before))
+AND NOT EXISTS (
+    SELECT 1 FROM users
+    WHERE users.id = scheduled_chirps.user_id
+    AND (users.suspended_until > sqlc.arg(now) OR users.deleted_at IS NOT NULL)
+)
+ORDER BY publish_at ASC
+LIMIT sqlc.arg(max_chirps)
+FOR UPDATE OF scheduled_chirps SKIP LOCKED;
+
+-- name: MarkScheduledChirpFailed :exec
+UPDATE scheduled_chirps SET failed_at = $2
+WHERE id = $1;
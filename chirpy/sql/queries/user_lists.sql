@@ -0,0 +1,67 @@
+-- name: CreateUserList :one
+INSERT INTO user_lists (id, created_at, updated_at, owner_id, name, is_private)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: GetUserList :one
+-- Private lists are only visible to their owner.
+SELECT * FROM user_lists
+WHERE id = sqlc.arg(id)
+AND (NOT is_private OR owner_id = sqlc.arg(viewer_id));
+
+-- name: GetUserListsByOwner :many
+SELECT * FROM user_lists
+WHERE owner_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteUserList :execrows
+DELETE FROM user_lists
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id);
+
+-- name: AddUserListMember :execrows
+INSERT INTO user_list_members (list_id, user_id, added_at)
+SELECT id, sqlc.arg(user_id), NOW() FROM user_lists
+WHERE id = sqlc.arg(list_id) AND owner_id = sqlc.arg(owner_id)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveUserListMember :exec
+DELETE FROM user_list_members
+USING user_lists
+WHERE user_lists.id = user_list_members.list_id
+AND user_list_members.list_id = sqlc.arg(list_id)
+AND user_list_members.user_id = sqlc.arg(user_id)
+AND user_lists.owner_id = sqlc.arg(owner_id);
+
+-- name: GetUserListMembers :many
+SELECT * FROM user_list_members
+WHERE list_id = ANY(sqlc.arg(list_ids)::uuid[])
+ORDER BY list_id, added_at, user_id;
+
+-- name: GetUserListChirps :many
+-- The chirps of a list's members that the viewer can see, newest first.
+SELECT chirps.* FROM chirps
+JOIN user_list_members ON user_list_members.user_id = chirps.user_id
+JOIN user_lists ON user_lists.id = user_list_members.list_id
+WHERE user_lists.id = sqlc.arg(list_id)
+AND (NOT user_lists.is_private OR user_lists.owner_id = sqlc.arg(viewer_id))
+AND (chirps.hidden_at IS NULL OR chirps.user_id = sqlc.arg(viewer_id) OR sqlc.arg(viewer_is_admin)::bool)
+AND NOT EXISTS (
+    SELECT 1 FROM user_mutes
+    WHERE muter_id = sqlc.arg(viewer_id) AND muted_id = chirps.user_id
+)
+AND NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = sqlc.arg(viewer_id) AND blocked_id = chirps.user_id)
+    OR (blocker_id = chirps.user_id AND blocked_id = sqlc.arg(viewer_id))
+)
+AND (sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(max_chirps);
@@ -0,0 +1,15 @@
+-- name: MuteUser :execrows
+INSERT INTO user_mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnmuteUser :exec
+DELETE FROM user_mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetHiddenAuthorIDs :many
+SELECT muted_id FROM user_mutes WHERE muter_id = $1
+UNION
+SELECT blocked_id FROM user_blocks WHERE blocker_id = $1
+UNION
+SELECT blocker_id FROM user_blocks WHERE blocked_id = $1;
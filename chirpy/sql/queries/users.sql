@@ -18,7 +18,57 @@ UPDATE users SET email = $2, hashed_password = $3, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: SetUserPassword :one
+UPDATE users SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
 -- name: UpgradeToChirpyRed :one
 UPDATE users SET is_chirpy_red = true, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByIDForUpdate :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SetUserAdmin :one
+UPDATE users SET is_admin = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserByHandle :one
+SELECT * FROM users
+WHERE lower(handle) = lower(sqlc.arg(handle));
+
+-- name: UpdateUserProfile :one
+UPDATE users SET handle = $2, display_name = $3, bio = $4, avatar_url = $5, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
+
+-- name: AnonymizeUser :one
+UPDATE users SET email = 'deleted-' || id || '@users.invalid', hashed_password = '',
+handle = NULL, display_name = '', bio = '', avatar_url = '',
+is_chirpy_red = FALSE, is_admin = FALSE, strikes = 0, suspended_until = NULL,
+deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: AddUserStrike :one
+UPDATE users SET strikes = strikes + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SuspendUser :one
+UPDATE users SET suspended_until = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,80 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (id, created_at, updated_at, user_id, url, secret, events, is_global)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: GetWebhookEndpointsByUser :many
+SELECT * FROM webhook_endpoints
+WHERE user_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhookEndpoint :exec
+DELETE FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: DeleteWebhookEndpointsByUser :exec
+DELETE FROM webhook_endpoints
+WHERE user_id = $1;
+
+-- name: EnqueueWebhookDeliveries :exec
+INSERT INTO webhook_deliveries (id, created_at, updated_at, endpoint_id, event_id, event_type, payload, next_attempt_at)
+SELECT gen_random_uuid(), NOW(), NOW(), webhook_endpoints.id, sqlc.arg(event_id), sqlc.arg(event_type)::text, sqlc.arg(payload), NOW()
+FROM webhook_endpoints
+WHERE sqlc.arg(event_type)::text = ANY(webhook_endpoints.events)
+AND (webhook_endpoints.is_global OR webhook_endpoints.user_id = sqlc.arg(subject_id));
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries SET next_attempt_at = sqlc.arg(lease_until), updated_at = NOW()
+FROM webhook_endpoints
+WHERE webhook_deliveries.endpoint_id = webhook_endpoints.id
+AND webhook_deliveries.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending'
+    AND next_attempt_at <= sqlc.arg(now)
+    ORDER BY next_attempt_at ASC
+    LIMIT sqlc.arg(max_deliveries)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING webhook_deliveries.*, webhook_endpoints.url, webhook_endpoints.secret;
+
+-- name: RecordWebhookAttempt :one
+INSERT INTO webhook_attempts (id, created_at, delivery_id, status_code, response_body, error, duration_ms)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: UpdateWebhookDelivery :one
+UPDATE webhook_deliveries SET status = $2, attempts = $3, next_attempt_at = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: GetWebhookAttempts :many
+SELECT * FROM webhook_attempts
+WHERE delivery_id = ANY(sqlc.arg(delivery_ids)::uuid[])
+ORDER BY created_at ASC;
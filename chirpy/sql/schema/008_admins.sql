@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN is_admin BOOLEAN NOT NULL
+DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_admin;
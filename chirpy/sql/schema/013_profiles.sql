@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN handle TEXT,
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+-- Keep in sync with the handle rules in internal/database/handles.go.
+ALTER TABLE users
+ADD CONSTRAINT users_handle_format CHECK (handle ~ '^[A-Za-z0-9_]{3,30}$'),
+ADD CONSTRAINT users_handle_reserved CHECK (lower(handle) NOT IN (
+    'admin', 'administrator', 'api', 'app', 'assets', 'chirpy', 'help',
+    'me', 'mod', 'moderator', 'null', 'root', 'settings', 'support',
+    'system', 'undefined'
+)),
+ADD CONSTRAINT users_display_name_length CHECK (char_length(display_name) <= 50),
+ADD CONSTRAINT users_bio_length CHECK (char_length(bio) <= 160),
+ADD CONSTRAINT users_avatar_url_scheme CHECK (avatar_url = '' OR avatar_url ~ '^https?://');
+
+CREATE UNIQUE INDEX users_handle_lower_idx ON users (lower(handle));
+
+-- +goose Down
+DROP INDEX users_handle_lower_idx;
+
+ALTER TABLE users
+DROP COLUMN avatar_url,
+DROP COLUMN bio,
+DROP COLUMN display_name,
+DROP COLUMN handle;
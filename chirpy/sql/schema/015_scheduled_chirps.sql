@@ -0,0 +1,20 @@
+-- +goose Up
+-- Scheduled chirps wait here until they are due, when the publisher moves
+-- them into chirps under the same ID.
+CREATE TABLE scheduled_chirps (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    publish_at TIMESTAMP NOT NULL,
+    -- failed_at is when publishing last failed. The publisher leaves the
+    -- chirp alone for a while before trying again.
+    failed_at TIMESTAMP
+);
+
+CREATE INDEX scheduled_chirps_publish_at_idx ON scheduled_chirps (publish_at);
+CREATE INDEX scheduled_chirps_user_idx ON scheduled_chirps (user_id, publish_at);
+
+-- +goose Down
+DROP TABLE scheduled_chirps;
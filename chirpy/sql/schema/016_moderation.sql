@@ -0,0 +1,32 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP;
+
+ALTER TABLE users
+ADD COLUMN strikes INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN suspended_until TIMESTAMP;
+
+CREATE TABLE chirp_reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    resolved_at TIMESTAMP,
+    resolution TEXT
+);
+
+-- A user has at most one open report per chirp.
+CREATE UNIQUE INDEX chirp_reports_open_idx ON chirp_reports (chirp_id, reporter_id)
+WHERE resolved_at IS NULL;
+
+-- +goose Down
+DROP TABLE chirp_reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_until,
+DROP COLUMN strikes;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- An identity is a user's account with an OpenID Connect provider. The
+-- issuer and subject together identify it; the email is whatever the
+-- provider reported when it was linked.
+CREATE TABLE user_identities (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX user_identities_user_idx ON user_identities (user_id);
+
+-- +goose Down
+DROP TABLE user_identities;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- A conversation is between two users. They are stored in UUID order so
+-- the pair can only have one conversation.
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_low UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_high UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_low, user_high),
+    CHECK (user_low < user_high)
+);
+
+-- last_read_at is the creation time of the newest message the user has
+-- read, which is what read receipts are made from.
+CREATE TABLE conversation_participants (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX conversation_participants_user_idx ON conversation_participants (user_id);
+
+CREATE TABLE messages (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL
+);
+
+CREATE INDEX messages_conversation_idx ON messages (conversation_id, created_at DESC, id DESC);
+CREATE INDEX messages_sender_idx ON messages (sender_id);
+
+-- +goose Down
+DROP TABLE messages;
+DROP TABLE conversation_participants;
+DROP TABLE conversations;
@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE bookmarks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_created_idx ON bookmarks (user_id, created_at DESC, chirp_id DESC);
+
+CREATE TABLE user_lists (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    is_private BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX user_lists_owner_idx ON user_lists (owner_id, created_at);
+
+CREATE TABLE user_list_members (
+    list_id UUID NOT NULL REFERENCES user_lists(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    added_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX user_list_members_user_idx ON user_list_members (user_id);
+CREATE INDEX chirps_user_created_idx ON chirps (user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX chirps_user_created_idx;
+DROP TABLE user_list_members;
+DROP TABLE user_lists;
+DROP TABLE bookmarks;
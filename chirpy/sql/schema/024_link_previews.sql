@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE link_previews (
+    url TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    image_url TEXT NOT NULL DEFAULT '',
+    site_name TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX link_previews_due_idx ON link_previews (next_attempt_at)
+WHERE status = 'pending';
+
+CREATE TABLE chirp_links (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    url TEXT NOT NULL REFERENCES link_previews(url),
+    position INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, url)
+);
+
+CREATE INDEX chirp_links_url_idx ON chirp_links (url);
+
+-- +goose Down
+DROP TABLE chirp_links;
+DROP TABLE link_previews;
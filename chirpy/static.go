@@ -0,0 +1,9 @@
+package main
+
+import "embed"
+
+// embeddedAppFiles is the web app served under /app/ when STATIC_EMBED is
+// set. Precompressed .gz and .zst copies in assets/ are embedded too.
+//
+//go:embed index.html assets
+var embeddedAppFiles embed.FS